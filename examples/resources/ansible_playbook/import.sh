@@ -0,0 +1,3 @@
+# Import with the path of the playbook and of an inventory file, so the
+# playbook only runs again if the configuration or the files change
+terraform import ansible_playbook.hello_world hello_world.yml:inventory.yml
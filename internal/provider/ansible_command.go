@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var binaryChecks = []string{"strict", "lenient"}
+
+// Build the command to run an ansible binary, with the environment of the
+// provider plus env.
+//
+// If the binary isn't found and python_interpreter is configured, it's run
+// as `<python_interpreter> -m ansible <command>` instead, or as
+// `-m ansiblelint` for ansible-lint. On Windows, ansible
+// is run in WSL, unless the binary is found on the PATH, e.g. as a .cmd
+// wrapper.
+func (d *ProviderData) AnsibleCommand(ctx context.Context, binary string, env []string, args ...string) *exec.Cmd {
+	if python := d.GetPythonInterpreter(); len(python) > 0 {
+		if _, err := exec.LookPath(binary); err != nil {
+			args = append(pythonModuleArgs(binary), args...)
+			binary = python
+		}
+	}
+
+	// The variables of the provider override the ones of the process, and
+	// are overridden by env
+	env = append(environmentList(d.GetEnvironment()), env...)
+	name, args, env := resolveAnsibleCommand(binary, args, env)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd
+}
+
+// Log the command line of cmd, redacted by redactor and without the values of
+// the variables of its environment that look like secrets
+func logCommand(ctx context.Context, cmd *exec.Cmd, redactor *Redactor) {
+	redactor = redactor.WithSecrets(sensitiveEnvironmentValues(cmd.Env)...)
+	tflog.Debug(ctx, redactor.Redact(fmt.Sprintf("Running %s", cmd.String())))
+}
+
+// Whether ansible_playbook_binary can be run during plan. If it isn't found,
+// that's an error with the strict binary_check. Otherwise it's only a warning
+// if a check that runs ansible during plan is deferred to the apply. In
+// containers, the binary is only found when the container runs.
+func ansibleFoundAtPlan(ctx context.Context, data *PlaybookResourceModel, providerData *ProviderData, diags *diag.Diagnostics) bool {
+	if data.AnsiblePlaybookBinary.IsUnknown() || data.ContainerImage.IsUnknown() || runsInContainer(data) {
+		return true
+	}
+
+	runsAtPlan := !data.AnsibleVersionConstraint.IsNull() || !data.CollectionsLockFile.IsNull() ||
+		data.PreviewHosts.ValueBool() || data.PredictChanges.ValueBool()
+	if !runsAtPlan && !providerData.GetStrictBinaryCheck() {
+		return true
+	}
+
+	binary := data.AnsiblePlaybookBinary.ValueString()
+	// Paths aren't looked up when the command is built
+	cmd := providerData.AnsibleCommand(ctx, binary, nil)
+	err := cmd.Err
+	if err == nil {
+		_, err = exec.LookPath(cmd.Path)
+	}
+	if err == nil {
+		return true
+	}
+
+	if providerData.GetStrictBinaryCheck() {
+		diags.AddAttributeError(path.Root("ansible_playbook_binary"), "Ansible not found", err.Error())
+	} else {
+		diags.AddAttributeWarning(path.Root("ansible_playbook_binary"), "Ansible not found",
+			err.Error()+"\nThe checks that run ansible during plan are deferred to the apply.")
+	}
+	return false
+}
+
+// The arguments to run a binary as a python module
+func pythonModuleArgs(binary string) []string {
+	subcommand := ansibleSubcommand(binary)
+	if subcommand == "lint" {
+		// ansible-lint is a separate package
+		return []string{"-m", "ansiblelint"}
+	}
+	return []string{"-m", "ansible", subcommand}
+}
+
+// The subcommand of `python -m ansible` for a binary, e.g. "playbook" for
+// "/usr/bin/ansible-playbook". ansible itself is the "adhoc" one.
+func ansibleSubcommand(binary string) string {
+	name := filepath.Base(binary)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if name == "ansible" {
+		return "adhoc"
+	}
+	return strings.TrimPrefix(name, "ansible-")
+}
@@ -0,0 +1,46 @@
+//go:build !windows
+
+package provider
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Progress events are written to an inherited file descriptor
+const progressStreamingSupported = true
+
+// The control sockets of ssh are on the same machine as the provider
+const controlMastersStoppable = true
+
+func resolveAnsibleCommand(binary string, args []string, env []string) (string, []string, []string) {
+	return binary, args, env
+}
+
+// Start the process in a process group of its own, so it can be stopped
+// together with its children, like the ssh connections of ansible
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// Stop ansible like Ctrl+C would, so it can clean up on the hosts
+func interruptProcess(process *os.Process) error {
+	return signalProcessGroup(process, syscall.SIGINT)
+}
+
+// Stop ansible if it didn't stop after the interrupt
+func terminateProcess(process *os.Process) error {
+	return signalProcessGroup(process, syscall.SIGTERM)
+}
+
+// Kill what's left of the process group, e.g. children that outlived ansible
+func killProcessGroup(process *os.Process) error {
+	return signalProcessGroup(process, syscall.SIGKILL)
+}
+
+// Signal the process group of setProcessGroup, which is still there if the
+// process itself already exited
+func signalProcessGroup(process *os.Process, signal syscall.Signal) error {
+	return syscall.Kill(-process.Pid, signal)
+}
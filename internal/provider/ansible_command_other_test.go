@@ -0,0 +1,75 @@
+//go:build !windows
+
+package provider
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalProcessGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		signal func(process *os.Process) error
+		want   syscall.Signal
+	}{
+		// Background jobs of a non-interactive shell ignore SIGINT, so only
+		// the process itself is interrupted
+		{name: "interrupt", script: "exec sleep 60", signal: interruptProcess, want: syscall.SIGINT},
+		{name: "terminate", script: "sleep 60 & exec sleep 60", signal: terminateProcess, want: syscall.SIGTERM},
+		{name: "kill", script: "sleep 60 & exec sleep 60", signal: killProcessGroup, want: syscall.SIGKILL},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Every process of the group inherits the write end, so the read
+			// end only reaches EOF once all of them are gone
+			reader, writer, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+
+			cmd := exec.Command("sh", "-c", test.script)
+			cmd.Stdout = writer
+			setProcessGroup(cmd)
+			if err := cmd.Start(); err != nil {
+				t.Fatal(err)
+			}
+			writer.Close()
+			defer killProcessGroup(cmd.Process)
+
+			// Give the shell time to start the background job and exec
+			time.Sleep(200 * time.Millisecond)
+			if err := test.signal(cmd.Process); err != nil {
+				t.Fatalf("signal: %v", err)
+			}
+
+			var exitError *exec.ExitError
+			if err := cmd.Wait(); !errors.As(err, &exitError) {
+				t.Fatalf("Wait() = %v, want an exit error", err)
+			}
+			status := exitError.Sys().(syscall.WaitStatus)
+			if !status.Signaled() || status.Signal() != test.want {
+				t.Errorf("process exited with %v, want signal %v", status, test.want)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				io.Copy(io.Discard, reader)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Error("processes of the group are still running")
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+//go:build windows
+
+package provider
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Extra files aren't inherited by child processes on Windows
+const progressStreamingSupported = false
+
+// The control sockets of ssh are inside of WSL
+const controlMastersStoppable = false
+
+var windowsPathRegexp = regexp.MustCompile(`^(@?)([A-Za-z]):[\\/](.*)$`)
+
+// Translate an absolute Windows path, optionally prefixed with @ as in
+// `-e @file`, to the path of the same file in WSL. Other values are
+// returned unchanged.
+func wslPath(value string) string {
+	match := windowsPathRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return value
+	}
+	return match[1] + "/mnt/" + strings.ToLower(match[2]) + "/" + strings.ReplaceAll(match[3], `\`, "/")
+}
+
+// Ansible doesn't run on Windows itself. Unless the binary is found on the
+// PATH, including with the extensions of PATHEXT like ansible-playbook.cmd,
+// it's run with `wsl.exe -e` and paths in the arguments and environment are
+// translated.
+func resolveAnsibleCommand(binary string, args []string, env []string) (string, []string, []string) {
+	if _, err := exec.LookPath(binary); err == nil {
+		return binary, args, env
+	}
+	wsl, err := exec.LookPath("wsl.exe")
+	if err != nil {
+		return binary, args, env
+	}
+
+	wslArgs := []string{"-e", wslPath(binary)}
+	for _, arg := range args {
+		wslArgs = append(wslArgs, wslPath(arg))
+	}
+
+	// Variables of Windows processes are only passed to WSL if listed in WSLENV
+	wslEnv := []string{}
+	var names []string
+	for _, variable := range env {
+		name, value, _ := strings.Cut(variable, "=")
+		wslEnv = append(wslEnv, name+"="+wslPath(value))
+		names = append(names, name+"/u")
+	}
+	if existing := os.Getenv("WSLENV"); len(existing) > 0 {
+		names = append([]string{existing}, names...)
+	}
+	if len(names) > 0 {
+		wslEnv = append(wslEnv, "WSLENV="+strings.Join(names, ":"))
+	}
+
+	return wsl, wslArgs, wslEnv
+}
+
+// Process groups can't be signaled on Windows, the process is killed alone
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// Windows can't deliver Ctrl+C to a single child process
+func interruptProcess(process *os.Process) error {
+	return process.Kill()
+}
+
+// The interrupt already killed the process
+func terminateProcess(process *os.Process) error {
+	return process.Kill()
+}
+
+func killProcessGroup(process *os.Process) error {
+	return process.Kill()
+}
@@ -0,0 +1,272 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &AnsibleLintDataSource{}
+var _ datasource.DataSourceWithConfigure = &AnsibleLintDataSource{}
+
+// ansible-lint exits with 2 if it found violations
+const lintExitCodeViolations = 2
+
+func NewAnsibleLintDataSource() datasource.DataSource {
+	return &AnsibleLintDataSource{}
+}
+
+type AnsibleLintDataSource struct {
+	providerData *ProviderData
+}
+
+// AnsibleLintDataSourceModel describes the data source data model.
+type AnsibleLintDataSourceModel struct {
+	Path              types.String `tfsdk:"path"`
+	AnsibleLintBinary types.String `tfsdk:"ansible_lint_binary"`
+	ConfigFile        types.String `tfsdk:"config_file"`
+	Profile           types.String `tfsdk:"profile"`
+	Violations        types.List   `tfsdk:"violations"`
+	SeverityCounts    types.Map    `tfsdk:"severity_counts"`
+}
+
+type LintViolationModel struct {
+	Rule        types.String `tfsdk:"rule"`
+	Description types.String `tfsdk:"description"`
+	Severity    types.String `tfsdk:"severity"`
+	Level       types.String `tfsdk:"level"`
+	Path        types.String `tfsdk:"path"`
+	Line        types.Int64  `tfsdk:"line"`
+}
+
+func (m LintViolationModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"rule":        types.StringType,
+		"description": types.StringType,
+		"severity":    types.StringType,
+		"level":       types.StringType,
+		"path":        types.StringType,
+		"line":        types.Int64Type,
+	}
+}
+
+// A violation of `ansible-lint --format json`, in the Code Climate format
+type LintViolation struct {
+	CheckName   string `json:"check_name"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+	Level       string `json:"level"`
+	Location    struct {
+		Path  string `json:"path"`
+		Lines struct {
+			Begin json.RawMessage `json:"begin"`
+		} `json:"lines"`
+		Positions struct {
+			Begin struct {
+				Line int64 `json:"line"`
+			} `json:"begin"`
+		} `json:"positions"`
+	} `json:"location"`
+}
+
+// The line of the violation, or 0 if it applies to the whole file. Depending
+// on the version, the begin of lines is a number or an object with the line.
+func (v LintViolation) Line() int64 {
+	if v.Location.Positions.Begin.Line > 0 {
+		return v.Location.Positions.Begin.Line
+	}
+
+	var line int64
+	if err := json.Unmarshal(v.Location.Lines.Begin, &line); err == nil {
+		return line
+	}
+	var position struct {
+		Line int64 `json:"line"`
+	}
+	if err := json.Unmarshal(v.Location.Lines.Begin, &position); err == nil {
+		return position.Line
+	}
+	return 0
+}
+
+func ParseLintViolations(output []byte) ([]LintViolation, error) {
+	violations := []LintViolation{}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return violations, nil
+	}
+	if err := json.Unmarshal(output, &violations); err != nil {
+		return nil, err
+	}
+	return violations, nil
+}
+
+func (d *AnsibleLintDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_lint"
+}
+
+func (d *AnsibleLintDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lints a playbook or directory with `ansible-lint --format json`. Violations don't fail the read, so a precondition can decide which are acceptable, e.g. none with severity `blocker` or `critical`.",
+
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				MarkdownDescription: "Path to the playbook or directory to lint.",
+				Required:            true,
+			},
+			"ansible_lint_binary": schema.StringAttribute{
+				MarkdownDescription: "Defaults to `ansible-lint`.",
+				Optional:            true,
+			},
+			"config_file": schema.StringAttribute{
+				MarkdownDescription: "Path to the ansible-lint configuration, passed with `-c`. By default ansible-lint looks for `.ansible-lint` in the project.",
+				Optional:            true,
+			},
+			"profile": schema.StringAttribute{
+				MarkdownDescription: "The profile to lint with, passed with `--profile`, e.g. `production`.",
+				Optional:            true,
+			},
+			"violations": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The violations found, in the order ansible-lint reported them.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"rule": schema.StringAttribute{
+							Computed:    true,
+							Description: "The rule that was violated, e.g. yaml[line-length].",
+						},
+						"description": schema.StringAttribute{
+							Computed:    true,
+							Description: "Description of the violation.",
+						},
+						"severity": schema.StringAttribute{
+							Computed:    true,
+							Description: "One of info, minor, major, critical and blocker.",
+						},
+						"level": schema.StringAttribute{
+							Computed:    true,
+							Description: "error, or warning for rules ansible-lint is configured to only warn about.",
+						},
+						"path": schema.StringAttribute{
+							Computed:    true,
+							Description: "The file of the violation.",
+						},
+						"line": schema.Int64Attribute{
+							Computed:    true,
+							Description: "The line of the violation, or 0 if it applies to the whole file.",
+						},
+					},
+				},
+			},
+			"severity_counts": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.Int64Type,
+				Description: "The number of violations per severity.",
+			},
+		},
+	}
+}
+
+func (d *AnsibleLintDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.providerData = providerData
+}
+
+func (d *AnsibleLintDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data AnsibleLintDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	binary := "ansible-lint"
+	if !data.AnsibleLintBinary.IsNull() {
+		binary = data.AnsibleLintBinary.ValueString()
+	}
+
+	args := []string{"--format", "json", "--nocolor"}
+	if !data.ConfigFile.IsNull() {
+		args = append(args, "-c", data.ConfigFile.ValueString())
+	}
+	if !data.Profile.IsNull() {
+		args = append(args, "--profile", data.Profile.ValueString())
+	}
+	args = append(args, data.Path.ValueString())
+
+	var stdout, stderr bytes.Buffer
+	cmd := d.providerData.AnsibleCommand(ctx, binary, nil, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd, nil)
+
+	if err := cmd.Run(); err != nil && ExitCode(err) != lintExitCodeViolations {
+		resp.Diagnostics.AddError(fmt.Sprintf("%s failed", binary), fmt.Sprintf("%s\n%s", err, stderr.String()))
+		return
+	}
+
+	violations, err := ParseLintViolations(stdout.Bytes())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to parse the output of ansible-lint", fmt.Sprintf("%s\n\nSTDOUT:\n%s", err, stdout.String()))
+		return
+	}
+
+	resp.Diagnostics.Append(setLintModel(ctx, violations, &data)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func setLintModel(ctx context.Context, violations []LintViolation, data *AnsibleLintDataSourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	models := make([]LintViolationModel, 0, len(violations))
+	counts := map[string]int64{}
+	for _, violation := range violations {
+		// Versions without levels don't distinguish warnings
+		level := violation.Level
+		if len(level) == 0 {
+			level = "error"
+		}
+
+		models = append(models, LintViolationModel{
+			Rule:        types.StringValue(violation.CheckName),
+			Description: types.StringValue(violation.Description),
+			Severity:    types.StringValue(violation.Severity),
+			Level:       types.StringValue(level),
+			Path:        types.StringValue(violation.Location.Path),
+			Line:        types.Int64Value(violation.Line()),
+		})
+		counts[violation.Severity]++
+	}
+
+	violationsValue, newDiags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: LintViolationModel{}.AttrTypes()}, models)
+	diags.Append(newDiags...)
+	data.Violations = violationsValue
+
+	countsValue, newDiags := types.MapValueFrom(ctx, types.Int64Type, counts)
+	diags.Append(newDiags...)
+	data.SeverityCounts = countsValue
+
+	return diags
+}
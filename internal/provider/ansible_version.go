@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+var (
+	// ansible-playbook [core 2.15.3]
+	coreVersionRegexp = regexp.MustCompile(`\[core ([0-9][^\]\s]*)\]`)
+	// ansible-playbook 2.9.27
+	legacyVersionRegexp = regexp.MustCompile(`^\S+ ([0-9][^\s]*)`)
+)
+
+// Parse the ansible core version from the output of `ansible-playbook --version`
+func ParseAnsibleVersion(output string) (*version.Version, error) {
+	firstLine := strings.SplitN(strings.TrimSpace(output), "\n", 2)[0]
+
+	match := coreVersionRegexp.FindStringSubmatch(firstLine)
+	if match == nil {
+		match = legacyVersionRegexp.FindStringSubmatch(firstLine)
+	}
+	if match == nil {
+		return nil, fmt.Errorf("couldn't find a version in %q", firstLine)
+	}
+
+	return version.NewVersion(match[1])
+}
+
+// Run cmd, `ansible-playbook --version`, and return the ansible core version
+func AnsibleVersion(cmd *exec.Cmd) (*version.Version, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w\n%s", cmd, err, stderr.String())
+	}
+
+	return ParseAnsibleVersion(stdout.String())
+}
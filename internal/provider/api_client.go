@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const apiRequestTimeout = 30 * time.Second
+
+// A client of a JSON REST API, like the one of AWX
+type APIClient struct {
+	URL string
+
+	// Set the credentials of a request
+	authorize func(req *http.Request)
+	client    *http.Client
+}
+
+func NewAPIClient(url string, insecure bool, authorize func(req *http.Request)) APIClient {
+	client := &http.Client{}
+	if insecure {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	return APIClient{URL: strings.TrimSuffix(url, "/"), authorize: authorize, client: client}
+}
+
+// The error of a request the API responded to with an unsuccessful status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Whether the API responded to the request with 404 Not Found
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Send a request to the API path, e.g. `/api/v2/ping/`, with body encoded as
+// JSON unless nil, and decode the response into result unless nil
+func (c *APIClient) Do(ctx context.Context, method string, apiPath string, body interface{}, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+apiPath, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("%s %s responded with %s: %s", method, apiPath, resp.Status, strings.TrimSpace(string(respBody))),
+		}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// The consecutive failed requests Poll retries, e.g. 502s of a load balancer
+// in front of a controller that restarts
+const pollRetries = 5
+
+// Whether a request may succeed when it's sent again: server errors, rate
+// limits and failures without a response, like refused connections
+func isTransient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// Get the API path every interval until finished returns true for the
+// decoded result. Transient errors are retried pollRetries times in a row, so
+// they don't end a long wait.
+func (c *APIClient) Poll(ctx context.Context, apiPath string, interval time.Duration, result interface{}, finished func() bool) error {
+	failures := 0
+	for {
+		if err := c.Do(ctx, http.MethodGet, apiPath, nil, result); err != nil {
+			if ctx.Err() != nil || !isTransient(err) || failures >= pollRetries {
+				return err
+			}
+			failures++
+			tflog.Warn(ctx, fmt.Sprintf("Retrying %s after a failed request (%d of %d): %s", apiPath, failures, pollRetries, err))
+		} else {
+			failures = 0
+			if finished() {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Validate the interval to poll the status of a remote job with
+func validatePollInterval(attribute path.Path, value types.String, diags *diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return
+	}
+	if interval, err := time.ParseDuration(value.ValueString()); err != nil || interval <= 0 {
+		diags.AddAttributeError(attribute, "Invalid poll interval",
+			fmt.Sprintf("%q isn't a positive duration like 30s.", value.ValueString()))
+	}
+}
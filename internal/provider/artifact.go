@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// The artifact of a run, decoded once and shared by the failure analysis,
+// the stats and the queries. The generic document JSONPath and jq queries
+// need is only decoded for the first of them.
+type Artifact struct {
+	Root Root
+	// The error decoding the artifact. Root holds the plays and tasks decoded
+	// before it then, if the output was cut off.
+	Err error
+
+	source          *io.SectionReader
+	document        interface{}
+	documentErr     error
+	documentDecoded bool
+}
+
+// Decode the artifact from output, skipping any text before the JSON
+// document, like warnings printed to stdout
+func ParseArtifact(output *io.SectionReader) *Artifact {
+	offset := jsonDocumentOffset(io.NewSectionReader(output, 0, output.Size()))
+	source := io.NewSectionReader(output, offset, output.Size()-offset)
+	root, err := DecodeArtifact(io.NewSectionReader(source, 0, source.Size()))
+	return &Artifact{Root: root, Err: err, source: source}
+}
+
+// The artifact of a run without the json callback
+func EmptyArtifact() *Artifact {
+	return &Artifact{source: io.NewSectionReader(strings.NewReader(""), 0, 0)}
+}
+
+// The artifact as generic values
+func (a *Artifact) Document() (interface{}, error) {
+	if !a.documentDecoded {
+		a.documentDecoded = true
+		a.document, a.documentErr = decodeJSON(a.Reader())
+	}
+	return a.document, a.documentErr
+}
+
+// A reader of the raw artifact
+func (a *Artifact) Reader() io.Reader {
+	return io.NewSectionReader(a.source, 0, a.source.Size())
+}
+
+// Lines of plain text ansible output worth reporting
+var rawOutputLineRegexp = regexp.MustCompile(`^(ERROR!|\[ERROR\]|\[WARNING\]|fatal:|failed:|FAILED!|UNREACHABLE!|Killed)`)
+
+// The number of lines at the end of the output reported for an artifact that
+// can't be decoded
+const rawOutputTailLines = 50
+
+// Describe an artifact that couldn't be decoded with the plain text of the
+// output instead: the errors and warnings in it and its end.
+func (a *Artifact) RawOutputDetail(output string, stderr string) string {
+	tasks := 0
+	for _, play := range a.Root.Plays {
+		tasks += len(play.Tasks)
+	}
+
+	var detail strings.Builder
+	fmt.Fprintf(&detail, "The JSON output couldn't be decoded (%s), e.g. because ansible was killed or other output was mixed into it. Tasks decoded before: %d.", a.Err, tasks)
+
+	var lines []string
+	for _, text := range []string{output, stderr} {
+		for _, line := range strings.Split(text, "\n") {
+			if rawOutputLineRegexp.MatchString(strings.TrimSpace(line)) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	if len(lines) > 0 {
+		detail.WriteString("\n\nErrors and warnings in the output:\n" + strings.Join(lines, "\n"))
+	}
+
+	detail.WriteString("\n\nEnd of the output:\n" + lastLines(output, rawOutputTailLines))
+	if len(stderr) > 0 {
+		detail.WriteString("\n\nEnd of stderr:\n" + lastLines(stderr, rawOutputTailLines))
+	}
+	return detail.String()
+}
+
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultArtifactRetention = 10
+
+// Keeps the JSON artifacts and logs of the last runs of every resource in a
+// directory. A nil *ArtifactStore does nothing.
+type ArtifactStore struct {
+	dir string
+	// The number of artifacts kept per resource, 0 keeps all
+	retention int
+	mu        sync.Mutex
+}
+
+func NewArtifactStore(dir string, retention int) *ArtifactStore {
+	return &ArtifactStore{dir: dir, retention: retention}
+}
+
+// Write the artifact of a run of the resource with the ID redacted, named by
+// the ID and the start of the run, and remove the oldest artifacts of the
+// resource beyond the retention.
+func (s *ArtifactStore) Save(id string, start time.Time, artifact io.Reader, redactor *Redactor) error {
+	if s == nil {
+		return nil
+	}
+
+	// Runs of several resources may finish at the same time
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path(id, start, ".json"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	err = redactor.RedactLines(file, artifact)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.prune(id)
+}
+
+// The path of the log of a run of the resource with the ID, next to its
+// artifact, or "" without a store. It's pruned with the artifacts.
+func (s *ArtifactStore) LogPath(id string, start time.Time) string {
+	if s == nil {
+		return ""
+	}
+	return s.path(id, start, ".log")
+}
+
+func (s *ArtifactStore) path(id string, start time.Time, extension string) string {
+	// The timestamp sorts in the order of the runs
+	return filepath.Join(s.dir, id+"-"+start.UTC().Format("20060102T150405.000000000Z")+extension)
+}
+
+func (s *ArtifactStore) prune(id string) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	for _, extension := range []string{".json", ".log"} {
+		// IDs are UUIDs, so only the files of this resource match
+		files, err := filepath.Glob(filepath.Join(s.dir, id+"-*"+extension))
+		if err != nil {
+			return err
+		}
+		sort.Strings(files)
+
+		for len(files) > s.retention {
+			if err := os.Remove(files[0]); err != nil {
+				return err
+			}
+			files = files[1:]
+		}
+	}
+	return nil
+}
+
+// Copy the log ansible wrote to tempLog to logPath, redacted
+func SaveRunLog(tempLog string, logPath string, redactor *Redactor) error {
+	content, err := os.Open(tempLog)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	err = redactor.RedactLines(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestJSONDocumentOffset(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int64
+	}{
+		{name: "document only", input: `{"plays": []}`, want: 0},
+		{name: "warning before", input: "[WARNING]: plugin\n{\"plays\": []}", want: 18},
+		{name: "several lines before", input: "a\n\nbc\n{}", want: 6},
+		{name: "brace inside a line", input: "x {\n{}", want: 4},
+		{name: "no document", input: "ERROR! no playbook\n", want: 0},
+		{name: "empty", input: "", want: 0},
+		{name: "long line before", input: strings.Repeat("x", 10000) + "\n{}", want: 10001},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := jsonDocumentOffset(strings.NewReader(test.input)); got != test.want {
+				t.Errorf("jsonDocumentOffset() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseArtifact(t *testing.T) {
+	const tasks = `{"plays": [{"play": {"name": "web"}, "tasks": [{"task": {"name": "one"}, "hosts": {}}, {"task": {"name": "two"}, "hosts": {}}`
+
+	tests := []struct {
+		name      string
+		input     string
+		wantTasks int
+		wantStats int
+		wantErr   bool
+	}{
+		{name: "complete", input: tasks + `]}], "stats": {"a": {}}}`, wantTasks: 2, wantStats: 1},
+		{name: "warnings before", input: "[WARNING]: a\n[WARNING]: b\n" + tasks + `]}], "stats": {"a": {}, "b": {}}}`, wantTasks: 2, wantStats: 2},
+		{name: "cut off", input: tasks + `, {"task": {"na`, wantTasks: 2, wantErr: true},
+		{name: "plain text", input: "ERROR! the playbook could not be found\n", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			artifact := ParseArtifact(io.NewSectionReader(strings.NewReader(test.input), 0, int64(len(test.input))))
+			if (artifact.Err != nil) != test.wantErr {
+				t.Fatalf("ParseArtifact() error = %v, want error %t", artifact.Err, test.wantErr)
+			}
+			gotTasks := 0
+			for _, play := range artifact.Root.Plays {
+				gotTasks += len(play.Tasks)
+			}
+			if gotTasks != test.wantTasks || len(artifact.Root.Stats) != test.wantStats {
+				t.Errorf("ParseArtifact() decoded %d tasks and %d hosts, want %d and %d", gotTasks, len(artifact.Root.Stats), test.wantTasks, test.wantStats)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var artifactQueryTypes = []string{"string", "number", "bool", "list", "object"}
+
+var artifactQuerySources = []string{"artifact", "stderr"}
+
+// Interpret the values matched by a query according to its declared type.
+// A single match is used as is, multiple matches are only valid for lists.
+func TypedArtifactValue(query ArtifactQuery) (interface{}, error) {
+	var value interface{}
+	if len(query.Values) == 1 {
+		value = query.Values[0]
+	} else if len(query.Values) > 1 {
+		value = query.Values
+	}
+
+	switch query.Type {
+	case "", "string":
+		return query.Result, nil
+	case "number":
+		switch v := value.(type) {
+		case nil:
+			return nil, nil
+		case float64, int, *big.Int:
+			// gojq returns integers as int, or *big.Int beyond its range
+			return v, nil
+		case string:
+			number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+			return number, nil
+		}
+	case "bool":
+		switch v := value.(type) {
+		case nil:
+			return nil, nil
+		case bool:
+			return v, nil
+		case string:
+			boolean, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a bool", v)
+			}
+			return boolean, nil
+		}
+	case "list":
+		switch v := value.(type) {
+		case nil:
+			return []interface{}{}, nil
+		case []interface{}:
+			if len(query.Values) == 1 {
+				return v, nil
+			}
+		}
+		return query.Values, nil
+	case "object":
+		switch v := value.(type) {
+		case nil:
+			return nil, nil
+		case map[string]interface{}:
+			return v, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %q", query.Type)
+	}
+
+	return nil, fmt.Errorf("result of type %T can't be converted to %s", value, query.Type)
+}
+
+// Convert a decoded JSON value into a Terraform value, redacting every string
+// on the way.
+func JSONToAttrValue(value interface{}, redactor *Redactor) attr.Value {
+	switch v := value.(type) {
+	case nil:
+		return types.StringNull()
+	case string:
+		return types.StringValue(redactor.Redact(v))
+	case bool:
+		return types.BoolValue(v)
+	case float64:
+		return types.NumberValue(big.NewFloat(v))
+	case int:
+		return types.NumberValue(new(big.Float).SetInt64(int64(v)))
+	case *big.Int:
+		return types.NumberValue(new(big.Float).SetInt(v))
+	case []interface{}:
+		elementTypes := make([]attr.Type, 0, len(v))
+		elements := make([]attr.Value, 0, len(v))
+		for _, element := range v {
+			converted := JSONToAttrValue(element, redactor)
+			elementTypes = append(elementTypes, converted.Type(context.Background()))
+			elements = append(elements, converted)
+		}
+		return types.TupleValueMust(elementTypes, elements)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		attributeTypes := make(map[string]attr.Type, len(v))
+		attributes := make(map[string]attr.Value, len(v))
+		for _, key := range keys {
+			converted := JSONToAttrValue(v[key], redactor)
+			attributeTypes[key] = converted.Type(context.Background())
+			attributes[key] = converted
+		}
+		return types.ObjectValueMust(attributeTypes, attributes)
+	}
+
+	return types.StringValue(redactor.Redact(fmt.Sprint(value)))
+}
+
+// Build the artifact_values attribute, an object with one typed value per query.
+func ArtifactValues(queries map[string]ArtifactQuery, redactor *Redactor) (types.Dynamic, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	attributeTypes := make(map[string]attr.Type, len(queries))
+	attributes := make(map[string]attr.Value, len(queries))
+	for name, query := range queries {
+		value, err := TypedArtifactValue(query)
+		if err != nil {
+			diags.AddError(fmt.Sprintf("Invalid result of artifact query %s", name), redactor.Redact(err.Error()))
+			continue
+		}
+
+		var converted attr.Value
+		switch {
+		case value == nil && query.Type == "number":
+			converted = types.NumberNull()
+		case value == nil && query.Type == "bool":
+			converted = types.BoolNull()
+		default:
+			converted = JSONToAttrValue(value, redactor)
+		}
+
+		attributeTypes[name] = converted.Type(context.Background())
+		attributes[name] = converted
+	}
+
+	if diags.HasError() {
+		return types.DynamicNull(), diags
+	}
+
+	object, newDiags := types.ObjectValue(attributeTypes, attributes)
+	diags.Append(newDiags...)
+	return types.DynamicValue(object), diags
+}
+
+// Convert a Terraform value into the equivalent decoded JSON value, the
+// reverse of JSONToAttrValue. Whole numbers become int64, so they are
+// rendered without a fraction.
+func AttrValueToJSON(value attr.Value) (interface{}, error) {
+	if value == nil || value.IsNull() {
+		return nil, nil
+	}
+	if value.IsUnknown() {
+		return nil, fmt.Errorf("value is not known yet")
+	}
+
+	switch v := value.(type) {
+	case types.Dynamic:
+		return AttrValueToJSON(v.UnderlyingValue())
+	case types.String:
+		return v.ValueString(), nil
+	case types.Bool:
+		return v.ValueBool(), nil
+	case types.Int64:
+		return v.ValueInt64(), nil
+	case types.Float64:
+		return v.ValueFloat64(), nil
+	case types.Number:
+		number := v.ValueBigFloat()
+		if number.IsInt() {
+			if integer, accuracy := number.Int64(); accuracy == big.Exact {
+				return integer, nil
+			}
+		}
+		float, _ := number.Float64()
+		return float, nil
+	case types.List:
+		return attrValuesToJSON(v.Elements())
+	case types.Set:
+		return attrValuesToJSON(v.Elements())
+	case types.Tuple:
+		return attrValuesToJSON(v.Elements())
+	case types.Map:
+		return attrMapToJSON(v.Elements())
+	case types.Object:
+		return attrMapToJSON(v.Attributes())
+	}
+
+	return nil, fmt.Errorf("unsupported value of type %s", value.Type(context.Background()))
+}
+
+func attrValuesToJSON(elements []attr.Value) (interface{}, error) {
+	values := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		value, err := AttrValueToJSON(element)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func attrMapToJSON(elements map[string]attr.Value) (interface{}, error) {
+	values := make(map[string]interface{}, len(elements))
+	for key, element := range elements {
+		value, err := AttrValueToJSON(element)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
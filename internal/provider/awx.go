@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+type AWXModel struct {
+	URL      types.String `tfsdk:"url"`
+	Token    types.String `tfsdk:"token"`
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+	Insecure types.Bool   `tfsdk:"insecure"`
+}
+
+// A client of the API of AWX or Automation Controller
+type AWXClient struct {
+	APIClient
+}
+
+// The settings of the model, or else the environment variables the awx.awx
+// collection uses
+func NewAWXClient(model *AWXModel) *AWXClient {
+	valueOrEnv := func(value types.String, name string) string {
+		if len(value.ValueString()) > 0 {
+			return value.ValueString()
+		}
+		return os.Getenv(name)
+	}
+
+	token := valueOrEnv(model.Token, "CONTROLLER_OAUTH_TOKEN")
+	username := valueOrEnv(model.Username, "CONTROLLER_USERNAME")
+	password := valueOrEnv(model.Password, "CONTROLLER_PASSWORD")
+	return &AWXClient{NewAPIClient(valueOrEnv(model.URL, "CONTROLLER_HOST"), model.Insecure.ValueBool(), func(req *http.Request) {
+		if len(token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else if len(username) > 0 {
+			req.SetBasicAuth(username, password)
+		}
+	})}
+}
+
+// The fields of a job template or workflow job template launch
+type awxLaunch struct {
+	ExtraVars map[string]string `json:"extra_vars,omitempty"`
+	Limit     string            `json:"limit,omitempty"`
+	Inventory int64             `json:"inventory,omitempty"`
+}
+
+type awxLaunchResponse struct {
+	AWXJob
+	// The fields the template doesn't prompt for on launch
+	IgnoredFields map[string]interface{} `json:"ignored_fields"`
+}
+
+// A job of AWX, like a workflow job
+type AWXJob struct {
+	Id             int64  `json:"id"`
+	Name           string `json:"name"`
+	Status         string `json:"status"`
+	Failed         bool   `json:"failed"`
+	JobExplanation string `json:"job_explanation"`
+	// The stats set by set_stats, only of jobs of job templates
+	Artifacts map[string]interface{} `json:"artifacts"`
+}
+
+// A page of the stdout of a job, with the range of its lines
+type awxStdoutPage struct {
+	Range struct {
+		Start       int64 `json:"start"`
+		End         int64 `json:"end"`
+		AbsoluteEnd int64 `json:"absolute_end"`
+	} `json:"range"`
+	Content string `json:"content"`
+}
+
+// Whether the job won't change anymore
+func (j AWXJob) Finished() bool {
+	switch j.Status {
+	case "successful", "failed", "error", "canceled":
+		return true
+	}
+	return false
+}
+
+// Poll the job at the API path every interval until it's finished, calling
+// onPoll unless nil with every state of the job
+func (c *AWXClient) WaitForJob(ctx context.Context, apiPath string, interval time.Duration, onPoll func(job AWXJob)) (AWXJob, error) {
+	var job AWXJob
+	status := ""
+	err := c.Poll(ctx, apiPath, interval, &job, func() bool {
+		if job.Status != status {
+			tflog.Info(ctx, fmt.Sprintf("AWX job %d (%s) is %s", job.Id, job.Name, job.Status))
+			status = job.Status
+		}
+		if onPoll != nil {
+			onPoll(job)
+		}
+		return job.Finished()
+	})
+	return job, err
+}
+
+// Log the stdout of the job at the API path from the line start on, page by
+// page, and return the line to continue at the next time
+func (c *AWXClient) LogStdout(ctx context.Context, apiPath string, start int64) (int64, error) {
+	for {
+		var page awxStdoutPage
+		if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("%sstdout/?format=json&start_line=%d", apiPath, start), nil, &page); err != nil {
+			return start, err
+		}
+		if page.Range.End <= start {
+			return start, nil
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(page.Content, "\n"), "\n") {
+			tflog.Info(ctx, line)
+		}
+		start = page.Range.End
+		if start >= page.Range.AbsoluteEnd {
+			return start, nil
+		}
+	}
+}
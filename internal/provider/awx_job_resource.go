@@ -0,0 +1,285 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &AWXJobResource{}
+var _ resource.ResourceWithValidateConfig = &AWXJobResource{}
+var _ resource.ResourceWithConfigure = &AWXJobResource{}
+
+func NewAWXJobResource() resource.Resource {
+	return &AWXJobResource{}
+}
+
+// AWXJobResource launches a job template of the AWX of the provider and waits
+// for the job to finish, logging its stdout on the way.
+type AWXJobResource struct {
+	providerData *ProviderData
+}
+
+// AWXJobResourceModel describes the resource data model.
+type AWXJobResourceModel struct {
+	JobTemplateId types.Int64    `tfsdk:"job_template_id"`
+	ExtraVars     types.Map      `tfsdk:"extra_vars"`
+	Limit         types.String   `tfsdk:"limit"`
+	InventoryId   types.Int64    `tfsdk:"inventory_id"`
+	Wait          types.Bool     `tfsdk:"wait"`
+	PollInterval  types.String   `tfsdk:"poll_interval"`
+	Status        types.String   `tfsdk:"status"`
+	Failed        types.Bool     `tfsdk:"failed"`
+	Artifacts     types.Dynamic  `tfsdk:"artifacts"`
+	Timeouts      timeouts.Value `tfsdk:"timeouts"`
+	Id            types.String   `tfsdk:"id"`
+}
+
+func (r *AWXJobResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_awx_job"
+}
+
+func (r *AWXJobResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Launches a job template on the AWX or Automation Controller configured in the `awx` block of the provider and waits for the job to finish, logging its stdout at the `INFO` level as it comes in. The job is launched again when any argument changes. Destroying the resource doesn't touch AWX.",
+
+		Attributes: map[string]schema.Attribute{
+			"job_template_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the job template to launch.",
+				Required:            true,
+			},
+			"extra_vars": schema.MapAttribute{
+				MarkdownDescription: "Extra variables of the job. The template must prompt for them on launch, or enable the survey.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"limit": schema.StringAttribute{
+				MarkdownDescription: "Limit the job to these hosts. The template must prompt for the limit on launch.",
+				Optional:            true,
+			},
+			"inventory_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the inventory to use instead of the one of the template. The template must prompt for the inventory on launch.",
+				Optional:            true,
+			},
+			"wait": schema.BoolAttribute{
+				MarkdownDescription: "Wait for the job to finish and fail if it didn't succeed. Defaults to true.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"poll_interval": schema.StringAttribute{
+				MarkdownDescription: "How often to check the status and stdout of the job while waiting, e.g. `30s`. Defaults to `5s`. How long to wait at most is set by `timeouts`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("5s"),
+			},
+			"status": schema.StringAttribute{
+				MarkdownDescription: "The status of the job, `successful` when waiting for it succeeded, `pending` usually if not waiting.",
+				Computed:            true,
+			},
+			"failed": schema.BoolAttribute{
+				MarkdownDescription: "Whether the job failed.",
+				Computed:            true,
+			},
+			"artifacts": schema.DynamicAttribute{
+				MarkdownDescription: "The `artifacts` of the job, the data the playbook set with `set_stats`. Query it like any object, or with `provider::ansible::query(jsonencode(...), ...)`. Null if not waiting for the job.",
+				Computed:            true,
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+			}),
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "ID of the last job.",
+			},
+		},
+	}
+}
+
+func (r *AWXJobResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.providerData = providerData
+}
+
+func (r *AWXJobResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config AWXJobResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validatePollInterval(path.Root("poll_interval"), config.PollInterval, &resp.Diagnostics)
+}
+
+// Launch the job template and wait for the job
+func (r *AWXJobResource) run(ctx context.Context, diags *diag.Diagnostics, data *AWXJobResourceModel) {
+	awx := r.providerData.GetAWX()
+	if awx == nil {
+		diags.AddError("AWX isn't configured", "Configure the awx block of the provider to launch job templates.")
+		return
+	}
+
+	launch := awxLaunch{
+		Limit:     data.Limit.ValueString(),
+		Inventory: data.InventoryId.ValueInt64(),
+	}
+	diags.Append(data.ExtraVars.ElementsAs(ctx, &launch.ExtraVars, false)...)
+	if diags.HasError() {
+		return
+	}
+
+	var launched awxLaunchResponse
+	launchPath := fmt.Sprintf("/api/v2/job_templates/%d/launch/", data.JobTemplateId.ValueInt64())
+	if err := awx.Do(ctx, http.MethodPost, launchPath, launch, &launched); err != nil {
+		diags.AddError("Failed to launch the job template", err.Error())
+		return
+	}
+	if len(launched.IgnoredFields) > 0 {
+		ignored := make([]string, 0, len(launched.IgnoredFields))
+		for field := range launched.IgnoredFields {
+			ignored = append(ignored, field)
+		}
+		sort.Strings(ignored)
+		diags.AddWarning("AWX ignored fields of the launch",
+			fmt.Sprintf("The job template doesn't prompt for %s on launch, so AWX ignored them.", strings.Join(ignored, ", ")))
+	}
+	tflog.Info(ctx, fmt.Sprintf("Launched AWX job %d", launched.Id))
+
+	data.Id = types.StringValue(strconv.FormatInt(launched.Id, 10))
+	data.Status = types.StringValue(launched.Status)
+	data.Failed = types.BoolValue(launched.Failed)
+	data.Artifacts = types.DynamicNull()
+	if !data.Wait.ValueBool() {
+		return
+	}
+
+	// Validated by ValidateConfig
+	interval, _ := time.ParseDuration(data.PollInterval.ValueString())
+	jobPath := fmt.Sprintf("/api/v2/jobs/%d/", launched.Id)
+	var line int64
+	stdoutFailed := false
+	job, err := awx.WaitForJob(ctx, jobPath, interval, func(job AWXJob) {
+		if stdoutFailed {
+			return
+		}
+		// The stdout is only logged, failing to get it doesn't fail the job
+		var stdoutErr error
+		if line, stdoutErr = awx.LogStdout(ctx, jobPath, line); stdoutErr != nil && ctx.Err() == nil {
+			tflog.Warn(ctx, fmt.Sprintf("Failed to get the stdout of AWX job %d: %s", launched.Id, stdoutErr))
+			stdoutFailed = true
+		}
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			// Don't leave the job running after a timeout
+			cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), apiRequestTimeout)
+			defer cancel()
+			if cancelErr := awx.Do(cancelCtx, http.MethodPost, jobPath+"cancel/", nil, nil); cancelErr != nil {
+				diags.AddWarning("Failed to cancel the job", cancelErr.Error())
+			}
+		}
+		diags.AddError(fmt.Sprintf("Failed to wait for the job %d", launched.Id), err.Error())
+		return
+	}
+
+	data.Status = types.StringValue(job.Status)
+	data.Failed = types.BoolValue(job.Failed)
+	if job.Artifacts != nil {
+		data.Artifacts = types.DynamicValue(JSONToAttrValue(job.Artifacts, nil))
+	}
+	if job.Status != "successful" {
+		diags.AddError(fmt.Sprintf("The job %d is %s", job.Id, job.Status), job.JobExplanation)
+	}
+}
+
+func (r *AWXJobResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data AWXJobResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := data.Timeouts.Create(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	// Keep the ID of a launched job even if waiting for it failed
+	if resp.Diagnostics.HasError() && data.Id.IsUnknown() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *AWXJobResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+func (r *AWXJobResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data AWXJobResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := data.Timeouts.Update(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	// Keep the ID of a launched job even if waiting for it failed
+	if resp.Diagnostics.HasError() && data.Id.IsUnknown() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *AWXJobResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
@@ -0,0 +1,292 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &AWXWorkflowJobResource{}
+var _ resource.ResourceWithValidateConfig = &AWXWorkflowJobResource{}
+var _ resource.ResourceWithConfigure = &AWXWorkflowJobResource{}
+
+func NewAWXWorkflowJobResource() resource.Resource {
+	return &AWXWorkflowJobResource{}
+}
+
+// AWXWorkflowJobResource launches a workflow job template of the AWX of the
+// provider and waits for the workflow job to finish.
+type AWXWorkflowJobResource struct {
+	providerData *ProviderData
+}
+
+// AWXWorkflowJobResourceModel describes the resource data model.
+type AWXWorkflowJobResourceModel struct {
+	WorkflowJobTemplateId types.Int64    `tfsdk:"workflow_job_template_id"`
+	ExtraVars             types.Map      `tfsdk:"extra_vars"`
+	Limit                 types.String   `tfsdk:"limit"`
+	InventoryId           types.Int64    `tfsdk:"inventory_id"`
+	Wait                  types.Bool     `tfsdk:"wait"`
+	PollInterval          types.String   `tfsdk:"poll_interval"`
+	Status                types.String   `tfsdk:"status"`
+	Failed                types.Bool     `tfsdk:"failed"`
+	Timeouts              timeouts.Value `tfsdk:"timeouts"`
+	Id                    types.String   `tfsdk:"id"`
+}
+
+type awxWorkflowNodes struct {
+	Results []struct {
+		SummaryFields struct {
+			Job *AWXJob `json:"job"`
+		} `json:"summary_fields"`
+	} `json:"results"`
+}
+
+func (r *AWXWorkflowJobResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_awx_workflow_job"
+}
+
+func (r *AWXWorkflowJobResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Launches a workflow job template on the AWX or Automation Controller configured in the `awx` block of the provider and waits for the workflow job to finish. The workflow is launched again when any argument changes. Destroying the resource doesn't touch AWX.",
+
+		Attributes: map[string]schema.Attribute{
+			"workflow_job_template_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the workflow job template to launch.",
+				Required:            true,
+			},
+			"extra_vars": schema.MapAttribute{
+				MarkdownDescription: "Extra variables of the workflow job. The template must prompt for them on launch, or enable the survey.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"limit": schema.StringAttribute{
+				MarkdownDescription: "Limit the jobs of the workflow to these hosts. The template must prompt for the limit on launch.",
+				Optional:            true,
+			},
+			"inventory_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the inventory to use instead of the one of the template. The template must prompt for the inventory on launch.",
+				Optional:            true,
+			},
+			"wait": schema.BoolAttribute{
+				MarkdownDescription: "Wait for the workflow job to finish and fail if it didn't succeed. Defaults to true.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"poll_interval": schema.StringAttribute{
+				MarkdownDescription: "How often to check the status of the workflow job while waiting, e.g. `30s`. Defaults to `5s`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("5s"),
+			},
+			"status": schema.StringAttribute{
+				MarkdownDescription: "The status of the workflow job, `successful` when waiting for it succeeded, `pending` usually if not waiting.",
+				Computed:            true,
+			},
+			"failed": schema.BoolAttribute{
+				MarkdownDescription: "Whether the workflow job failed.",
+				Computed:            true,
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+			}),
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "ID of the last workflow job.",
+			},
+		},
+	}
+}
+
+func (r *AWXWorkflowJobResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.providerData = providerData
+}
+
+func (r *AWXWorkflowJobResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config AWXWorkflowJobResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validatePollInterval(path.Root("poll_interval"), config.PollInterval, &resp.Diagnostics)
+}
+
+// Launch the workflow job template and wait for the workflow job
+func (r *AWXWorkflowJobResource) run(ctx context.Context, diags *diag.Diagnostics, data *AWXWorkflowJobResourceModel) {
+	awx := r.providerData.GetAWX()
+	if awx == nil {
+		diags.AddError("AWX isn't configured", "Configure the awx block of the provider to launch workflow job templates.")
+		return
+	}
+
+	launch := awxLaunch{
+		Limit:     data.Limit.ValueString(),
+		Inventory: data.InventoryId.ValueInt64(),
+	}
+	diags.Append(data.ExtraVars.ElementsAs(ctx, &launch.ExtraVars, false)...)
+	if diags.HasError() {
+		return
+	}
+
+	var launched awxLaunchResponse
+	launchPath := fmt.Sprintf("/api/v2/workflow_job_templates/%d/launch/", data.WorkflowJobTemplateId.ValueInt64())
+	if err := awx.Do(ctx, http.MethodPost, launchPath, launch, &launched); err != nil {
+		diags.AddError("Failed to launch the workflow job template", err.Error())
+		return
+	}
+	if len(launched.IgnoredFields) > 0 {
+		ignored := make([]string, 0, len(launched.IgnoredFields))
+		for field := range launched.IgnoredFields {
+			ignored = append(ignored, field)
+		}
+		sort.Strings(ignored)
+		diags.AddWarning("AWX ignored fields of the launch",
+			fmt.Sprintf("The workflow job template doesn't prompt for %s on launch, so AWX ignored them.", strings.Join(ignored, ", ")))
+	}
+	tflog.Info(ctx, fmt.Sprintf("Launched AWX workflow job %d", launched.Id))
+
+	data.Id = types.StringValue(strconv.FormatInt(launched.Id, 10))
+	data.Status = types.StringValue(launched.Status)
+	data.Failed = types.BoolValue(launched.Failed)
+	if !data.Wait.ValueBool() {
+		return
+	}
+
+	// Validated by ValidateConfig
+	interval, _ := time.ParseDuration(data.PollInterval.ValueString())
+	jobPath := fmt.Sprintf("/api/v2/workflow_jobs/%d/", launched.Id)
+	job, err := awx.WaitForJob(ctx, jobPath, interval, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Don't leave the workflow running after a timeout
+			cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), apiRequestTimeout)
+			defer cancel()
+			if cancelErr := awx.Do(cancelCtx, http.MethodPost, jobPath+"cancel/", nil, nil); cancelErr != nil {
+				diags.AddWarning("Failed to cancel the workflow job", cancelErr.Error())
+			}
+		}
+		diags.AddError(fmt.Sprintf("Failed to wait for the workflow job %d", launched.Id), err.Error())
+		return
+	}
+
+	data.Status = types.StringValue(job.Status)
+	data.Failed = types.BoolValue(job.Failed)
+	if job.Status != "successful" {
+		diags.AddError(fmt.Sprintf("The workflow job %d is %s", job.Id, job.Status), r.failedNodes(ctx, awx, jobPath))
+	}
+}
+
+// Describe the jobs of the workflow that didn't succeed
+func (r *AWXWorkflowJobResource) failedNodes(ctx context.Context, awx *AWXClient, jobPath string) string {
+	var nodes awxWorkflowNodes
+	if err := awx.Do(ctx, http.MethodGet, jobPath+"workflow_nodes/?page_size=200", nil, &nodes); err != nil {
+		return fmt.Sprintf("Failed to get the jobs of the workflow: %s", err)
+	}
+
+	var failed []string
+	for _, node := range nodes.Results {
+		job := node.SummaryFields.Job
+		if job != nil && job.Finished() && job.Status != "successful" {
+			failed = append(failed, fmt.Sprintf("%s (job %d): %s", job.Name, job.Id, job.Status))
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return "Jobs of the workflow that didn't succeed:\n" + strings.Join(failed, "\n")
+}
+
+func (r *AWXWorkflowJobResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data AWXWorkflowJobResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := data.Timeouts.Create(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	// Keep the ID of a launched workflow job even if waiting for it failed
+	if resp.Diagnostics.HasError() && data.Id.IsUnknown() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *AWXWorkflowJobResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+func (r *AWXWorkflowJobResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data AWXWorkflowJobResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := data.Timeouts.Update(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	// Keep the ID of a launched workflow job even if waiting for it failed
+	if resp.Diagnostics.HasError() && data.Id.IsUnknown() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *AWXWorkflowJobResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
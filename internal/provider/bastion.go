@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+type BastionModel struct {
+	Host       types.String `tfsdk:"host"`
+	User       types.String `tfsdk:"user"`
+	Port       types.Int64  `tfsdk:"port"`
+	PrivateKey types.String `tfsdk:"private_key"`
+}
+
+func (BastionModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"host":        types.StringType,
+		"user":        types.StringType,
+		"port":        types.Int64Type,
+		"private_key": types.StringType,
+	}
+}
+
+// The destination of the bastion for ssh, [user@]host
+func (m BastionModel) destination() string {
+	if m.User.IsNull() || len(m.User.ValueString()) == 0 {
+		return m.Host.ValueString()
+	}
+	return m.User.ValueString() + "@" + m.Host.ValueString()
+}
+
+// The ssh arguments to connect through the bastion. Without a private key,
+// that's ProxyJump. Options on the command line don't apply to jump hosts,
+// so with a private key, keyFile, or a known_hosts file, knownHostsFile, the
+// bastion is connected to with a ProxyCommand instead.
+func (m BastionModel) SSHCommonArgs(keyFile string, knownHostsFile string) string {
+	if len(keyFile) == 0 && len(knownHostsFile) == 0 {
+		jump := m.destination()
+		if !m.Port.IsNull() {
+			jump += ":" + strconv.FormatInt(m.Port.ValueInt64(), 10)
+		}
+		return "-o ProxyJump=" + jump
+	}
+
+	proxyCommand := "ssh -W %h:%p"
+	if len(keyFile) > 0 {
+		proxyCommand += " -i " + keyFile
+	}
+	if len(knownHostsFile) > 0 {
+		proxyCommand += " " + KnownHostsSSHArgs(knownHostsFile)
+	}
+	if !m.Port.IsNull() {
+		proxyCommand += " -p " + strconv.FormatInt(m.Port.ValueInt64(), 10)
+	}
+	return fmt.Sprintf("-o ProxyCommand=\"%s %s\"", proxyCommand, m.destination())
+}
+
+// Add the connection variables of the bastion to vars. The private key is
+// written to a temporary file, which is returned and has to be removed. The
+// bastion's host key is checked against knownHostsFile, if it's given.
+func (m BastionModel) AddVars(ctx context.Context, vars map[string]interface{}, knownHostsFile string, diags *diag.Diagnostics) string {
+	var keyFile string
+	if !m.PrivateKey.IsNull() {
+		keyFile = WriteTempFile(ctx, "bastion-key-*", m.PrivateKey.ValueString(), diags)
+	}
+
+	vars["ansible_ssh_common_args"] = m.SSHCommonArgs(keyFile, knownHostsFile)
+	return keyFile
+}
+
+func validateBastion(ctx context.Context, value types.Object, diags *diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return
+	}
+
+	var bastion BastionModel
+	diags.Append(value.As(ctx, &bastion, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return
+	}
+
+	if !bastion.Port.IsNull() && !bastion.Port.IsUnknown() && (bastion.Port.ValueInt64() < 1 || bastion.Port.ValueInt64() > 65535) {
+		diags.AddAttributeError(path.Root("bastion").AtName("port"), "Invalid port", "port must be between 1 and 65535.")
+	}
+}
@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+const artifactCallbackName = "terraform_artifact"
+
+// Ansible callback plugin that writes the output of the json callback to the
+// file given in TF_ANSIBLE_ARTIFACT_FILE, so any stdout callback can be used
+// for the human readable output.
+const artifactCallbackSource = `from __future__ import (absolute_import, division, print_function)
+__metaclass__ = type
+
+import os
+
+from ansible.plugins.callback import CallbackBase
+from ansible.plugins.loader import callback_loader
+
+
+class FileDisplay(object):
+    def __init__(self, path):
+        self._path = path
+
+    def display(self, msg, *args, **kwargs):
+        with open(self._path, 'w') as artifact:
+            artifact.write(msg + '\n')
+
+    def __getattr__(self, name):
+        # Warnings and verbose messages of the json callback are dropped
+        return lambda *args, **kwargs: None
+
+
+class CallbackModule(CallbackBase):
+    CALLBACK_VERSION = 2.0
+    CALLBACK_TYPE = 'aggregate'
+    CALLBACK_NAME = 'terraform_artifact'
+    CALLBACK_NEEDS_ENABLED = True
+
+    def __init__(self):
+        super(CallbackModule, self).__init__()
+        path = os.environ.get('TF_ANSIBLE_ARTIFACT_FILE')
+        if not path:
+            return
+
+        json_callback = callback_loader.get('ansible.posix.json') or callback_loader.get('json')
+        if json_callback is None:
+            return
+        json_callback.set_options()
+        json_callback._display = FileDisplay(path)
+
+        # Forward every event to the json callback
+        for name in dir(json_callback):
+            if name.startswith('v2_'):
+                setattr(self, name, getattr(json_callback, name))
+`
+
+// The callback plugins of the provider, by name
+var callbackPlugins = map[string]string{
+	progressCallbackName: progressCallbackSource,
+	artifactCallbackName: artifactCallbackSource,
+}
+
+// Write the callback plugins of the provider into a new temporary directory,
+// which the caller has to remove once the run finished. They only run once
+// enabled with CallbackPluginsEnv.
+func WriteCallbackPlugins() (string, error) {
+	dir, err := os.MkdirTemp("", TempFilePattern("callbacks-*"))
+	if err != nil {
+		return "", err
+	}
+
+	for name, source := range callbackPlugins {
+		err = os.WriteFile(filepath.Join(dir, name+".py"), []byte(source), 0o600)
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	}
+
+	return dir, nil
+}
+
+// Environment that loads the callbacks from callbackDir, in addition to the
+// callback plugins already configured in env, see EffectiveEnvironment.
+func CallbackPluginsEnv(env map[string]string, callbackDir string, callbacks ...string) []string {
+	callbackPlugins := callbackDir
+	if existing := env["ANSIBLE_CALLBACK_PLUGINS"]; existing != "" {
+		callbackPlugins += string(os.PathListSeparator) + existing
+	}
+
+	callbacksEnabled := strings.Join(callbacks, ",")
+	if existing := env["ANSIBLE_CALLBACKS_ENABLED"]; existing != "" {
+		callbacksEnabled = existing + "," + callbacksEnabled
+	}
+
+	return []string{
+		"ANSIBLE_CALLBACK_PLUGINS=" + callbackPlugins,
+		"ANSIBLE_CALLBACKS_ENABLED=" + callbacksEnabled,
+	}
+}
+
+// The stdout callback of a run: stdout_callback, otherwise the one configured
+// by ANSIBLE_STDOUT_CALLBACK in env, see EffectiveEnvironment, or by
+// ansible.cfg, so it isn't overridden silently. Containers don't see the
+// ansible.cfg of the provider. Empty for the json callback.
+func StdoutCallback(data *PlaybookResourceModel, env map[string]string) string {
+	callback := data.StdoutCallback.ValueString()
+	if len(callback) == 0 {
+		callback = env["ANSIBLE_STDOUT_CALLBACK"]
+	}
+	if configFile := ansibleConfigFile(); len(callback) == 0 && !runsInContainer(data) && len(configFile) > 0 {
+		callback = readAnsibleConfig(configFile, "stdout_callback")
+	}
+	if callback == "json" || callback == "ansible.builtin.json" {
+		return ""
+	}
+	return callback
+}
+
+// The attributes that need the artifact of the json callback, which aren't
+// available without it
+func validateJSONCallback(ctx context.Context, config *PlaybookResourceModel, diags *diag.Diagnostics) {
+	if config.JSONCallback.IsNull() || config.JSONCallback.IsUnknown() || config.JSONCallback.ValueBool() {
+		return
+	}
+
+	conflicts := []struct {
+		attribute string
+		set       bool
+	}{
+		{"junit_report_path", !config.JUnitReportPath.IsNull()},
+		{"diff_mode", config.DiffMode.ValueBool()},
+		{"max_failed_hosts", !config.MaxFailedHosts.IsNull()},
+		{"max_failed_percentage", !config.MaxFailedPercentage.IsNull()},
+		{"ignore_unreachable", config.IgnoreUnreachable.ValueBool()},
+	}
+	for _, conflict := range conflicts {
+		if conflict.set {
+			diags.AddAttributeError(path.Root(conflict.attribute), "Conflicting json_callback",
+				fmt.Sprintf("%s needs the artifact of the json callback, so json_callback must not be false.", conflict.attribute))
+		}
+	}
+
+	var queriesModel map[string]ArtifactQueryModel
+	diags.Append(config.ArtifactQueries.ElementsAs(ctx, &queriesModel, false)...)
+	for name, model := range queriesModel {
+		if model.Source.IsNull() || model.Source.ValueString() == "artifact" {
+			diags.AddAttributeError(path.Root("artifact_queries").AtMapKey(name).AtName("source"), "Conflicting json_callback",
+				"Without the json callback there's no artifact to query, only stderr.")
+		}
+	}
+}
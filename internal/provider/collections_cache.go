@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// The directory of the shared collections cache if galaxy doesn't configure
+// one
+func defaultCollectionsCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "terraform-provider-ansible", "collections")
+}
+
+// Install the collections of requirements_file into the collections cache of
+// the provider, in a directory named by the hash of the requirements, unless
+// another run already did. So resources with the same requirements share one
+// installation. Returns the environment to use the installed collections in
+// addition to the ones of env, see EffectiveEnvironment.
+func InstallRequirements(ctx context.Context, data *PlaybookResourceModel, providerData *ProviderData, env map[string]string, diags *diag.Diagnostics) []string {
+	if data.RequirementsFile.IsNull() || len(data.RequirementsFile.ValueString()) == 0 {
+		return nil
+	}
+
+	// The collections cache isn't mounted into containers, the installation
+	// would only land in the container and leave the cache entry empty. See
+	// also ValidateConfig.
+	if runsInContainer(data) {
+		diags.AddAttributeError(path.Root("requirements_file"), "Conflicting requirements_file",
+			"The collections cache isn't mounted into containers, install the requirements into container_image instead.")
+		return nil
+	}
+
+	requirementsFile := data.RequirementsFile.ValueString()
+	content, err := os.ReadFile(requirementsFile)
+	if err != nil {
+		diags.AddAttributeError(path.Root("requirements_file"), "Failed to read the requirements file", err.Error())
+		return nil
+	}
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:16])
+
+	cacheDir := providerData.GetGalaxy().GetCollectionsCacheDir()
+	dir := filepath.Join(cacheDir, hash)
+
+	// Resources with the same requirements wait for the first one to install
+	// them
+	release, err := AcquireRunLock(ctx, "requirements:"+hash)
+	if err != nil {
+		diags.AddError("Failed to wait for the installation of the requirements", err.Error())
+		return nil
+	}
+	defer release()
+
+	if directoryExists(filepath.Join(dir, "ansible_collections")) {
+		tflog.Debug(ctx, fmt.Sprintf("The requirements of %s are cached in %s", requirementsFile, dir))
+	} else {
+		// An empty entry of an installation that went elsewhere is replaced
+		if err := os.RemoveAll(dir); err != nil {
+			diags.AddError("Failed to remove an incomplete entry of the collections cache", err.Error())
+			return nil
+		}
+		installRequirements(ctx, data, providerData, requirementsFile, cacheDir, dir, diags)
+		if diags.HasError() {
+			return nil
+		}
+	}
+
+	collectionsPaths := CollectionsPaths()
+	if existing := env["ANSIBLE_COLLECTIONS_PATH"]; len(existing) > 0 {
+		collectionsPaths = filepath.SplitList(existing)
+	}
+	collectionsPaths = append([]string{dir}, collectionsPaths...)
+	return []string{"ANSIBLE_COLLECTIONS_PATH=" + strings.Join(collectionsPaths, string(os.PathListSeparator))}
+}
+
+// Install the requirements into a temporary directory of the cache first and
+// rename it to dir when done, so neither failed installations nor the ones of
+// parallel applies leave a partial dir behind.
+func installRequirements(ctx context.Context, data *PlaybookResourceModel, providerData *ProviderData, requirementsFile string, cacheDir string, dir string, diags *diag.Diagnostics) {
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		diags.AddError("Failed to create the collections cache", err.Error())
+		return
+	}
+	tempDir, err := os.MkdirTemp(cacheDir, filepath.Base(dir)+".tmp-*")
+	if err != nil {
+		diags.AddError("Failed to create the collections cache", err.Error())
+		return
+	}
+	defer os.RemoveAll(tempDir)
+
+	binary := siblingBinary(data.AnsiblePlaybookBinary.ValueString(), "ansible-galaxy")
+	args := append([]string{"collection", "install", "-r", requirementsFile, "-p", tempDir}, providerData.GetGalaxy().InstallArgs()...)
+	cmd, newDiags := providerData.ResourceCommand(ctx, data, binary, providerData.GetGalaxy().Env(), []string{tempDir}, args...)
+	diags.Append(newDiags...)
+	if diags.HasError() {
+		return
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	tflog.Info(ctx, fmt.Sprintf("Installing the requirements of %s into %s", requirementsFile, dir))
+
+	if err := cmd.Run(); err != nil {
+		diags.AddAttributeError(path.Root("requirements_file"), fmt.Sprintf("%s collection install failed", binary),
+			fmt.Sprintf("%s\n%s\n%s", err, stdout.String(), stderr.String()))
+		return
+	}
+
+	// An installation that didn't write the collections, e.g. into another
+	// file system, must not be cached as a finished one
+	if !directoryExists(filepath.Join(tempDir, "ansible_collections")) {
+		diags.AddAttributeError(path.Root("requirements_file"), fmt.Sprintf("%s collection install installed nothing", binary),
+			fmt.Sprintf("No ansible_collections directory was created in %s.\n%s\n%s", tempDir, stdout.String(), stderr.String()))
+		return
+	}
+
+	if err := os.Rename(tempDir, dir); err != nil && !directoryExists(dir) {
+		diags.AddError("Failed to add the requirements to the collections cache", err.Error())
+	}
+}
@@ -0,0 +1,174 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"gopkg.in/yaml.v2"
+)
+
+// A collection of a requirements file, given as a name or an object
+type CollectionRequirement struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
+func (c *CollectionRequirement) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		c.Name = name
+		return nil
+	}
+
+	type plain CollectionRequirement
+	return unmarshal((*plain)(c))
+}
+
+// Parse the collections of a requirements file, like `ansible-galaxy
+// collection install -r` reads it
+func ParseCollectionRequirements(content []byte) ([]CollectionRequirement, error) {
+	var requirements struct {
+		Collections []CollectionRequirement `yaml:"collections"`
+	}
+	if err := yaml.Unmarshal(content, &requirements); err != nil {
+		return nil, err
+	}
+	return requirements.Collections, nil
+}
+
+// The installed collections and their versions from the output of
+// `ansible-galaxy collection list --format json`. If a collection is
+// installed in several paths, the first one is used, like ansible does.
+func ParseInstalledCollections(output []byte) (map[string]string, error) {
+	installed := map[string]string{}
+
+	// The paths are in the order of precedence, so the object is read in
+	// order instead of into a map
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	for decoder.More() {
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		var collections map[string]struct {
+			Version string `json:"version"`
+		}
+		if err := decoder.Decode(&collections); err != nil {
+			return nil, err
+		}
+
+		for name, collection := range collections {
+			if _, found := installed[name]; !found {
+				installed[name] = collection.Version
+			}
+		}
+	}
+
+	return installed, nil
+}
+
+// Compare the installed collections with the requirements. Returns a line per
+// collection that is missing or doesn't satisfy its version, in the order of
+// the requirements.
+func DiffCollections(requirements []CollectionRequirement, installed map[string]string) ([]string, error) {
+	var diff []string
+	for _, requirement := range requirements {
+		installedVersion, found := installed[requirement.Name]
+		required := requirement.Version
+		if len(required) == 0 {
+			required = "*"
+		}
+
+		if !found {
+			diff = append(diff, fmt.Sprintf("- %s: %s required, not installed", requirement.Name, required))
+			continue
+		}
+		if required == "*" {
+			continue
+		}
+
+		// ansible-galaxy writes an exact version as ==1.0.0
+		constraint, err := version.NewConstraint(strings.ReplaceAll(required, "==", "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q of %s: %s", required, requirement.Name, err)
+		}
+		current, err := version.NewVersion(installedVersion)
+		if err != nil || !constraint.Check(current) {
+			diff = append(diff, fmt.Sprintf("~ %s: %s required, %s installed", requirement.Name, required, installedVersion))
+		}
+	}
+
+	return diff, nil
+}
+
+// The path of another ansible binary next to binary, e.g. ansible-galaxy for
+// ansible-playbook
+func siblingBinary(binary string, name string) string {
+	dir, base := filepath.Split(binary)
+	return dir + strings.Replace(base, "ansible-playbook", name, 1)
+}
+
+// Report an error with the differences, if the installed collections don't
+// match collections_lock_file
+func verifyCollectionsLock(ctx context.Context, data *PlaybookResourceModel, providerData *ProviderData, diags *diag.Diagnostics) {
+	if data.CollectionsLockFile.IsNull() || data.CollectionsLockFile.IsUnknown() || data.AnsiblePlaybookBinary.IsUnknown() {
+		return
+	}
+
+	content, err := os.ReadFile(data.CollectionsLockFile.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("collections_lock_file"), "Failed to read the collections lock file", err.Error())
+		return
+	}
+	requirements, err := ParseCollectionRequirements(content)
+	if err != nil {
+		diags.AddAttributeError(path.Root("collections_lock_file"), "Failed to parse the collections lock file", err.Error())
+		return
+	}
+
+	binary := siblingBinary(data.AnsiblePlaybookBinary.ValueString(), "ansible-galaxy")
+	cmd, newDiags := providerData.ResourceCommand(ctx, data, binary, providerData.GetGalaxy().Env(), nil, "collection", "list", "--format", "json")
+	diags.Append(newDiags...)
+	if diags.HasError() {
+		return
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd, PatternRedactor(ctx, data))
+
+	if err := cmd.Run(); err != nil {
+		diags.AddError(fmt.Sprintf("%s collection list failed", binary), fmt.Sprintf("%s\n%s", err, stderr.String()))
+		return
+	}
+
+	installed, err := ParseInstalledCollections(stdout.Bytes())
+	if err != nil {
+		diags.AddError("Failed to parse the output of ansible-galaxy collection list", fmt.Sprintf("%s\n\nSTDOUT:\n%s", err, stdout.String()))
+		return
+	}
+
+	diff, err := DiffCollections(requirements, installed)
+	if err != nil {
+		diags.AddAttributeError(path.Root("collections_lock_file"), "Invalid collections lock file", err.Error())
+		return
+	}
+	if len(diff) > 0 {
+		diags.AddAttributeError(path.Root("collections_lock_file"), "Installed collections don't match the lock file",
+			fmt.Sprintf("Install them with `ansible-galaxy collection install -r %s --force`:\n%s",
+				data.CollectionsLockFile.ValueString(), strings.Join(diff, "\n")))
+	}
+}
@@ -0,0 +1,242 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ConfigDumpDataSource{}
+var _ datasource.DataSourceWithConfigure = &ConfigDumpDataSource{}
+
+func NewConfigDumpDataSource() datasource.DataSource {
+	return &ConfigDumpDataSource{}
+}
+
+type ConfigDumpDataSource struct {
+	providerData *ProviderData
+}
+
+// ConfigDumpDataSourceModel describes the data source data model.
+type ConfigDumpDataSourceModel struct {
+	AnsibleConfigBinary types.String  `tfsdk:"ansible_config_binary"`
+	ConfigFile          types.String  `tfsdk:"config_file"`
+	OnlyChanged         types.Bool    `tfsdk:"only_changed"`
+	Settings            types.Dynamic `tfsdk:"settings"`
+	Origins             types.Map     `tfsdk:"origins"`
+	RolesPath           types.List    `tfsdk:"roles_path"`
+	CollectionsPath     types.List    `tfsdk:"collections_path"`
+	StdoutCallback      types.String  `tfsdk:"stdout_callback"`
+	CallbacksEnabled    types.List    `tfsdk:"callbacks_enabled"`
+}
+
+// A setting of `ansible-config dump --format json`
+type ConfigSetting struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value"`
+	Origin string      `json:"origin"`
+}
+
+// Parse the output of `ansible-config dump --format json`. Depending on the
+// ansible version it's a list of settings or an object keyed by setting name.
+func ParseConfigDump(output []byte) (map[string]ConfigSetting, error) {
+	settings := map[string]ConfigSetting{}
+
+	var list []ConfigSetting
+	if err := json.Unmarshal(output, &list); err == nil {
+		for _, setting := range list {
+			settings[setting.Name] = setting
+		}
+		return settings, nil
+	}
+
+	var object map[string]ConfigSetting
+	if err := json.Unmarshal(output, &object); err != nil {
+		return nil, err
+	}
+	for name, setting := range object {
+		setting.Name = name
+		settings[name] = setting
+	}
+	return settings, nil
+}
+
+// Interpret a setting as a list of strings. Path lists may be reported as a
+// single string separated by the path list separator.
+func configStringList(setting ConfigSetting) []string {
+	values := []string{}
+	switch v := setting.Value.(type) {
+	case []interface{}:
+		for _, element := range v {
+			values = append(values, fmt.Sprint(element))
+		}
+	case string:
+		values = append(values, strings.FieldsFunc(v, func(r rune) bool {
+			return r == os.PathListSeparator || r == ','
+		})...)
+	}
+	return values
+}
+
+func (d *ConfigDumpDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_config_dump"
+}
+
+func (d *ConfigDumpDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reads the effective Ansible configuration with `ansible-config dump --format json`, e.g. to check assumptions about roles or collections paths before running playbooks.",
+
+		Attributes: map[string]schema.Attribute{
+			"ansible_config_binary": schema.StringAttribute{
+				MarkdownDescription: "Defaults to `ansible-config`.",
+				Optional:            true,
+			},
+			"config_file": schema.StringAttribute{
+				MarkdownDescription: "Path to the ansible.cfg to read, passed as `ANSIBLE_CONFIG`. By default ansible looks for it the usual way.",
+				Optional:            true,
+			},
+			"only_changed": schema.BoolAttribute{
+				MarkdownDescription: "Only dump the settings that differ from the defaults (`--only-changed`). Defaults to false.",
+				Optional:            true,
+			},
+			"settings": schema.DynamicAttribute{
+				Computed:    true,
+				Description: "The value of every setting, keyed by setting name, e.g. DEFAULT_ROLES_PATH.",
+			},
+			"origins": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Where the value of every setting comes from, e.g. default, an environment variable or a config file.",
+			},
+			"roles_path": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The DEFAULT_ROLES_PATH setting.",
+			},
+			"collections_path": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The COLLECTIONS_PATHS setting.",
+			},
+			"stdout_callback": schema.StringAttribute{
+				Computed:    true,
+				Description: "The DEFAULT_STDOUT_CALLBACK setting.",
+			},
+			"callbacks_enabled": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The CALLBACKS_ENABLED setting.",
+			},
+		},
+	}
+}
+
+func (d *ConfigDumpDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.providerData = providerData
+}
+
+func (d *ConfigDumpDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ConfigDumpDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	binary := "ansible-config"
+	if !data.AnsibleConfigBinary.IsNull() {
+		binary = data.AnsibleConfigBinary.ValueString()
+	}
+
+	args := []string{"dump", "--format", "json"}
+	if data.OnlyChanged.ValueBool() {
+		args = append(args, "--only-changed")
+	}
+
+	var env []string
+	if !data.ConfigFile.IsNull() {
+		env = append(env, "ANSIBLE_CONFIG="+data.ConfigFile.ValueString())
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := d.providerData.AnsibleCommand(ctx, binary, env, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd, nil)
+
+	if err := cmd.Run(); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("%s dump failed", binary), fmt.Sprintf("%s\n%s", err, stderr.String()))
+		return
+	}
+
+	settings, err := ParseConfigDump(stdout.Bytes())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to parse the output of ansible-config", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(setConfigDumpModel(ctx, settings, &data)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func setConfigDumpModel(ctx context.Context, settings map[string]ConfigSetting, data *ConfigDumpDataSourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	values := make(map[string]interface{}, len(settings))
+	origins := make(map[string]string, len(settings))
+	for name, setting := range settings {
+		values[name] = setting.Value
+		origins[name] = setting.Origin
+	}
+
+	data.Settings = types.DynamicValue(JSONToAttrValue(values, nil))
+
+	originsValue, newDiags := types.MapValueFrom(ctx, types.StringType, origins)
+	diags.Append(newDiags...)
+	data.Origins = originsValue
+
+	rolesPath, newDiags := types.ListValueFrom(ctx, types.StringType, configStringList(settings["DEFAULT_ROLES_PATH"]))
+	diags.Append(newDiags...)
+	data.RolesPath = rolesPath
+
+	collectionsPath, newDiags := types.ListValueFrom(ctx, types.StringType, configStringList(settings["COLLECTIONS_PATHS"]))
+	diags.Append(newDiags...)
+	data.CollectionsPath = collectionsPath
+
+	callbacksEnabled, newDiags := types.ListValueFrom(ctx, types.StringType, configStringList(settings["CALLBACKS_ENABLED"]))
+	diags.Append(newDiags...)
+	data.CallbacksEnabled = callbacksEnabled
+
+	if callback, found := settings["DEFAULT_STDOUT_CALLBACK"]; found && callback.Value != nil {
+		data.StdoutCallback = types.StringValue(fmt.Sprint(callback.Value))
+	} else {
+		data.StdoutCallback = types.StringNull()
+	}
+
+	return diags
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// The forks of ansible if not configured otherwise, i.e. the connections a
+// run opens at most at the same time
+const defaultForks = 5
+
+// ConnectionLimiter caps the connections all runs of the provider open to
+// the hosts at the same time, e.g. to stay below the limits of a bastion
+// host. Every run reserves the connections it may open, its forks.
+type ConnectionLimiter struct {
+	mu   sync.Mutex
+	free int
+	// Closed and replaced whenever connections are released
+	released chan struct{}
+}
+
+func NewConnectionLimiter(maxConnections int) *ConnectionLimiter {
+	return &ConnectionLimiter{free: maxConnections, released: make(chan struct{})}
+}
+
+// Wait until at least one connection is free, then reserve up to forks of
+// them. Returns the number of reserved connections, the forks the run may
+// use, and a function releasing them. A nil limiter reserves nothing and
+// returns 0.
+func (l *ConnectionLimiter) Acquire(ctx context.Context, forks int) (int, func(), error) {
+	if l == nil {
+		return 0, func() {}, nil
+	}
+
+	waiting := false
+	for {
+		l.mu.Lock()
+		if l.free > 0 {
+			reserved := min(l.free, forks)
+			l.free -= reserved
+			l.mu.Unlock()
+			return reserved, func() { l.release(reserved) }, nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		if !waiting {
+			waiting = true
+			tflog.Info(ctx, "Waiting for other playbook runs to free connections, max_connections is reached")
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+	}
+}
+
+func (l *ConnectionLimiter) release(connections int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.free += connections
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// The forks of a run: ANSIBLE_FORKS in env, see EffectiveEnvironment,
+// otherwise forks of ansible.cfg, otherwise ansible's default. Containers
+// don't see the ansible.cfg of the provider.
+func RunForks(data *PlaybookResourceModel, env map[string]string) int {
+	forks := env["ANSIBLE_FORKS"]
+	if configFile := ansibleConfigFile(); len(forks) == 0 && !runsInContainer(data) && len(configFile) > 0 {
+		forks = readAnsibleConfig(configFile, "forks")
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(forks)); err == nil && n > 0 {
+		return n
+	}
+	return defaultForks
+}
+
+// Reserve the connections of a run with forks with the limiter of the
+// provider. Returns the arguments limiting the forks of the run to the
+// reserved connections, which take precedence over the environment and
+// ansible.cfg, and a function releasing them.
+func AcquireConnections(ctx context.Context, providerData *ProviderData, forks int) ([]string, func(), error) {
+	reserved, release, err := providerData.GetConnectionLimiter().Acquire(ctx, forks)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reserved == 0 {
+		return nil, release, nil
+	}
+	tflog.Debug(ctx, fmt.Sprintf("Reserved %d of %d connections", reserved, forks))
+	return []string{"--forks", strconv.Itoa(reserved)}, release, nil
+}
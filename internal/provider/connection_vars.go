@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Write the connection variables of the resource, of winrm, ssh, bastion and
+// known_hosts_entries, to a temporary file, to be passed with `-e @file`, so
+// they apply whatever the inventory format is and secrets aren't on the
+// command line. Returns "" if there are none, all temporary files written,
+// which have to be removed, and the secrets to redact.
+func WriteConnectionVars(ctx context.Context, data *PlaybookResourceModel, diags *diag.Diagnostics) (string, []string, []string) {
+	vars := map[string]interface{}{}
+	var tempFiles, secrets []string
+
+	if !data.WinRM.IsNull() && !data.WinRM.IsUnknown() {
+		var winrm WinRMModel
+		diags.Append(data.WinRM.As(ctx, &winrm, basetypes.ObjectAsOptions{})...)
+		if diags.HasError() {
+			return "", nil, nil
+		}
+
+		for name, value := range winrm.Vars() {
+			vars[name] = value
+		}
+		if !winrm.Password.IsNull() {
+			secrets = append(secrets, winrm.Password.ValueString())
+		}
+	}
+
+	if !data.SSH.IsNull() && !data.SSH.IsUnknown() {
+		var ssh SSHModel
+		diags.Append(data.SSH.As(ctx, &ssh, basetypes.ObjectAsOptions{})...)
+		if diags.HasError() {
+			return "", nil, nil
+		}
+
+		for name, value := range ssh.Vars() {
+			vars[name] = value
+		}
+	}
+
+	knownHostsFile := WriteKnownHosts(ctx, data.KnownHostsEntries, diags)
+	if len(knownHostsFile) > 0 {
+		tempFiles = append(tempFiles, knownHostsFile)
+		vars["ansible_ssh_extra_args"] = KnownHostsSSHArgs(knownHostsFile)
+		vars["ansible_host_key_checking"] = true
+	}
+	if diags.HasError() {
+		return "", tempFiles, nil
+	}
+
+	if !data.Bastion.IsNull() && !data.Bastion.IsUnknown() {
+		var bastion BastionModel
+		diags.Append(data.Bastion.As(ctx, &bastion, basetypes.ObjectAsOptions{})...)
+		if diags.HasError() {
+			return "", tempFiles, nil
+		}
+
+		if keyFile := bastion.AddVars(ctx, vars, knownHostsFile, diags); len(keyFile) > 0 {
+			tempFiles = append(tempFiles, keyFile)
+		}
+		if !bastion.PrivateKey.IsNull() {
+			secrets = append(secrets, bastion.PrivateKey.ValueString())
+		}
+		if diags.HasError() {
+			return "", tempFiles, nil
+		}
+	}
+
+	if len(vars) == 0 {
+		return "", tempFiles, secrets
+	}
+
+	content, err := json.Marshal(vars)
+	if err != nil {
+		diags.AddError("Failed to encode the connection variables", err.Error())
+		return "", tempFiles, nil
+	}
+
+	varsFile := WriteTempFile(ctx, "connection-vars-*.json", string(content), diags)
+	if len(varsFile) > 0 {
+		tempFiles = append(tempFiles, varsFile)
+	}
+	return varsFile, tempFiles, secrets
+}
@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var containerEngines = []string{"docker", "podman"}
+
+// The path of the SSH agent socket inside the container
+const containerSSHAuthSock = "/run/ssh-agent.sock"
+
+// The prefix of the names of the containers the provider runs
+const containerNamePrefix = "terraform-ansible-"
+
+// How long to wait for the engine to kill a container
+const containerKillTimeout = 30 * time.Second
+
+// The paths ansible needs in the container: the working directory, which is
+// the project, the directories of the playbook and var files, and the
+// temporary files and directories of the run, like the inventory. Nothing
+// else of the temporary directory is shared with the container. They are
+// mounted at the same paths, so the arguments don't need to be rewritten.
+func containerMounts(playbook string, varFiles []string, runFiles []string) []string {
+	var dirs []string
+	if cwd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, cwd)
+	}
+
+	for _, file := range append([]string{playbook}, varFiles...) {
+		if dir, err := filepath.Abs(filepath.Dir(file)); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, file := range runFiles {
+		if len(file) == 0 {
+			continue
+		}
+		if file, err := filepath.Abs(file); err == nil {
+			dirs = append(dirs, file)
+		}
+	}
+
+	// Resolve symlinks like /tmp -> /private/tmp, as the engine mounts the
+	// target anyway
+	for i, dir := range dirs {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dirs[i] = resolved
+		}
+	}
+
+	// Leave out paths within others
+	sort.Strings(dirs)
+	var mounts []string
+	for _, dir := range dirs {
+		if len(mounts) > 0 {
+			last := mounts[len(mounts)-1]
+			if dir == last || (len(dir) > len(last) && dir[:len(last)] == last && (last == "/" || dir[len(last)] == filepath.Separator)) {
+				continue
+			}
+		}
+		mounts = append(mounts, dir)
+	}
+	return mounts
+}
+
+// Build the command to run an ansible binary in a container of the resource's
+// container_image, mounting the temporary files and directories of the run
+// in runFiles. Besides the mounts of containerMounts, ~/.ssh is mounted
+// read-only at /root/.ssh and the SSH agent is forwarded, so the keys of the
+// runner can be used. The variables of env are only named on the command
+// line, the engine takes their values from its own environment, so they
+// don't show up in the process list. The container is named, so
+// KillContainer can stop it.
+func ContainerCommand(ctx context.Context, data *PlaybookResourceModel, binary string, env []string, runFiles []string, args ...string) (*exec.Cmd, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var varFiles, volumes []string
+	diags.Append(data.VarFiles.ElementsAs(ctx, &varFiles, false)...)
+	diags.Append(data.ContainerVolumes.ElementsAs(ctx, &volumes, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	engine := data.ContainerEngine.ValueString()
+	if len(engine) == 0 {
+		engine = "docker"
+	}
+
+	engineArgs := []string{"run", "--rm", "--name", containerNamePrefix + uuid.New().String(), "--network", "host"}
+
+	for _, mount := range containerMounts(data.Playbook.ValueString(), varFiles, runFiles) {
+		engineArgs = append(engineArgs, "--volume", mount+":"+mount)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if info, err := os.Stat(filepath.Join(home, ".ssh")); err == nil && info.IsDir() {
+			engineArgs = append(engineArgs, "--volume", filepath.Join(home, ".ssh")+":/root/.ssh:ro")
+		}
+	}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); len(sock) > 0 {
+		engineArgs = append(engineArgs, "--volume", sock+":"+containerSSHAuthSock, "--env", "SSH_AUTH_SOCK="+containerSSHAuthSock)
+	}
+	for _, volume := range volumes {
+		engineArgs = append(engineArgs, "--volume", volume)
+	}
+
+	named := map[string]bool{}
+	for _, variable := range env {
+		name, _, _ := strings.Cut(variable, "=")
+		if !named[name] {
+			named[name] = true
+			engineArgs = append(engineArgs, "--env", name)
+		}
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		engineArgs = append(engineArgs, "--workdir", cwd)
+	}
+
+	engineArgs = append(engineArgs, "--entrypoint", binary, data.ContainerImage.ValueString())
+	engineArgs = append(engineArgs, args...)
+
+	cmd := exec.CommandContext(ctx, engine, engineArgs...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd, diags
+}
+
+// The name of the container of a command of ContainerCommand, if cmd is one
+func containerName(cmd *exec.Cmd) (string, bool) {
+	if len(cmd.Args) < 5 || cmd.Args[1] != "run" || cmd.Args[3] != "--name" || !strings.HasPrefix(cmd.Args[4], containerNamePrefix) {
+		return "", false
+	}
+	return cmd.Args[4], true
+}
+
+// Kill the container of cmd, if it runs ansible in one. Once the command was
+// canceled, the container would keep running without the engine's client,
+// which only forwards signals to it.
+func KillContainer(ctx context.Context, cmd *exec.Cmd) {
+	name, found := containerName(cmd)
+	if !found {
+		return
+	}
+
+	// The context of the run has ended by then
+	killCtx, cancel := context.WithTimeout(context.Background(), containerKillTimeout)
+	defer cancel()
+
+	tflog.Warn(ctx, fmt.Sprintf("Killing container %s, as the operation ended", name))
+	if output, err := exec.CommandContext(killCtx, cmd.Path, "kill", name).CombinedOutput(); err != nil {
+		// The container may have stopped on its own already
+		tflog.Debug(ctx, fmt.Sprintf("Failed to kill container %s: %s\n%s", name, err, output))
+	}
+}
+
+// Whether the resource runs ansible in a container
+func runsInContainer(data *PlaybookResourceModel) bool {
+	return !data.ContainerImage.IsNull() && len(data.ContainerImage.ValueString()) > 0
+}
+
+// Build the command to run ansible-playbook for the resource, in a container
+// if container_image is set
+func (d *ProviderData) PlaybookCommand(ctx context.Context, data *PlaybookResourceModel, env []string, runFiles []string, args ...string) (*exec.Cmd, diag.Diagnostics) {
+	return d.ResourceCommand(ctx, data, data.AnsiblePlaybookBinary.ValueString(), env, runFiles, args...)
+}
+
+// Build the command to run an ansible binary for the resource, in a
+// container if container_image is set, with the temporary files and
+// directories of the run in runFiles mounted. The environment of the resource
+// overrides the one of the provider, and is overridden by env.
+func (d *ProviderData) ResourceCommand(ctx context.Context, data *PlaybookResourceModel, binary string, env []string, runFiles []string, args ...string) (*exec.Cmd, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	env = append(environmentList(resourceEnvironment(ctx, data, &diags)), env...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	if runsInContainer(data) {
+		return ContainerCommand(ctx, data, binary, append(environmentList(d.GetEnvironment()), env...), runFiles, args...)
+	}
+	return d.AnsibleCommand(ctx, binary, env, args...), nil
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestContainerMounts(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd, _ = filepath.EvalSymlinks(cwd)
+	dir, _ := filepath.EvalSymlinks(t.TempDir())
+	inventory := filepath.Join(dir, ".terraform-ansible-inventory-1.yml")
+	callbacks := filepath.Join(dir, ".terraform-ansible-callbacks-1")
+	for _, file := range []string{inventory, callbacks} {
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		playbook string
+		runFiles []string
+		want     []string
+	}{
+		{name: "project only", playbook: "site.yml", want: []string{cwd}},
+		{name: "run files", playbook: "site.yml", runFiles: []string{inventory, "", callbacks}, want: []string{callbacks, inventory, cwd}},
+		{name: "run files in a mounted directory", playbook: filepath.Join(dir, "site.yml"), runFiles: []string{inventory}, want: []string{dir, cwd}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := containerMounts(test.playbook, nil, test.runFiles)
+			want := slices.Clone(test.want)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("containerMounts() = %q, want %q", got, want)
+			}
+			if slices.Contains(got, os.TempDir()) {
+				t.Errorf("containerMounts() = %q mounts the whole temporary directory", got)
+			}
+		})
+	}
+}
+
+func TestContainerCommand(t *testing.T) {
+	data := &PlaybookResourceModel{
+		Playbook:         types.StringValue("site.yml"),
+		VarFiles:         types.ListNull(types.StringType),
+		ContainerVolumes: types.ListNull(types.StringType),
+		ContainerEngine:  types.StringValue("podman"),
+		ContainerImage:   types.StringValue("ansible:latest"),
+	}
+	cmd, diags := ContainerCommand(context.Background(), data, "ansible-playbook", nil, nil, "site.yml")
+	if diags.HasError() {
+		t.Fatalf("ContainerCommand() diagnostics: %v", diags)
+	}
+	if !slices.Contains(cmd.Args, "--rm") {
+		t.Errorf("ContainerCommand() = %q, want the container removed after the run", cmd.Args)
+	}
+
+	name, found := containerName(cmd)
+	if !found || !strings.HasPrefix(name, containerNamePrefix) {
+		t.Errorf("containerName() = %q, %t, want a container of the provider", name, found)
+	}
+
+	if name, found := containerName(exec.Command("ansible-playbook", "-i", "hosts.ini", "site.yml")); found {
+		t.Errorf("containerName() = %q for a command without a container", name)
+	}
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeArtifact(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name: "plays and stats",
+			input: `{
+				"custom_stats": {},
+				"global_custom_stats": {"nested": [1, {"a": [true, null]}]},
+				"plays": [
+					{
+						"play": {"name": "web", "id": "1"},
+						"tasks": [
+							{"task": {"name": "ping"}, "hosts": {"a": {"changed": false, "msg": "pong"}}},
+							{"task": {"name": "fail"}, "hosts": {"b": {"failed": true, "msg": ["x", "y"], "results": [{"failed": true}]}}}
+						]
+					},
+					{"play": {"name": "db"}, "tasks": [{"task": {"name": "ping"}, "hosts": {}}]}
+				],
+				"stats": {"a": {"ok": 1, "failures": 0}, "b": {"failures": 1, "unreachable": 0}}
+			}`,
+		},
+		{name: "empty object", input: `{}`},
+		{name: "no plays", input: `{"stats": {"a": {"unreachable": 1}}}`},
+		{name: "not an object", input: `[]`, wantErr: true},
+		{name: "truncated", input: `{"plays": [{"play": {"name": "web"}, "tasks": [`, wantErr: true},
+		{name: "empty", input: ``, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := DecodeArtifact(strings.NewReader(test.input))
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("DecodeArtifact() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeArtifact() error: %v", err)
+			}
+
+			var want Root
+			if err := json.Unmarshal([]byte(test.input), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("DecodeArtifact() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
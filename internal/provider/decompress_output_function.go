@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &DecompressOutputFunction{}
+
+func NewDecompressOutputFunction() function.Function {
+	return &DecompressOutputFunction{}
+}
+
+type DecompressOutputFunction struct {
+}
+
+func (f *DecompressOutputFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "decompress_output"
+}
+
+func (f *DecompressOutputFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Decompress output stored with compress_output",
+		MarkdownDescription: "Decodes `ansible_playbook_stdout` or `ansible_playbook_stderr` of a playbook resource with `compress_output` enabled.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "compressed",
+				MarkdownDescription: "The gzipped and base64 encoded output.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *DecompressOutputFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var compressed string
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &compressed))
+	if resp.Error != nil {
+		return
+	}
+
+	output, err := DecompressOutput(compressed)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, output))
+}
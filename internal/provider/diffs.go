@@ -0,0 +1,248 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Lines of context around the changes of a unified diff, like ansible shows
+const diffContext = 3
+
+// Files with more line pairs than this are shown as fully replaced, instead
+// of computing the longest common subsequence
+const maxDiffLinePairs = 4000000
+
+// A diff returned by a module with --diff. Before and after are strings, or
+// objects for modules like file that diff their state.
+type ModuleDiff struct {
+	Before       interface{} `json:"before"`
+	After        interface{} `json:"after"`
+	BeforeHeader string      `json:"before_header"`
+	AfterHeader  string      `json:"after_header"`
+	Prepared     string      `json:"prepared"`
+}
+
+// Parse the diff of a result, a single diff or a list of them
+func ParseModuleDiffs(raw json.RawMessage) []ModuleDiff {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var diffs []ModuleDiff
+	if err := json.Unmarshal(raw, &diffs); err == nil {
+		return diffs
+	}
+	var diff ModuleDiff
+	if err := json.Unmarshal(raw, &diff); err == nil {
+		return []ModuleDiff{diff}
+	}
+	return nil
+}
+
+// The diff as text, like ansible prints it
+func (d ModuleDiff) Text() string {
+	if len(d.Prepared) > 0 {
+		return d.Prepared
+	}
+	if d.Before == nil && d.After == nil {
+		return ""
+	}
+
+	before, after := diffSide(d.Before), diffSide(d.After)
+	if before == after {
+		return ""
+	}
+
+	beforeHeader, afterHeader := "before", "after"
+	if len(d.BeforeHeader) > 0 {
+		beforeHeader += ": " + d.BeforeHeader
+	}
+	if len(d.AfterHeader) > 0 {
+		afterHeader += ": " + d.AfterHeader
+	}
+	return UnifiedDiff(before, after, beforeHeader, afterHeader)
+}
+
+// Objects are compared as indented JSON, like ansible does
+func diffSide(side interface{}) string {
+	switch value := side.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	}
+
+	encoded, err := json.MarshalIndent(side, "", "    ")
+	if err != nil {
+		return fmt.Sprint(side)
+	}
+	return string(encoded) + "\n"
+}
+
+// The diffs of the artifact, keyed by task name. The diffs of every host are
+// headed by the host name, and the diffs of tasks with the same name are
+// concatenated.
+func CollectDiffs(root Root) map[string]string {
+	diffs := map[string]string{}
+	for _, play := range root.Plays {
+		for _, task := range play.Tasks {
+			hosts := make([]string, 0, len(task.Hosts))
+			for host := range task.Hosts {
+				hosts = append(hosts, host)
+			}
+			sort.Strings(hosts)
+
+			for _, host := range hosts {
+				result := task.Hosts[host]
+
+				var texts []string
+				for _, diff := range ParseModuleDiffs(result.Diff) {
+					texts = append(texts, diff.Text())
+				}
+				for _, item := range result.Results {
+					for _, diff := range ParseModuleDiffs(item.Diff) {
+						texts = append(texts, diff.Text())
+					}
+				}
+
+				text := strings.Join(nonEmptyStrings(texts), "\n")
+				if len(text) > 0 {
+					diffs[task.Task.Name] += fmt.Sprintf("[%s]\n%s", host, text)
+				}
+			}
+		}
+	}
+	return diffs
+}
+
+func nonEmptyStrings(values []string) []string {
+	result := values[:0]
+	for _, value := range values {
+		if len(value) > 0 {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// A line based diff of before and after in the unified format
+func UnifiedDiff(before string, after string, beforeHeader string, afterHeader string) string {
+	a, b := splitLines(before), splitLines(after)
+
+	// The operations of the diff, ' ' for common lines, '-' and '+'
+	type operation struct {
+		kind byte
+		line string
+	}
+	var operations []operation
+
+	if len(a)*len(b) > maxDiffLinePairs {
+		for _, line := range a {
+			operations = append(operations, operation{'-', line})
+		}
+		for _, line := range b {
+			operations = append(operations, operation{'+', line})
+		}
+	} else {
+		// lengths[i][j] is the length of the longest common subsequence of
+		// a[i:] and b[j:]
+		lengths := make([][]int, len(a)+1)
+		for i := range lengths {
+			lengths[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lengths[i][j] = lengths[i+1][j+1] + 1
+				} else {
+					lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			switch {
+			case i < len(a) && j < len(b) && a[i] == b[j]:
+				operations = append(operations, operation{' ', a[i]})
+				i++
+				j++
+			case i < len(a) && (j == len(b) || lengths[i+1][j] >= lengths[i][j+1]):
+				operations = append(operations, operation{'-', a[i]})
+				i++
+			default:
+				operations = append(operations, operation{'+', b[j]})
+				j++
+			}
+		}
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %s\n+++ %s\n", beforeHeader, afterHeader)
+
+	// Group the operations into hunks of changes with their context
+	for start := 0; start < len(operations); {
+		if operations[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		first := max(start-diffContext, 0)
+		last := start
+		for k := start; k < len(operations) && k <= last+2*diffContext; k++ {
+			if operations[k].kind != ' ' {
+				last = k
+			}
+		}
+		end := min(last+diffContext+1, len(operations))
+
+		beforeStart, afterStart := 1, 1
+		for _, op := range operations[:first] {
+			if op.kind != '+' {
+				beforeStart++
+			}
+			if op.kind != '-' {
+				afterStart++
+			}
+		}
+		beforeLines, afterLines := 0, 0
+		for _, op := range operations[first:end] {
+			if op.kind != '+' {
+				beforeLines++
+			}
+			if op.kind != '-' {
+				afterLines++
+			}
+		}
+		fmt.Fprintf(&builder, "@@ -%s +%s @@\n", hunkRange(beforeStart, beforeLines), hunkRange(afterStart, afterLines))
+		for _, op := range operations[first:end] {
+			builder.WriteByte(op.kind)
+			builder.WriteString(op.line)
+			builder.WriteByte('\n')
+		}
+
+		start = end
+	}
+
+	return builder.String()
+}
+
+func hunkRange(start int, lines int) string {
+	if lines == 0 {
+		// An empty range starts at the line before it
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
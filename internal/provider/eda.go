@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type EDAModel struct {
+	URL      types.String `tfsdk:"url"`
+	Token    types.String `tfsdk:"token"`
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+	Insecure types.Bool   `tfsdk:"insecure"`
+}
+
+// A client of the API of the Event-Driven Ansible controller
+type EDAClient struct {
+	APIClient
+}
+
+func NewEDAClient(model *EDAModel) *EDAClient {
+	token := model.Token.ValueString()
+	username := model.Username.ValueString()
+	password := model.Password.ValueString()
+	return &EDAClient{NewAPIClient(model.URL.ValueString(), model.Insecure.ValueBool(), func(req *http.Request) {
+		if len(token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else if len(username) > 0 {
+			req.SetBasicAuth(username, password)
+		}
+	})}
+}
+
+// A rulebook activation of EDA
+type EDAActivation struct {
+	Id            int64  `json:"id"`
+	Name          string `json:"name"`
+	IsEnabled     bool   `json:"is_enabled"`
+	Status        string `json:"status"`
+	RestartPolicy string `json:"restart_policy"`
+}
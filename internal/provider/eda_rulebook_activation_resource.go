@@ -0,0 +1,325 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &EDARulebookActivationResource{}
+var _ resource.ResourceWithValidateConfig = &EDARulebookActivationResource{}
+var _ resource.ResourceWithConfigure = &EDARulebookActivationResource{}
+
+func NewEDARulebookActivationResource() resource.Resource {
+	return &EDARulebookActivationResource{}
+}
+
+// EDARulebookActivationResource manages a rulebook activation of the EDA
+// controller of the provider.
+type EDARulebookActivationResource struct {
+	providerData *ProviderData
+}
+
+// EDARulebookActivationResourceModel describes the resource data model.
+type EDARulebookActivationResourceModel struct {
+	Name                  types.String `tfsdk:"name"`
+	Description           types.String `tfsdk:"description"`
+	RulebookId            types.Int64  `tfsdk:"rulebook_id"`
+	DecisionEnvironmentId types.Int64  `tfsdk:"decision_environment_id"`
+	OrganizationId        types.Int64  `tfsdk:"organization_id"`
+	AWXTokenId            types.Int64  `tfsdk:"awx_token_id"`
+	ExtraVars             types.Map    `tfsdk:"extra_vars"`
+	RestartPolicy         types.String `tfsdk:"restart_policy"`
+	Enabled               types.Bool   `tfsdk:"enabled"`
+	Status                types.String `tfsdk:"status"`
+	Id                    types.String `tfsdk:"id"`
+}
+
+var restartPolicies = []string{"on-failure", "always", "never"}
+
+// The fields of a new activation
+type edaNewActivation struct {
+	Name                  string `json:"name"`
+	Description           string `json:"description,omitempty"`
+	RulebookId            int64  `json:"rulebook_id"`
+	DecisionEnvironmentId int64  `json:"decision_environment_id"`
+	OrganizationId        int64  `json:"organization_id,omitempty"`
+	AWXTokenId            int64  `json:"awx_token_id,omitempty"`
+	// The extra variables as YAML, which JSON is
+	ExtraVar      string `json:"extra_var,omitempty"`
+	RestartPolicy string `json:"restart_policy"`
+	IsEnabled     bool   `json:"is_enabled"`
+}
+
+func (r *EDARulebookActivationResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_eda_rulebook_activation"
+}
+
+func (r *EDARulebookActivationResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages a rulebook activation of the Event-Driven Ansible controller configured in the `eda` block of the provider, so the event-driven automation lives and dies with the infrastructure it reacts to. Changing anything but `enabled` replaces the activation.",
+
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the activation.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "Description of the activation.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"rulebook_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the rulebook to activate.",
+				Required:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"decision_environment_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the decision environment to run the rulebook in.",
+				Required:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"organization_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the organization of the activation, required by newer EDA controllers.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"awx_token_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the AWX token of the user, for rulebooks with `run_job_template` actions.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"extra_vars": schema.MapAttribute{
+				MarkdownDescription: "Variables of the rulebook.",
+				Optional:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"restart_policy": schema.StringAttribute{
+				MarkdownDescription: "When EDA restarts the activation, `on-failure`, `always` or `never`. Defaults to `on-failure`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("on-failure"),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"enabled": schema.BoolAttribute{
+				MarkdownDescription: "Whether the activation runs. Defaults to true.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"status": schema.StringAttribute{
+				MarkdownDescription: "The status of the activation, e.g. `running` or `failed`.",
+				Computed:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "ID of the activation.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *EDARulebookActivationResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.providerData = providerData
+}
+
+func (r *EDARulebookActivationResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config EDARulebookActivationResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validateOneOf(path.Root("restart_policy"), config.RestartPolicy, restartPolicies, &resp.Diagnostics)
+}
+
+func (r *EDARulebookActivationResource) client(diags *diag.Diagnostics) *EDAClient {
+	eda := r.providerData.GetEDA()
+	if eda == nil {
+		diags.AddError("EDA isn't configured", "Configure the eda block of the provider to manage rulebook activations.")
+	}
+	return eda
+}
+
+func activationPath(id string) string {
+	return "/api/eda/v1/activations/" + id + "/"
+}
+
+// Update the computed attributes and the ones EDA changes itself from the
+// activation
+func (m *EDARulebookActivationResourceModel) setActivation(activation EDAActivation) {
+	m.Id = types.StringValue(strconv.FormatInt(activation.Id, 10))
+	m.Name = types.StringValue(activation.Name)
+	m.Enabled = types.BoolValue(activation.IsEnabled)
+	m.Status = types.StringValue(activation.Status)
+	if len(activation.RestartPolicy) > 0 {
+		m.RestartPolicy = types.StringValue(activation.RestartPolicy)
+	}
+}
+
+func (r *EDARulebookActivationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data EDARulebookActivationResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	eda := r.client(&resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	newActivation := edaNewActivation{
+		Name:                  data.Name.ValueString(),
+		Description:           data.Description.ValueString(),
+		RulebookId:            data.RulebookId.ValueInt64(),
+		DecisionEnvironmentId: data.DecisionEnvironmentId.ValueInt64(),
+		OrganizationId:        data.OrganizationId.ValueInt64(),
+		AWXTokenId:            data.AWXTokenId.ValueInt64(),
+		RestartPolicy:         data.RestartPolicy.ValueString(),
+		IsEnabled:             data.Enabled.ValueBool(),
+	}
+	if !data.ExtraVars.IsNull() {
+		var extraVars map[string]string
+		resp.Diagnostics.Append(data.ExtraVars.ElementsAs(ctx, &extraVars, false)...)
+		encoded, err := json.Marshal(extraVars)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to encode the extra variables", err.Error())
+		}
+		newActivation.ExtraVar = string(encoded)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var activation EDAActivation
+	if err := eda.Do(ctx, http.MethodPost, "/api/eda/v1/activations/", newActivation, &activation); err != nil {
+		resp.Diagnostics.AddError("Failed to create the rulebook activation", err.Error())
+		return
+	}
+	tflog.Info(ctx, fmt.Sprintf("Created EDA rulebook activation %d", activation.Id))
+
+	data.setActivation(activation)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *EDARulebookActivationResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data EDARulebookActivationResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	eda := r.client(&resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var activation EDAActivation
+	err := eda.Do(ctx, http.MethodGet, activationPath(data.Id.ValueString()), nil, &activation)
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read the rulebook activation", err.Error())
+		return
+	}
+
+	data.setActivation(activation)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *EDARulebookActivationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data EDARulebookActivationResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	eda := r.client(&resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Everything else requires a replacement
+	action := "disable"
+	if data.Enabled.ValueBool() {
+		action = "enable"
+	}
+	if err := eda.Do(ctx, http.MethodPost, activationPath(data.Id.ValueString())+action+"/", nil, nil); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to %s the rulebook activation", action), err.Error())
+		return
+	}
+
+	var activation EDAActivation
+	if err := eda.Do(ctx, http.MethodGet, activationPath(data.Id.ValueString()), nil, &activation); err != nil {
+		resp.Diagnostics.AddError("Failed to read the rulebook activation", err.Error())
+		return
+	}
+
+	data.setActivation(activation)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *EDARulebookActivationResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data EDARulebookActivationResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	eda := r.client(&resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := eda.Do(ctx, http.MethodDelete, activationPath(data.Id.ValueString()), nil, nil)
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to delete the rulebook activation", err.Error())
+	}
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"maps"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Variables whose values are masked in effective_environment, e.g.
+// ANSIBLE_BECOME_PASS or ANSIBLE_CACHE_PLUGIN_CONNECTION, which may contain the
+// password of the cache
+var sensitiveVariableRegexp = regexp.MustCompile(`TOKEN|PASS|SECRET|PRIVATE_KEY|CONNECTION`)
+
+// The environment the ansible commands of a resource run with, before the
+// variables the provider sets for a run: the environment of the resource,
+// of the provider and of the provider process, in that order of precedence.
+// Containers don't get the environment of the provider process.
+func EffectiveEnvironment(ctx context.Context, data *PlaybookResourceModel, providerData *ProviderData, diags *diag.Diagnostics) map[string]string {
+	env := map[string]string{}
+	if !runsInContainer(data) {
+		for _, variable := range os.Environ() {
+			// Windows has variables like =C:, which aren't ones really
+			if name, value, _ := strings.Cut(variable, "="); len(name) > 0 {
+				env[name] = value
+			}
+		}
+	}
+	maps.Copy(env, providerData.GetEnvironment())
+	maps.Copy(env, resourceEnvironment(ctx, data, diags))
+	return env
+}
+
+func resourceEnvironment(ctx context.Context, data *PlaybookResourceModel, diags *diag.Diagnostics) map[string]string {
+	var env map[string]string
+	if !data.Environment.IsUnknown() {
+		diags.Append(data.Environment.ElementsAs(ctx, &env, false)...)
+	}
+	return env
+}
+
+// The variables of env as NAME=value, sorted by name
+func environmentList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for _, name := range SortedKeys(env) {
+		list = append(list, name+"="+env[name])
+	}
+	return list
+}
+
+// The values of the variables of env, as NAME=value, whose names look like
+// secrets
+func sensitiveEnvironmentValues(env []string) []string {
+	var values []string
+	for _, variable := range env {
+		name, value, _ := strings.Cut(variable, "=")
+		if len(value) > 0 && sensitiveVariableRegexp.MatchString(name) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// The ANSIBLE_* variables of the effective environment for
+// effective_environment, with secrets masked
+func AnsibleEnvironmentValue(ctx context.Context, env map[string]string, redactor *Redactor, diags *diag.Diagnostics) types.Map {
+	ansibleEnv := map[string]string{}
+	for name, value := range env {
+		if !strings.HasPrefix(name, "ANSIBLE_") {
+			continue
+		}
+		if sensitiveVariableRegexp.MatchString(name) {
+			value = redactedPlaceholder
+		}
+		ansibleEnv[name] = redactor.Redact(value)
+	}
+
+	value, newDiags := types.MapValueFrom(ctx, types.StringType, ansibleEnv)
+	diags.Append(newDiags...)
+	return value
+}
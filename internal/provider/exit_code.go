@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// Exit codes of ansible-playbook
+const (
+	ExitCodeOK                = 0
+	ExitCodeError             = 1
+	ExitCodeFailedHosts       = 2
+	ExitCodeUnreachableLegacy = 3
+	ExitCodeUnreachable       = 4
+	ExitCodeBadOptions        = 5
+	ExitCodeFailedBreakPlay   = 8
+	ExitCodeInterrupted       = 99
+	ExitCodeUnexpectedError   = 250
+	ExitCodeUnknownError      = 255
+)
+
+// The exit code of a finished command, or -1 if it couldn't be started or
+// was killed by a signal.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitCode()
+	}
+	return -1
+}
+
+// Describe what an exit code of ansible-playbook means. Exit code 4 is used
+// for unreachable hosts as well as parser errors, so whether there were
+// unreachable hosts has to be passed in.
+func DescribeExitCode(code int, unreachable bool) string {
+	switch code {
+	case ExitCodeOK:
+		return "success"
+	case ExitCodeError:
+		return "error"
+	case ExitCodeFailedHosts:
+		return "one or more hosts failed"
+	case ExitCodeUnreachableLegacy:
+		return "one or more hosts were unreachable"
+	case ExitCodeUnreachable:
+		if unreachable {
+			return "one or more hosts were unreachable"
+		}
+		return "the playbook or inventory couldn't be parsed"
+	case ExitCodeBadOptions:
+		return "bad or incomplete command line options"
+	case ExitCodeFailedBreakPlay:
+		return "a play was aborted, because of any_errors_fatal or max_fail_percentage"
+	case ExitCodeInterrupted:
+		return "interrupted"
+	case ExitCodeUnexpectedError, ExitCodeUnknownError:
+		return "unexpected error"
+	}
+	return fmt.Sprintf("unknown exit code %d", code)
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	exitError := func(code string) error {
+		return exec.Command("sh", "-c", "exit "+code).Run()
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: ExitCodeOK},
+		{name: "failed hosts", err: exitError("2"), want: ExitCodeFailedHosts},
+		{name: "unreachable", err: exitError("4"), want: ExitCodeUnreachable},
+		{name: "not started", err: exec.Command("/nonexistent/ansible-playbook").Run(), want: -1},
+		{name: "other error", err: errors.New("boom"), want: -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExitCode(test.err); got != test.want {
+				t.Errorf("ExitCode() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDescribeExitCode(t *testing.T) {
+	tests := []struct {
+		code        int
+		unreachable bool
+		want        string
+	}{
+		{code: ExitCodeOK, want: "success"},
+		{code: ExitCodeFailedHosts, want: "one or more hosts failed"},
+		{code: ExitCodeUnreachable, unreachable: true, want: "one or more hosts were unreachable"},
+		{code: ExitCodeUnreachable, want: "the playbook or inventory couldn't be parsed"},
+		{code: ExitCodeUnreachableLegacy, want: "one or more hosts were unreachable"},
+		{code: ExitCodeFailedBreakPlay, want: "a play was aborted, because of any_errors_fatal or max_fail_percentage"},
+		{code: ExitCodeUnknownError, want: "unexpected error"},
+		{code: 42, want: "unknown exit code 42"},
+	}
+
+	for _, test := range tests {
+		if got := DescribeExitCode(test.code, test.unreachable); got != test.want {
+			t.Errorf("DescribeExitCode(%d, %t) = %q, want %q", test.code, test.unreachable, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// Write sensitive_extra_vars to a temporary file, to be passed with
+// `-e @file`, so their values aren't on the command line, where other users
+// can see them in the process list. Returns "" if there are none, otherwise
+// the file, which has to be removed.
+func WriteSensitiveExtraVars(ctx context.Context, sensitiveExtraVars map[string]string, diags *diag.Diagnostics) string {
+	if len(sensitiveExtraVars) == 0 {
+		return ""
+	}
+
+	content, err := json.Marshal(sensitiveExtraVars)
+	if err != nil {
+		diags.AddError("Failed to encode the sensitive extra variables", err.Error())
+		return ""
+	}
+
+	return WriteTempFile(ctx, "sensitive-vars-*.json", string(content), diags)
+}
+
+// The arguments passing extra_vars as a single JSON object, so ansible takes
+// the values literally, whatever quotes, spaces or `=` they contain. The keys
+// are sorted by encoding/json, so the command line is the same on every run.
+func ExtraVarsArgs(extraVars map[string]string) []string {
+	if len(extraVars) == 0 {
+		return nil
+	}
+
+	// Maps of strings always encode
+	content, _ := json.Marshal(extraVars)
+	return []string{"-e", string(content)}
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func TestWriteSensitiveExtraVars(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+	}{
+		{name: "none", vars: nil},
+		{name: "empty", vars: map[string]string{}},
+		{name: "values", vars: map[string]string{"password": "hunter2", "token": "abc"}},
+		{name: "special characters", vars: map[string]string{"password": `it's "quoted" \ with spaces`, "key": "line\nbreak"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			file := WriteSensitiveExtraVars(context.Background(), test.vars, &diags)
+			if diags.HasError() {
+				t.Fatalf("WriteSensitiveExtraVars() diagnostics: %v", diags)
+			}
+			if len(test.vars) == 0 {
+				if file != "" {
+					os.Remove(file)
+					t.Errorf("WriteSensitiveExtraVars() = %q, want no file", file)
+				}
+				return
+			}
+			defer os.Remove(file)
+
+			content, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]string
+			if err := json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("WriteSensitiveExtraVars() wrote invalid JSON %q: %v", content, err)
+			}
+			if !maps.Equal(got, test.vars) {
+				t.Errorf("WriteSensitiveExtraVars() wrote %v, want %v", got, test.vars)
+			}
+		})
+	}
+}
+
+func TestExtraVarsArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want []string
+	}{
+		{name: "none", vars: nil, want: nil},
+		{name: "sorted", vars: map[string]string{"b": "2", "c": "3", "a": "1"}, want: []string{"-e", `{"a":"1","b":"2","c":"3"}`}},
+		{name: "quotes and spaces", vars: map[string]string{"msg": `it's "a" test`}, want: []string{"-e", `{"msg":"it's \"a\" test"}`}},
+		{name: "equals sign", vars: map[string]string{"query": "a=b c=d"}, want: []string{"-e", `{"query":"a=b c=d"}`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Map iteration order is random, the arguments must not be
+			for i := 0; i < 10; i++ {
+				if got := ExtraVarsArgs(test.vars); !slices.Equal(got, test.want) {
+					t.Fatalf("ExtraVarsArgs() = %q, want %q", got, test.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// The cache plugins of fact_caching, redis has moved to community.general
+var factCachingPlugins = map[string]string{
+	"jsonfile": "jsonfile",
+	"redis":    "community.general.redis",
+}
+
+var factCachingValues = SortedKeys(factCachingPlugins)
+
+// The environment of the fact cache of the resource: fact_caching with
+// fact_caching_connection, or jsonfile in fact_cache_dir. Facts are only
+// gathered for hosts without cached facts, in plays that don't set
+// gather_facts. Returns nil if no fact cache is configured.
+func FactCachingEnv(data *PlaybookResourceModel) []string {
+	var env []string
+
+	connection := data.FactCachingConnection.ValueString()
+	if !data.FactCacheDir.IsNull() {
+		env = FactCacheEnv(data.FactCacheDir.ValueString())
+	} else if !data.FactCaching.IsNull() || len(connection) > 0 {
+		plugin := "jsonfile"
+		if !data.FactCaching.IsNull() {
+			plugin = data.FactCaching.ValueString()
+		}
+		env = []string{"ANSIBLE_CACHE_PLUGIN=" + factCachingPlugins[plugin], "ANSIBLE_GATHERING=smart"}
+		if len(connection) > 0 {
+			env = append(env, "ANSIBLE_CACHE_PLUGIN_CONNECTION="+connection)
+		}
+	}
+
+	if !data.FactCachingTimeout.IsNull() {
+		env = append(env, "ANSIBLE_CACHE_PLUGIN_TIMEOUT="+strconv.FormatInt(data.FactCachingTimeout.ValueInt64(), 10))
+	}
+	return env
+}
+
+func validateFactCaching(config *PlaybookResourceModel, diags *diag.Diagnostics) {
+	validateOneOf(path.Root("fact_caching"), config.FactCaching, factCachingValues, diags)
+
+	if !config.FactCacheDir.IsNull() && !config.FactCachingConnection.IsNull() {
+		diags.AddAttributeError(path.Root("fact_caching_connection"), "Conflicting fact cache",
+			"fact_cache_dir is the connection of the jsonfile cache, so only one of fact_cache_dir and fact_caching_connection can be set.")
+	}
+	if !config.FactCacheDir.IsNull() && !config.FactCaching.IsNull() && !config.FactCaching.IsUnknown() && config.FactCaching.ValueString() != "jsonfile" {
+		diags.AddAttributeError(path.Root("fact_caching"), "Conflicting fact cache",
+			"fact_cache_dir uses the jsonfile cache, use fact_caching_connection for other caches.")
+	}
+	if config.FactCaching.ValueString() == "jsonfile" && config.FactCacheDir.IsNull() && config.FactCachingConnection.IsNull() {
+		diags.AddAttributeError(path.Root("fact_caching_connection"), "Missing fact cache directory",
+			"The jsonfile cache needs a directory, set fact_caching_connection or fact_cache_dir.")
+	}
+	if !config.FactCachingTimeout.IsNull() && !config.FactCachingTimeout.IsUnknown() && config.FactCachingTimeout.ValueInt64() < 0 {
+		diags.AddAttributeError(path.Root("fact_caching_timeout"), "Invalid timeout",
+			"fact_caching_timeout must not be negative, 0 keeps facts forever.")
+	}
+}
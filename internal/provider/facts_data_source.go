@@ -0,0 +1,247 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &FactsDataSource{}
+var _ datasource.DataSourceWithConfigure = &FactsDataSource{}
+
+func NewFactsDataSource() datasource.DataSource {
+	return &FactsDataSource{}
+}
+
+type FactsDataSource struct {
+	providerData *ProviderData
+}
+
+// FactsDataSourceModel describes the data source data model.
+type FactsDataSourceModel struct {
+	Inventory        types.String  `tfsdk:"inventory"`
+	Hosts            types.String  `tfsdk:"hosts"`
+	GatherSubset     types.List    `tfsdk:"gather_subset"`
+	FactCacheDir     types.String  `tfsdk:"fact_cache_dir"`
+	Select           types.List    `tfsdk:"select"`
+	AnsibleBinary    types.String  `tfsdk:"ansible_binary"`
+	Facts            types.Dynamic `tfsdk:"facts"`
+	UnreachableHosts types.List    `tfsdk:"unreachable_hosts"`
+}
+
+// The environment to cache facts in dir with the jsonfile cache plugin.
+// Plays that don't set gather_facts skip gathering for hosts with cached facts.
+func FactCacheEnv(dir string) []string {
+	return []string{
+		"ANSIBLE_CACHE_PLUGIN=jsonfile",
+		"ANSIBLE_CACHE_PLUGIN_CONNECTION=" + dir,
+		"ANSIBLE_GATHERING=smart",
+	}
+}
+
+// The result of the setup module for a host in the output of the json callback
+type setupResult struct {
+	Failed      bool                   `json:"failed"`
+	Unreachable bool                   `json:"unreachable"`
+	Msg         interface{}            `json:"msg"`
+	Facts       map[string]interface{} `json:"ansible_facts"`
+}
+
+// The facts per host of `ansible -m setup` with the json callback, and the
+// hosts that failed or were unreachable with their error.
+func ParseGatheredFacts(output []byte) (map[string]map[string]interface{}, map[string]string, error) {
+	var root struct {
+		Plays []struct {
+			Tasks []struct {
+				Hosts map[string]setupResult `json:"hosts"`
+			} `json:"tasks"`
+		} `json:"plays"`
+	}
+	if err := json.Unmarshal(output, &root); err != nil {
+		return nil, nil, err
+	}
+
+	facts := map[string]map[string]interface{}{}
+	failures := map[string]string{}
+	for _, play := range root.Plays {
+		for _, task := range play.Tasks {
+			for host, result := range task.Hosts {
+				if result.Failed || result.Unreachable {
+					failures[host] = fmt.Sprint(result.Msg)
+					continue
+				}
+				facts[host] = result.Facts
+			}
+		}
+	}
+	return facts, failures, nil
+}
+
+// Only keep the facts in names, if any are given. The ansible_ prefix is
+// optional, like in playbooks.
+func selectFacts(facts map[string]interface{}, names []string) map[string]interface{} {
+	if len(names) == 0 {
+		return facts
+	}
+
+	selected := map[string]interface{}{}
+	for name, value := range facts {
+		if slices.Contains(names, name) || slices.Contains(names, strings.TrimPrefix(name, "ansible_")) {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+
+func (d *FactsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_facts"
+}
+
+func (d *FactsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Gathers the facts of the hosts of an inventory with `ansible -m setup` and caches them in `fact_cache_dir`. Playbook resources with the same `fact_cache_dir` then skip fact gathering for the cached hosts, so the facts are only gathered once per apply.",
+
+		Attributes: map[string]schema.Attribute{
+			"inventory": schema.StringAttribute{
+				MarkdownDescription: "The inventory to use. Not a path, the contents.",
+				Required:            true,
+			},
+			"hosts": schema.StringAttribute{
+				MarkdownDescription: "The host pattern to gather facts of. Defaults to `all`.",
+				Optional:            true,
+			},
+			"gather_subset": schema.ListAttribute{
+				MarkdownDescription: "The subsets of facts to gather, passed to the setup module, e.g. `[\"!all\", \"network\"]`. Defaults to all. Plays that need facts outside the subset have to gather them themselves.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"fact_cache_dir": schema.StringAttribute{
+				MarkdownDescription: "The directory to cache the facts in, with the jsonfile cache plugin. Facts expire after ansible's `fact_caching_timeout`, 24 hours by default.",
+				Required:            true,
+			},
+			"select": schema.ListAttribute{
+				MarkdownDescription: "The facts to expose in `facts`, e.g. `[\"default_ipv4\", \"distribution\"]`, with or without the `ansible_` prefix. All facts are still cached. Defaults to all facts.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"ansible_binary": schema.StringAttribute{
+				MarkdownDescription: "Defaults to `ansible`.",
+				Optional:            true,
+			},
+			"facts": schema.DynamicAttribute{
+				Computed:    true,
+				Description: "The selected facts, keyed by host and fact name.",
+			},
+			"unreachable_hosts": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The hosts facts couldn't be gathered of, sorted. They are reported as warnings as well.",
+			},
+		},
+	}
+}
+
+func (d *FactsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.providerData = providerData
+}
+
+func (d *FactsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data FactsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	var gatherSubset, selected []string
+	resp.Diagnostics.Append(data.GatherSubset.ElementsAs(ctx, &gatherSubset, false)...)
+	resp.Diagnostics.Append(data.Select.ElementsAs(ctx, &selected, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	binary := "ansible"
+	if !data.AnsibleBinary.IsNull() {
+		binary = data.AnsibleBinary.ValueString()
+	}
+
+	hosts := "all"
+	if !data.Hosts.IsNull() {
+		hosts = data.Hosts.ValueString()
+	}
+
+	tempInventory := SharedInventory(ctx, InventoryPattern(data.Inventory.ValueString(), "auto"), data.Inventory.ValueString(), &resp.Diagnostics)
+	if len(tempInventory) > 0 {
+		defer ReleaseInventory(tempInventory, &resp.Diagnostics)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	args := []string{hosts, "-i", tempInventory, "-m", "setup"}
+	if len(gatherSubset) > 0 {
+		args = append(args, "-a", "gather_subset="+strings.Join(gatherSubset, ","))
+	}
+
+	// Ad hoc commands only use the stdout callback if callbacks are loaded
+	env := append([]string{"ANSIBLE_LOAD_CALLBACK_PLUGINS=1", "ANSIBLE_STDOUT_CALLBACK=json"}, FactCacheEnv(data.FactCacheDir.ValueString())...)
+
+	var stdout, stderr bytes.Buffer
+	cmd := d.providerData.AnsibleCommand(ctx, binary, env, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd, nil)
+
+	// Failed and unreachable hosts are reported as warnings
+	if err := cmd.Run(); err != nil && !slices.Contains([]int{ExitCodeFailedHosts, ExitCodeUnreachableLegacy, ExitCodeUnreachable}, ExitCode(err)) {
+		resp.Diagnostics.AddError(fmt.Sprintf("%s -m setup failed", binary), fmt.Sprintf("%s\n%s", err, stderr.String()))
+		return
+	}
+
+	facts, failures, err := ParseGatheredFacts(stdout.Bytes())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to parse the output of ansible -m setup", fmt.Sprintf("%s\n\nSTDOUT:\n%s", err, stdout.String()))
+		return
+	}
+
+	unreachableHosts := make([]string, 0, len(failures))
+	for host, msg := range failures {
+		unreachableHosts = append(unreachableHosts, host)
+		resp.Diagnostics.AddWarning(fmt.Sprintf("Failed to gather the facts of %s", host), msg)
+	}
+	sort.Strings(unreachableHosts)
+
+	selectedFacts := make(map[string]interface{}, len(facts))
+	for host, hostFacts := range facts {
+		selectedFacts[host] = selectFacts(hostFacts, selected)
+	}
+	data.Facts = types.DynamicValue(JSONToAttrValue(selectedFacts, nil))
+
+	unreachableHostsValue, diags := types.ListValueFrom(ctx, types.StringType, unreachableHosts)
+	resp.Diagnostics.Append(diags...)
+	data.UnreachableHosts = unreachableHostsValue
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
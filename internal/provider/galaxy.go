@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Galaxy server names become part of environment variable names
+var galaxyServerNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+type GalaxyModel struct {
+	Servers             []GalaxyServerModel `tfsdk:"servers"`
+	Offline             types.Bool          `tfsdk:"offline"`
+	IgnoreCerts         types.Bool          `tfsdk:"ignore_certs"`
+	CollectionsCacheDir types.String        `tfsdk:"collections_cache_dir"`
+}
+
+type GalaxyServerModel struct {
+	Name    types.String `tfsdk:"name"`
+	URL     types.String `tfsdk:"url"`
+	Token   types.String `tfsdk:"token"`
+	AuthURL types.String `tfsdk:"auth_url"`
+}
+
+type GalaxyServer struct {
+	Name    string
+	URL     string
+	Token   string
+	AuthURL string
+}
+
+// The galaxy settings of the provider, for the ansible-galaxy commands it runs
+type GalaxyConfig struct {
+	Servers     []GalaxyServer
+	Offline     bool
+	IgnoreCerts bool
+	// The directory the collections of requirements_file are installed to
+	CollectionsCacheDir string
+}
+
+func NewGalaxyConfig(model *GalaxyModel) *GalaxyConfig {
+	config := &GalaxyConfig{
+		Offline:     model.Offline.ValueBool(),
+		IgnoreCerts: model.IgnoreCerts.ValueBool(),
+	}
+	if len(model.CollectionsCacheDir.ValueString()) > 0 {
+		config.CollectionsCacheDir = expandHome(model.CollectionsCacheDir.ValueString())
+	}
+	for _, server := range model.Servers {
+		config.Servers = append(config.Servers, GalaxyServer{
+			Name:    server.Name.ValueString(),
+			URL:     server.URL.ValueString(),
+			Token:   server.Token.ValueString(),
+			AuthURL: server.AuthURL.ValueString(),
+		})
+	}
+	return config
+}
+
+// The environment to configure ansible-galaxy with, overriding the servers
+// of ansible.cfg if any are configured
+func (c *GalaxyConfig) Env() []string {
+	if c == nil {
+		return nil
+	}
+
+	var env []string
+	if c.IgnoreCerts {
+		env = append(env, "ANSIBLE_GALAXY_IGNORE=true")
+	}
+	if len(c.Servers) == 0 {
+		return env
+	}
+
+	names := make([]string, 0, len(c.Servers))
+	for _, server := range c.Servers {
+		names = append(names, server.Name)
+
+		prefix := "ANSIBLE_GALAXY_SERVER_" + strings.ToUpper(server.Name) + "_"
+		env = append(env, prefix+"URL="+server.URL)
+		if len(server.Token) > 0 {
+			env = append(env, prefix+"TOKEN="+server.Token)
+		}
+		if len(server.AuthURL) > 0 {
+			env = append(env, prefix+"AUTH_URL="+server.AuthURL)
+		}
+	}
+	return append(env, "ANSIBLE_GALAXY_SERVER_LIST="+strings.Join(names, ","))
+}
+
+// The arguments of `ansible-galaxy collection install`, to only install from
+// local sources when offline
+func (c *GalaxyConfig) InstallArgs() []string {
+	if c == nil || !c.Offline {
+		return nil
+	}
+	return []string{"--offline"}
+}
+
+// The directory of the shared collections cache, the user cache directory if
+// not configured
+func (c *GalaxyConfig) GetCollectionsCacheDir() string {
+	if c == nil || len(c.CollectionsCacheDir) == 0 {
+		return defaultCollectionsCacheDir()
+	}
+	return c.CollectionsCacheDir
+}
@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// The most digests the cache keeps, the ones of the most recently modified
+// files
+const fileDigestCacheMaxEntries = 100000
+
+// Digest of a file, valid as long as its size and modification time don't change
+type fileDigestEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"`
+	Digest  []byte `json:"digest"`
+}
+
+// Caches the SHA-256 digests of files between provider invocations, so
+// unchanged files in large role trees aren't read again on every plan.
+type FileDigestCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]fileDigestEntry
+	dirty   bool
+}
+
+var (
+	fileDigestCache     *FileDigestCache
+	fileDigestCacheOnce sync.Once
+)
+
+func DefaultFileDigestCache() *FileDigestCache {
+	fileDigestCacheOnce.Do(func() {
+		// Prefer the per-user cache directory, so other users can't tamper with the digests
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			cacheDir = os.TempDir()
+		}
+		fileDigestCache = LoadFileDigestCache(filepath.Join(cacheDir, "terraform-provider-ansible", "hash-cache.json"))
+	})
+	return fileDigestCache
+}
+
+// Load the cache from cachePath. A missing or broken cache file results in
+// an empty cache. Entries of files that no longer exist are dropped once
+// here, rather than on every save.
+func LoadFileDigestCache(cachePath string) *FileDigestCache {
+	cache := &FileDigestCache{path: cachePath, entries: map[string]fileDigestEntry{}}
+
+	content, err := os.ReadFile(cachePath)
+	if err == nil {
+		if err := json.Unmarshal(content, &cache.entries); err != nil {
+			cache.entries = map[string]fileDigestEntry{}
+		}
+	}
+
+	for key := range cache.entries {
+		if _, err := os.Stat(key); os.IsNotExist(err) {
+			delete(cache.entries, key)
+			cache.dirty = true
+		}
+	}
+
+	if len(cache.entries) > fileDigestCacheMaxEntries {
+		keys := make([]string, 0, len(cache.entries))
+		for key := range cache.entries {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return cache.entries[keys[i]].ModTime > cache.entries[keys[j]].ModTime
+		})
+		for _, key := range keys[fileDigestCacheMaxEntries:] {
+			delete(cache.entries, key)
+		}
+		cache.dirty = true
+	}
+
+	return cache
+}
+
+func (c *FileDigestCache) Digest(filePath string) ([]byte, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := filepath.Abs(filePath)
+	if err != nil {
+		key = filePath
+	}
+
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	c.mu.Unlock()
+
+	if found && entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano() {
+		return entry.Digest, nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	digest := hash.Sum(nil)
+
+	c.mu.Lock()
+	c.entries[key] = fileDigestEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Digest: digest}
+	c.dirty = true
+	c.mu.Unlock()
+
+	return digest, nil
+}
+
+// Persist the cache, if it changed since it was loaded or last saved. The
+// lock is only held while encoding, so hashing goes on while the file is
+// written.
+func (c *FileDigestCache) Save() error {
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	content, err := json.Marshal(c.entries)
+	c.dirty = false
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Saved again next time, if writing fails
+	saved := false
+	defer func() {
+		if !saved {
+			c.mu.Lock()
+			c.dirty = true
+			c.mu.Unlock()
+		}
+	}()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so concurrent readers never see a partial cache
+	tempFile, err := os.CreateTemp(filepath.Dir(c.path), ".hash-cache-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile.Name(), c.path); err != nil {
+		return err
+	}
+
+	saved = true
+	return nil
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// The hosts an update has to run on according to host_triggers: those with a
+// trigger that was added or changed since the last run, sorted. The bool is
+// false if the update has to run on all hosts, because host_triggers isn't
+// set, the last run failed or the playbook or variables changed.
+func TriggeredHosts(ctx context.Context, state *PlaybookResourceModel, plan *PlaybookResourceModel) ([]string, bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if plan.HostTriggers.IsNull() || state.HostTriggers.IsNull() || state.Failed.ValueBool() ||
+		!plan.Playbook.Equal(state.Playbook) || !plan.Plays.Equal(state.Plays) || !plan.Limit.Equal(state.Limit) || !plan.PlaybookHash.Equal(state.PlaybookHash) ||
+		!plan.ExtraVars.Equal(state.ExtraVars) || !plan.GroupVars.Equal(state.GroupVars) || !plan.HostVars.Equal(state.HostVars) || !plan.SensitiveExtraVars.Equal(state.SensitiveExtraVars) ||
+		!plan.VarFiles.Equal(state.VarFiles) {
+		return nil, false, diags
+	}
+
+	var oldTriggers, newTriggers map[string]string
+	diags.Append(state.HostTriggers.ElementsAs(ctx, &oldTriggers, false)...)
+	diags.Append(plan.HostTriggers.ElementsAs(ctx, &newTriggers, false)...)
+	if diags.HasError() {
+		return nil, false, diags
+	}
+
+	hosts := []string{}
+	for host, trigger := range newTriggers {
+		if oldTrigger, found := oldTriggers[host]; !found || oldTrigger != trigger {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+
+	// Without a changed trigger, e.g. when a host was only removed, there is
+	// nothing to limit the run to
+	return hosts, len(hosts) > 0, diags
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func hostTriggersModel(triggers map[string]string) *PlaybookResourceModel {
+	model := &PlaybookResourceModel{
+		Playbook:           types.StringValue("site.yml"),
+		PlaybookHash:       types.StringValue("hash"),
+		ExtraVars:          types.MapNull(types.StringType),
+		SensitiveExtraVars: types.MapNull(types.StringType),
+		VarFiles:           types.ListNull(types.StringType),
+		HostTriggers:       types.MapNull(types.StringType),
+		Plays:              types.ListNull(types.StringType),
+		GroupVars:          types.MapNull(types.StringType),
+		HostVars:           types.MapNull(types.StringType),
+	}
+	if triggers != nil {
+		values := map[string]attr.Value{}
+		for host, trigger := range triggers {
+			values[host] = types.StringValue(trigger)
+		}
+		model.HostTriggers = types.MapValueMust(types.StringType, values)
+	}
+	return model
+}
+
+func TestTriggeredHosts(t *testing.T) {
+	tests := []struct {
+		name        string
+		failed      bool
+		change      func(plan *PlaybookResourceModel)
+		state       map[string]string
+		plan        map[string]string
+		want        []string
+		wantLimited bool
+	}{
+		{
+			name:  "no host_triggers",
+			state: nil,
+			plan:  nil,
+		},
+		{
+			name:  "host_triggers added",
+			state: nil,
+			plan:  map[string]string{"a": "1"},
+		},
+		{
+			name:        "changed trigger",
+			state:       map[string]string{"a": "1", "b": "1"},
+			plan:        map[string]string{"a": "1", "b": "2"},
+			want:        []string{"b"},
+			wantLimited: true,
+		},
+		{
+			name:        "added hosts sorted",
+			state:       map[string]string{"a": "1"},
+			plan:        map[string]string{"a": "1", "c": "1", "b": "1"},
+			want:        []string{"b", "c"},
+			wantLimited: true,
+		},
+		{
+			name:  "removed host only",
+			state: map[string]string{"a": "1", "b": "1"},
+			plan:  map[string]string{"a": "1"},
+			want:  []string{},
+		},
+		{
+			name:   "last run failed",
+			failed: true,
+			state:  map[string]string{"a": "1"},
+			plan:   map[string]string{"a": "2"},
+		},
+		{
+			name:   "playbook hash changed",
+			change: func(plan *PlaybookResourceModel) { plan.PlaybookHash = types.StringValue("other") },
+			state:  map[string]string{"a": "1"},
+			plan:   map[string]string{"a": "2"},
+		},
+		{
+			name: "extra_vars changed",
+			change: func(plan *PlaybookResourceModel) {
+				plan.ExtraVars = types.MapValueMust(types.StringType, map[string]attr.Value{"x": types.StringValue("1")})
+			},
+			state: map[string]string{"a": "1"},
+			plan:  map[string]string{"a": "2"},
+		},
+		{
+			name: "plays changed",
+			change: func(plan *PlaybookResourceModel) {
+				plan.Plays = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("web")})
+			},
+			state: map[string]string{"a": "1"},
+			plan:  map[string]string{"a": "2"},
+		},
+		{
+			name: "group_vars changed",
+			change: func(plan *PlaybookResourceModel) {
+				plan.GroupVars = types.MapValueMust(types.StringType, map[string]attr.Value{"web": types.StringValue("x: 1")})
+			},
+			state: map[string]string{"a": "1"},
+			plan:  map[string]string{"a": "2"},
+		},
+		{
+			name: "host_vars changed",
+			change: func(plan *PlaybookResourceModel) {
+				plan.HostVars = types.MapValueMust(types.StringType, map[string]attr.Value{"a": types.StringValue("x: 1")})
+			},
+			state: map[string]string{"a": "1"},
+			plan:  map[string]string{"a": "2"},
+		},
+		{
+			name:   "limit changed",
+			change: func(plan *PlaybookResourceModel) { plan.Limit = types.StringValue("web") },
+			state:  map[string]string{"a": "1"},
+			plan:   map[string]string{"a": "2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := hostTriggersModel(test.state)
+			plan := hostTriggersModel(test.plan)
+			state.Failed = types.BoolValue(test.failed)
+			if test.change != nil {
+				test.change(plan)
+			}
+
+			hosts, limited, diags := TriggeredHosts(context.Background(), state, plan)
+			if diags.HasError() {
+				t.Fatalf("TriggeredHosts() diagnostics: %v", diags)
+			}
+			if limited != test.wantLimited || !slices.Equal(hosts, test.want) {
+				t.Errorf("TriggeredHosts() = %q, %t, want %q, %t", hosts, limited, test.want, test.wantLimited)
+			}
+		})
+	}
+}
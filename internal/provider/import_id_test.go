@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitImportID(t *testing.T) {
+	dir := t.TempDir()
+	playbook := filepath.Join(dir, "site.yml")
+	inventory := filepath.Join(dir, "hosts.ini")
+	colonPlaybook := filepath.Join(dir, "a:b.yml")
+	for _, file := range []string{playbook, inventory, colonPlaybook} {
+		if err := os.WriteFile(file, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		id            string
+		wantPlaybook  string
+		wantInventory string
+		wantFound     bool
+	}{
+		{name: "plain ID", id: "5b3e1c6a-6ab0-4c8d-9a43-b9f8a4f3e3f1"},
+		{name: "playbook and inventory", id: playbook + ":" + inventory, wantPlaybook: playbook, wantInventory: inventory, wantFound: true},
+		{name: "colon in playbook path", id: colonPlaybook + ":" + inventory, wantPlaybook: colonPlaybook, wantInventory: inventory, wantFound: true},
+		{name: "missing inventory", id: playbook + ":" + filepath.Join(dir, "missing.ini")},
+		{name: "directory", id: dir + ":" + inventory},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotPlaybook, gotInventory, gotFound := splitImportID(test.id)
+			if gotPlaybook != test.wantPlaybook || gotInventory != test.wantInventory || gotFound != test.wantFound {
+				t.Errorf("splitImportID(%q) = %q, %q, %t, want %q, %q, %t", test.id,
+					gotPlaybook, gotInventory, gotFound, test.wantPlaybook, test.wantInventory, test.wantFound)
+			}
+		})
+	}
+}
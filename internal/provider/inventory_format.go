@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"gopkg.in/yaml.v2"
+)
+
+var inventoryFormats = []string{"auto", "yaml", "ini"}
+
+// The format of an inventory, yaml if it's a YAML or JSON mapping, ini
+// otherwise
+func DetectInventoryFormat(content string) string {
+	var inventory map[interface{}]interface{}
+	if err := yaml.Unmarshal([]byte(content), &inventory); err == nil && len(inventory) > 0 {
+		return "yaml"
+	}
+	return "ini"
+}
+
+// The pattern of the temporary inventory file, with the extension of the
+// format, as ansible's yaml inventory plugin only reads .yml files and the
+// ini plugin is tried after it
+func InventoryPattern(content string, format string) string {
+	if format == "" || format == "auto" {
+		format = DetectInventoryFormat(content)
+	}
+	if format == "ini" {
+		return TempFilePattern("inventory-*.ini")
+	}
+	return TempFilePattern("inventory-*.yml")
+}
+
+// Check that ansible-inventory, next to the playbook binary, can parse the
+// inventories. Ansible only warns about an inventory it can't parse, and then
+// runs the playbook on no hosts at all.
+func verifyInventory(ctx context.Context, data *PlaybookResourceModel, providerData *ProviderData, inventories []string, diags *diag.Diagnostics) {
+	args := []string{"--list"}
+	for _, inventory := range inventories {
+		args = append(args, "-i", inventory)
+	}
+
+	binary := siblingBinary(data.AnsiblePlaybookBinary.ValueString(), "ansible-inventory")
+	cmd, newDiags := providerData.ResourceCommand(ctx, data, binary, []string{"ANSIBLE_INVENTORY_UNPARSED_FAILED=true"}, inventories, args...)
+	diags.Append(newDiags...)
+	if diags.HasError() {
+		return
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd, PatternRedactor(ctx, data))
+
+	if err := cmd.Run(); err != nil {
+		diags.AddAttributeError(path.Root("inventory"), "Invalid inventory",
+			fmt.Sprintf("%s failed to parse the inventory: %s\n%s", binary, err, stderr.String()))
+	}
+}
@@ -0,0 +1,224 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v2"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &InventoryFunction{}
+
+func NewInventoryFunction() function.Function {
+	return &InventoryFunction{}
+}
+
+type InventoryFunction struct {
+}
+
+func (f *InventoryFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "inventory"
+}
+
+func (f *InventoryFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Build an inventory in YAML format",
+		MarkdownDescription: "Converts hosts and groups into an inventory in the YAML format, ready to be used as `inventory` of `ansible_playbook`.",
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:                "hosts",
+				MarkdownDescription: "A list of host names, or an object with the variables of every host, keyed by host name, e.g. `{ web1 = { ansible_host = \"10.0.0.1\" } }`.",
+				AllowNullValue:      true,
+			},
+			function.DynamicParameter{
+				Name:                "groups",
+				MarkdownDescription: "An object or map of groups, keyed by group name. Every group may set `hosts` (a list of host names, or an object of host variables), `children` (a list of group names) and `vars` (an object).",
+				AllowNullValue:      true,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *InventoryFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var hostsValue, groupsValue types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &hostsValue, &groupsValue))
+	if resp.Error != nil {
+		return
+	}
+
+	hosts, err := AttrValueToJSON(hostsValue)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid hosts: %s", err))
+		return
+	}
+	groups, err := AttrValueToJSON(groupsValue)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Invalid groups: %s", err))
+		return
+	}
+
+	inventory, argument, err := BuildInventoryYAML(hosts, groups)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(argument, err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, inventory))
+}
+
+// Build a YAML inventory from decoded hosts and groups. On error, the index
+// of the offending argument is returned as well.
+func BuildInventoryYAML(hosts interface{}, groups interface{}) (string, int64, error) {
+	allHosts, err := inventoryHosts(hosts)
+	if err != nil {
+		return "", 0, fmt.Errorf("Invalid hosts: %s", err)
+	}
+
+	groupsMap, ok := groups.(map[string]interface{})
+	if groups != nil && !ok {
+		return "", 1, fmt.Errorf("Invalid groups: expected an object keyed by group name, got %s", jsonTypeName(groups))
+	}
+
+	children := map[string]interface{}{}
+	for name, value := range groupsMap {
+		group, err := inventoryGroup(value)
+		if err != nil {
+			return "", 1, fmt.Errorf("Invalid group %s: %s", name, err)
+		}
+		children[name] = group
+	}
+
+	all := map[string]interface{}{}
+	if len(allHosts) > 0 {
+		all["hosts"] = allHosts
+	}
+	if len(children) > 0 {
+		all["children"] = children
+	}
+
+	content, err := yaml.Marshal(map[string]interface{}{"all": all})
+	if err != nil {
+		return "", 0, err
+	}
+	return string(content), 0, nil
+}
+
+// Hosts are either a list of host names or an object of host variables
+func inventoryHosts(value interface{}) (map[string]interface{}, error) {
+	hosts := map[string]interface{}{}
+
+	switch v := value.(type) {
+	case nil:
+	case []interface{}:
+		for _, host := range v {
+			name, ok := host.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected host names, got %s", jsonTypeName(host))
+			}
+			hosts[name] = map[string]interface{}{}
+		}
+	case map[string]interface{}:
+		for name, vars := range v {
+			switch vars.(type) {
+			case nil:
+				hosts[name] = map[string]interface{}{}
+			case map[string]interface{}:
+				hosts[name] = vars
+			default:
+				return nil, fmt.Errorf("variables of host %s must be an object, got %s", name, jsonTypeName(vars))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("expected a list of host names or an object keyed by host name, got %s", jsonTypeName(value))
+	}
+
+	return hosts, nil
+}
+
+func inventoryGroup(value interface{}) (map[string]interface{}, error) {
+	group := map[string]interface{}{}
+	if value == nil {
+		return group, nil
+	}
+
+	settings, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %s", jsonTypeName(value))
+	}
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		setting := settings[key]
+		if setting == nil {
+			continue
+		}
+
+		switch key {
+		case "hosts":
+			hosts, err := inventoryHosts(setting)
+			if err != nil {
+				return nil, err
+			}
+			if len(hosts) > 0 {
+				group["hosts"] = hosts
+			}
+		case "children":
+			names, ok := setting.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("children must be a list of group names, got %s", jsonTypeName(setting))
+			}
+			children := map[string]interface{}{}
+			for _, child := range names {
+				name, ok := child.(string)
+				if !ok {
+					return nil, fmt.Errorf("children must be a list of group names, got %s", jsonTypeName(child))
+				}
+				children[name] = map[string]interface{}{}
+			}
+			if len(children) > 0 {
+				group["children"] = children
+			}
+		case "vars":
+			vars, ok := setting.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("vars must be an object, got %s", jsonTypeName(setting))
+			}
+			if len(vars) > 0 {
+				group["vars"] = vars
+			}
+		default:
+			return nil, fmt.Errorf("unsupported attribute %q, expected hosts, children or vars", key)
+		}
+	}
+
+	return group, nil
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a bool"
+	case int64, float64:
+		return "a number"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,289 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &InventoryListDataSource{}
+var _ datasource.DataSourceWithValidateConfig = &InventoryListDataSource{}
+var _ datasource.DataSourceWithConfigure = &InventoryListDataSource{}
+
+func NewInventoryListDataSource() datasource.DataSource {
+	return &InventoryListDataSource{}
+}
+
+type InventoryListDataSource struct {
+	providerData *ProviderData
+}
+
+// InventoryListDataSourceModel describes the data source data model.
+type InventoryListDataSourceModel struct {
+	Inventory              types.String  `tfsdk:"inventory"`
+	InventorySources       types.List    `tfsdk:"inventory_sources"`
+	AnsibleInventoryBinary types.String  `tfsdk:"ansible_inventory_binary"`
+	Hosts                  types.List    `tfsdk:"hosts"`
+	Groups                 types.Map     `tfsdk:"groups"`
+	Hostvars               types.Dynamic `tfsdk:"hostvars"`
+	Json                   types.String  `tfsdk:"json"`
+}
+
+type InventoryGroupModel struct {
+	Hosts    types.List `tfsdk:"hosts"`
+	Children types.List `tfsdk:"children"`
+}
+
+func (m InventoryGroupModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"hosts":    types.ListType{ElemType: types.StringType},
+		"children": types.ListType{ElemType: types.StringType},
+	}
+}
+
+// The output of `ansible-inventory --list`
+type InventoryList struct {
+	Hosts    []string
+	Groups   map[string]InventoryListGroup
+	Hostvars map[string]interface{}
+}
+
+type InventoryListGroup struct {
+	Hosts    []string `json:"hosts"`
+	Children []string `json:"children"`
+}
+
+func ParseInventoryList(output []byte) (*InventoryList, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, err
+	}
+
+	inventory := &InventoryList{Groups: map[string]InventoryListGroup{}, Hostvars: map[string]interface{}{}}
+	hosts := map[string]bool{}
+
+	for name, content := range raw {
+		if name == "_meta" {
+			var meta struct {
+				Hostvars map[string]interface{} `json:"hostvars"`
+			}
+			if err := json.Unmarshal(content, &meta); err != nil {
+				return nil, fmt.Errorf("_meta: %w", err)
+			}
+			for host, vars := range meta.Hostvars {
+				inventory.Hostvars[host] = vars
+				hosts[host] = true
+			}
+			continue
+		}
+
+		var group InventoryListGroup
+		if err := json.Unmarshal(content, &group); err != nil {
+			return nil, fmt.Errorf("group %s: %w", name, err)
+		}
+		sort.Strings(group.Hosts)
+		sort.Strings(group.Children)
+		inventory.Groups[name] = group
+		for _, host := range group.Hosts {
+			hosts[host] = true
+		}
+	}
+
+	for host := range hosts {
+		inventory.Hosts = append(inventory.Hosts, host)
+		if _, found := inventory.Hostvars[host]; !found {
+			inventory.Hostvars[host] = map[string]interface{}{}
+		}
+	}
+	sort.Strings(inventory.Hosts)
+
+	return inventory, nil
+}
+
+func (d *InventoryListDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_inventory_list"
+}
+
+func (d *InventoryListDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reads an inventory with `ansible-inventory --list`. Works with every inventory source ansible supports, including dynamic inventory plugins.",
+
+		Attributes: map[string]schema.Attribute{
+			"inventory": schema.StringAttribute{
+				MarkdownDescription: "The inventory to read. Not a path, the contents. Exactly one of `inventory` and `inventory_sources` must be set.",
+				Optional:            true,
+			},
+			"inventory_sources": schema.ListAttribute{
+				MarkdownDescription: "Inventory sources passed with `-i`, e.g. paths to inventory files, directories or inventory plugin configurations. Exactly one of `inventory` and `inventory_sources` must be set.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"ansible_inventory_binary": schema.StringAttribute{
+				MarkdownDescription: "Defaults to `ansible-inventory`.",
+				Optional:            true,
+			},
+			"hosts": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Names of all hosts in the inventory, sorted.",
+			},
+			"groups": schema.MapNestedAttribute{
+				Computed:    true,
+				Description: "The groups of the inventory, keyed by group name.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"hosts": schema.ListAttribute{
+							Computed:    true,
+							ElementType: types.StringType,
+							Description: "Hosts that are direct members of the group.",
+						},
+						"children": schema.ListAttribute{
+							Computed:    true,
+							ElementType: types.StringType,
+							Description: "Names of the child groups.",
+						},
+					},
+				},
+			},
+			"hostvars": schema.DynamicAttribute{
+				Computed:    true,
+				Description: "The variables of every host, keyed by host name, with group variables already merged in.",
+			},
+			"json": schema.StringAttribute{
+				Computed:    true,
+				Description: "The unmodified output of ansible-inventory --list.",
+			},
+		},
+	}
+}
+
+func (d *InventoryListDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.providerData = providerData
+}
+
+func (d *InventoryListDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var config InventoryListDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() || config.Inventory.IsUnknown() || config.InventorySources.IsUnknown() {
+		return
+	}
+
+	if config.Inventory.IsNull() == config.InventorySources.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("inventory"), "Invalid inventory",
+			"Exactly one of inventory and inventory_sources must be set.")
+	}
+}
+
+func (d *InventoryListDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data InventoryListDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var sources []string
+	if !data.InventorySources.IsNull() {
+		resp.Diagnostics.Append(data.InventorySources.ElementsAs(ctx, &sources, false)...)
+	} else {
+		tempInventory := SharedInventory(ctx, InventoryPattern(data.Inventory.ValueString(), "auto"), data.Inventory.ValueString(), &resp.Diagnostics)
+		if len(tempInventory) > 0 {
+			defer ReleaseInventory(tempInventory, &resp.Diagnostics)
+		}
+		sources = append(sources, tempInventory)
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	binary := "ansible-inventory"
+	if !data.AnsibleInventoryBinary.IsNull() {
+		binary = data.AnsibleInventoryBinary.ValueString()
+	}
+
+	args := []string{"--list"}
+	for _, source := range sources {
+		args = append(args, "-i", source)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := d.providerData.AnsibleCommand(ctx, binary, nil, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd, nil)
+
+	if err := cmd.Run(); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("%s --list failed", binary), fmt.Sprintf("%s\n%s", err, stderr.String()))
+		return
+	}
+
+	inventory, err := ParseInventoryList(stdout.Bytes())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to parse the output of ansible-inventory", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(inventory.SetModel(ctx, &data)...)
+	data.Json = types.StringValue(stdout.String())
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (inventory *InventoryList) SetModel(ctx context.Context, data *InventoryListDataSourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	hosts, newDiags := types.ListValueFrom(ctx, types.StringType, nonNilStrings(inventory.Hosts))
+	diags.Append(newDiags...)
+	data.Hosts = hosts
+
+	groups := make(map[string]InventoryGroupModel, len(inventory.Groups))
+	for name, group := range inventory.Groups {
+		groupHosts, newDiags := types.ListValueFrom(ctx, types.StringType, nonNilStrings(group.Hosts))
+		diags.Append(newDiags...)
+		children, newDiags := types.ListValueFrom(ctx, types.StringType, nonNilStrings(group.Children))
+		diags.Append(newDiags...)
+		groups[name] = InventoryGroupModel{Hosts: groupHosts, Children: children}
+	}
+	groupsValue, newDiags := types.MapValueFrom(ctx, types.ObjectType{AttrTypes: InventoryGroupModel{}.AttrTypes()}, groups)
+	diags.Append(newDiags...)
+	data.Groups = groupsValue
+
+	data.Hostvars = types.DynamicValue(JSONToAttrValue(inventory.Hostvars, nil))
+
+	return diags
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
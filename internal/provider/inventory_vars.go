@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"gopkg.in/yaml.v2"
+)
+
+// An inventory with only the variables of groups and hosts. It's passed as a
+// second inventory source, which ansible merges into the groups and hosts of
+// the inventory, whether that's in the YAML or the INI format. Hosts that
+// aren't in the inventory are added to the all group.
+func VarsInventory(groupVars map[string]map[string]string, hostVars map[string]map[string]string) ([]byte, error) {
+	children := map[string]interface{}{}
+	all := map[string]interface{}{}
+	for group, vars := range groupVars {
+		if group == "all" {
+			all["vars"] = vars
+			continue
+		}
+		children[group] = map[string]interface{}{"vars": vars}
+	}
+	if len(children) > 0 {
+		all["children"] = children
+	}
+	if len(hostVars) > 0 {
+		all["hosts"] = hostVars
+	}
+
+	return yaml.Marshal(map[string]interface{}{"all": all})
+}
+
+// Write the group_vars and host_vars of the resource to a temporary
+// inventory, which the caller has to remove. Returns "" if neither is set.
+func WriteVarsInventory(ctx context.Context, data *PlaybookResourceModel, diags *diag.Diagnostics) string {
+	var groupVars, hostVars map[string]map[string]string
+	diags.Append(data.GroupVars.ElementsAs(ctx, &groupVars, false)...)
+	diags.Append(data.HostVars.ElementsAs(ctx, &hostVars, false)...)
+	if diags.HasError() || len(groupVars)+len(hostVars) == 0 {
+		return ""
+	}
+
+	content, err := VarsInventory(groupVars, hostVars)
+	if err != nil {
+		diags.AddError("Failed to build the inventory of group_vars and host_vars", err.Error())
+		return ""
+	}
+
+	return BuildInventory(ctx, TempFilePattern("inventory-vars-*.yml"), string(content), diags)
+}
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+type JUnitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	TestSuites []JUnitTestSuite `xml:"testsuite"`
+}
+
+// Convert the playbook artifact into JUnit test suites, one suite per play
+// and one test case per task and host.
+func BuildJUnitReport(root Root, playbook string) JUnitTestSuites {
+	report := JUnitTestSuites{Name: playbook}
+
+	for _, play := range root.Plays {
+		suite := JUnitTestSuite{Name: play.Play.Name}
+
+		for _, task := range play.Tasks {
+			hostNames := make([]string, 0, len(task.Hosts))
+			for hostName := range task.Hosts {
+				hostNames = append(hostNames, hostName)
+			}
+			sort.Strings(hostNames)
+
+			for _, hostName := range hostNames {
+				host := task.Hosts[hostName]
+				testCase := JUnitTestCase{
+					Name:      fmt.Sprintf("[%s] %s", hostName, task.Task.Name),
+					ClassName: play.Play.Name,
+				}
+
+				if host.Failed || host.Unreachable {
+					failureType := "failed"
+					if host.Unreachable {
+						failureType = "unreachable"
+					}
+
+					message := host.Msg.StringValue
+					if len(message) == 0 {
+						message = failureType
+					}
+
+					testCase.Failure = &JUnitFailure{
+						Message: message,
+						Type:    failureType,
+						Content: strings.TrimRight(printFailedInfo(host.Result, ""), "\n"),
+					}
+					suite.Failures++
+				} else if host.Skipped {
+					testCase.Skipped = &JUnitSkipped{Message: host.SkipReason}
+					suite.Skipped++
+				} else if len(host.Stdout) > 0 {
+					testCase.SystemOut = host.Stdout
+				}
+
+				suite.TestCases = append(suite.TestCases, testCase)
+				suite.Tests++
+			}
+		}
+
+		report.TestSuites = append(report.TestSuites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+
+	return report
+}
+
+func WriteJUnitReport(artifact *Artifact, playbook string, reportPath string, redactor *Redactor) error {
+	if artifact.Err != nil {
+		return artifact.Err
+	}
+
+	content, err := xml.MarshalIndent(BuildJUnitReport(artifact.Root, playbook), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	output := xml.Header + redactor.Redact(string(content)) + "\n"
+	return os.WriteFile(reportPath, []byte(output), 0o600)
+}
@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Write known_hosts_entries to a temporary known_hosts file, which the caller
+// has to remove. Returns "" if there are no entries.
+func WriteKnownHosts(ctx context.Context, entries types.List, diags *diag.Diagnostics) string {
+	var lines []string
+	diags.Append(entries.ElementsAs(ctx, &lines, false)...)
+	if diags.HasError() || len(lines) == 0 {
+		return ""
+	}
+
+	return WriteTempFile(ctx, "known-hosts-*", strings.Join(lines, "\n")+"\n", diags)
+}
+
+// The ssh options to only trust the host keys of the known_hosts file
+func KnownHostsSSHArgs(knownHostsFile string) string {
+	return "-o UserKnownHostsFile=" + knownHostsFile + " -o StrictHostKeyChecking=yes"
+}
+
+// Every entry needs at least the host names, the key type and the key
+func validateKnownHostsEntries(ctx context.Context, entries types.List, diags *diag.Diagnostics) {
+	var lines []types.String
+	diags.Append(entries.ElementsAs(ctx, &lines, false)...)
+
+	for i, line := range lines {
+		if line.IsNull() || line.IsUnknown() {
+			continue
+		}
+		fields := strings.Fields(line.ValueString())
+		if strings.HasPrefix(line.ValueString(), "@") {
+			// A marker like @cert-authority comes first
+			fields = fields[1:]
+		}
+		if len(fields) < 3 {
+			diags.AddAttributeError(path.Root("known_hosts_entries").AtListIndex(i), "Invalid known_hosts entry",
+				"An entry must be a known_hosts line like `host.example.com ssh-ed25519 AAAA...`.")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"strings"
+)
+
+// Split a host pattern into its terms like ansible does: on commas, or, if
+// there are none, on colons outside of ranges like web[1:3]
+func splitHostPattern(pattern string) []string {
+	if strings.Contains(pattern, ",") {
+		return strings.Split(pattern, ",")
+	}
+
+	var terms []string
+	depth, start := 0, 0
+	for i, c := range pattern {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ':':
+			if depth == 0 {
+				terms = append(terms, pattern[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, pattern[start:])
+}
+
+// The --limit of a run on hosts, within the host pattern limit. Intersections
+// and exclusions of limit apply to hosts, and so does a single other term, as
+// an intersection. The pattern can't be narrowed to hosts if it's the union of
+// several terms, then limit is returned and the bool is false.
+func LimitPattern(limit string, hosts []string) (string, bool) {
+	if len(hosts) == 0 {
+		return limit, false
+	}
+	if len(limit) == 0 {
+		return strings.Join(hosts, ","), true
+	}
+
+	var union, restrictions []string
+	for _, term := range splitHostPattern(limit) {
+		term = strings.TrimSpace(term)
+		switch {
+		case len(term) == 0:
+		case strings.HasPrefix(term, "&") || strings.HasPrefix(term, "!"):
+			restrictions = append(restrictions, term)
+		default:
+			union = append(union, term)
+		}
+	}
+	if len(union) > 1 {
+		return limit, false
+	}
+	if len(union) == 1 && union[0] != "all" && union[0] != "*" {
+		restrictions = append([]string{"&" + union[0]}, restrictions...)
+	}
+
+	return strings.Join(append(append([]string{}, hosts...), restrictions...), ","), true
+}
@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestLimitPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    string
+		hosts    []string
+		want     string
+		narrowed bool
+	}{
+		{name: "no hosts", limit: "web", hosts: nil, want: "web", narrowed: false},
+		{name: "no limit", limit: "", hosts: []string{"web1", "web2"}, want: "web1,web2", narrowed: true},
+		{name: "group", limit: "web", hosts: []string{"web1"}, want: "web1,&web", narrowed: true},
+		{name: "all", limit: "all", hosts: []string{"web1"}, want: "web1", narrowed: true},
+		{name: "star", limit: "*", hosts: []string{"web1"}, want: "web1", narrowed: true},
+		{name: "exclusion only", limit: "!db1", hosts: []string{"web1", "web2"}, want: "web1,web2,!db1", narrowed: true},
+		{name: "intersection and exclusion", limit: "web:&prod:!web3", hosts: []string{"web1"}, want: "web1,&web,&prod,!web3", narrowed: true},
+		{name: "commas", limit: "web, &prod", hosts: []string{"web1"}, want: "web1,&web,&prod", narrowed: true},
+		{name: "range", limit: "web[1:3]", hosts: []string{"web1"}, want: "web1,&web[1:3]", narrowed: true},
+		{name: "union of colons", limit: "web:db", hosts: []string{"web1"}, want: "web:db", narrowed: false},
+		{name: "union of commas", limit: "web,db", hosts: []string{"web1"}, want: "web,db", narrowed: false},
+		{name: "empty terms", limit: "web::", hosts: []string{"web1"}, want: "web1,&web", narrowed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, narrowed := LimitPattern(test.limit, test.hosts)
+			if got != test.want || narrowed != test.narrowed {
+				t.Errorf("LimitPattern(%q, %q) = %q, %t, want %q, %t", test.limit, test.hosts, got, narrowed, test.want, test.narrowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var listedHostsRegexp = regexp.MustCompile(`^hosts \(\d+\):$`)
+
+// Parse the output of `ansible-playbook --list-hosts` into the hosts matched
+// by any play, sorted and without duplicates.
+func ParseListHosts(output []byte) []string {
+	hosts := map[string]bool{}
+	inHosts := false
+
+	// Not with bufio.Scanner, which stops at lines longer than 64KB
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case listedHostsRegexp.MatchString(line):
+			inHosts = true
+		case len(line) == 0 || strings.HasPrefix(line, "play #"):
+			inHosts = false
+		case inHosts:
+			hosts[line] = true
+		}
+	}
+
+	result := []string{}
+	for host := range hosts {
+		result = append(result, host)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Whether everything a plan time run of the playbook depends on is known
+func planRunInputsKnown(data *PlaybookResourceModel) bool {
+	return !data.Playbook.IsUnknown() && !data.Plays.IsUnknown() && !data.Limit.IsUnknown() && !data.AnsiblePlaybookBinary.IsUnknown() &&
+		!data.Inventory.IsUnknown() && !data.InventoryFormat.IsUnknown() && !data.GroupVars.IsUnknown() && !data.HostVars.IsUnknown() &&
+		!data.ExtraVars.IsUnknown() && !data.SensitiveExtraVars.IsUnknown() && !data.VarFiles.IsUnknown() && !data.VaultIds.IsUnknown() &&
+		!data.FactCacheDir.IsUnknown() && !data.FactCaching.IsUnknown() && !data.FactCachingConnection.IsUnknown() && !data.FactCachingTimeout.IsUnknown() &&
+		!data.Pipelining.IsUnknown() && !data.ConnectionPlugin.IsUnknown() && !data.WinRM.IsUnknown() && !data.SSH.IsUnknown() &&
+		!data.Bastion.IsUnknown() && !data.KnownHostsEntries.IsUnknown() &&
+		!data.ContainerImage.IsUnknown() && !data.ContainerEngine.IsUnknown() && !data.ContainerVolumes.IsUnknown() &&
+		!data.Environment.IsUnknown()
+}
+
+// Run ansible-playbook during plan with the inventory and variables of the
+// resource and the given options. Returns the stdout, and an error diagnostic
+// if the command failed, unless its exit code is in acceptExitCodes.
+func runPlaybookAtPlan(ctx context.Context, data *PlaybookResourceModel, providerData *ProviderData, env []string, acceptExitCodes []int, options ...string) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var extraVars, sensitiveExtraVars map[string]string
+	diags.Append(data.ExtraVars.ElementsAs(ctx, &extraVars, false)...)
+	diags.Append(data.SensitiveExtraVars.ElementsAs(ctx, &sensitiveExtraVars, false)...)
+
+	var varFiles []string
+	diags.Append(data.VarFiles.ElementsAs(ctx, &varFiles, false)...)
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	sensitiveVarsFile := WriteSensitiveExtraVars(ctx, sensitiveExtraVars, &diags)
+	if len(sensitiveVarsFile) > 0 {
+		defer RemoveFile(sensitiveVarsFile, &diags)
+	}
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	connectionVarsFile, connectionFiles, secrets := WriteConnectionVars(ctx, data, &diags)
+	for _, file := range connectionFiles {
+		defer RemoveFile(file, &diags)
+	}
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	vaultIdArgs, vaultIdFiles, vaultIdSecrets := VaultIdArgs(ctx, data.VaultIds, &diags)
+	for _, file := range vaultIdFiles {
+		defer RemoveFile(file, &diags)
+	}
+	if diags.HasError() {
+		return nil, diags
+	}
+	secrets = append(secrets, vaultIdSecrets...)
+
+	for _, value := range sensitiveExtraVars {
+		secrets = append(secrets, value)
+	}
+	redactor := NewRedactor(secrets).WithPatterns(RedactPatterns(ctx, data.RedactPatterns, &diags)).WithStripANSI(data.StripANSI.ValueBool())
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	args := append([]string{}, options...)
+	if len(data.Limit.ValueString()) > 0 {
+		args = append(args, "--limit", data.Limit.ValueString())
+	}
+	if !data.ConnectionPlugin.IsNull() {
+		args = append(args, "-c", data.ConnectionPlugin.ValueString())
+	}
+	args = append(args, vaultIdArgs...)
+	if len(connectionVarsFile) > 0 {
+		args = append(args, "-e", "@"+connectionVarsFile)
+	}
+	for _, varFile := range varFiles {
+		args = append(args, "-e", "@"+varFile)
+	}
+	args = append(args, ExtraVarsArgs(extraVars)...)
+	if len(sensitiveVarsFile) > 0 {
+		args = append(args, "-e", "@"+sensitiveVarsFile)
+	}
+
+	tempInventory := SharedInventory(ctx, InventoryPattern(data.Inventory.ValueString(), data.InventoryFormat.ValueString()), data.Inventory.ValueString(), &diags)
+	if len(tempInventory) > 0 {
+		defer ReleaseInventory(tempInventory, &diags)
+	}
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	playbook, removePlaybook := SelectPlays(ctx, data, &diags)
+	defer removePlaybook()
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	args = append(args, "-i", tempInventory)
+
+	varsInventory := WriteVarsInventory(ctx, data, &diags)
+	if len(varsInventory) > 0 {
+		defer RemoveFile(varsInventory, &diags)
+		args = append(args, "-i", varsInventory)
+	}
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	args = append(args, playbook)
+
+	connectionsArgs, releaseConnections, err := AcquireConnections(ctx, providerData, RunForks(data, EffectiveEnvironment(ctx, data, providerData, &diags)))
+	if err != nil {
+		diags.AddError("Failed to wait for free connections", err.Error())
+		return nil, diags
+	}
+	defer releaseConnections()
+	args = append(args, connectionsArgs...)
+
+	var stdout, stderr bytes.Buffer
+	env = append(append(append([]string{}, env...), FactCachingEnv(data)...), PipeliningEnv(data.Pipelining)...)
+
+	runFiles := append(append([]string{sensitiveVarsFile, tempInventory, varsInventory}, connectionFiles...), vaultIdFiles...)
+	cmd, newDiags := providerData.PlaybookCommand(ctx, data, env, runFiles, args...)
+	diags.Append(newDiags...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd.Process)
+	}
+
+	logCommand(ctx, cmd, redactor)
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		KillContainer(ctx, cmd)
+	}
+	if err != nil && !slices.Contains(acceptExitCodes, ExitCode(err)) {
+		diags.AddError(fmt.Sprintf("ansible-playbook %s failed", strings.Join(options, " ")), redactor.Redact(fmt.Sprintf("%s\n%s", err, stderr.String())))
+		return nil, diags
+	}
+
+	return stdout.Bytes(), diags
+}
+
+// List the hosts the playbook would run on with `ansible-playbook --list-hosts`
+func ListHosts(ctx context.Context, data *PlaybookResourceModel, providerData *ProviderData) ([]string, diag.Diagnostics) {
+	output, diags := runPlaybookAtPlan(ctx, data, providerData, nil, nil, "--list-hosts")
+	if diags.HasError() {
+		return nil, diags
+	}
+	return ParseListHosts(output), diags
+}
+
+// Set matched_hosts, if it wasn't known at plan time. A failure to list the
+// hosts doesn't stop the run.
+func setMatchedHosts(ctx context.Context, diags *diag.Diagnostics, data *PlaybookResourceModel, providerData *ProviderData) {
+	if !data.MatchedHosts.IsUnknown() {
+		return
+	}
+
+	data.MatchedHosts = types.ListNull(types.StringType)
+	if !data.PreviewHosts.ValueBool() {
+		return
+	}
+
+	hosts, listDiags := ListHosts(ctx, data, providerData)
+	if listDiags.HasError() {
+		for _, listDiag := range listDiags {
+			diags.AddWarning(listDiag.Summary(), listDiag.Detail())
+		}
+		return
+	}
+
+	matchedHosts, newDiags := types.ListValueFrom(ctx, types.StringType, hosts)
+	diags.Append(newDiags...)
+	data.MatchedHosts = matchedHosts
+}
@@ -0,0 +1,315 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// Lines waiting to be forwarded beyond this are dropped, so a slow log
+	// endpoint can't block ansible
+	logForwardingBuffer = 10000
+	// The most lines POSTed to the HTTP endpoint at once, and how long they
+	// wait for more at most
+	logForwardingBatchSize     = 100
+	logForwardingBatchInterval = time.Second
+	logForwardingDialTimeout   = 5 * time.Second
+)
+
+type LogForwardingModel struct {
+	Syslog  types.String `tfsdk:"syslog"`
+	HTTPURL types.String `tfsdk:"http_url"`
+	Headers types.Map    `tfsdk:"headers"`
+}
+
+// Forwards the output of playbook runs to a syslog server and/or an HTTP
+// endpoint while they run
+type LogForwarder struct {
+	SyslogNetwork string
+	SyslogAddress string
+	HTTPURL       string
+	Headers       map[string]string
+}
+
+func NewLogForwarder(ctx context.Context, model *LogForwardingModel) (*LogForwarder, error) {
+	forwarder := &LogForwarder{HTTPURL: model.HTTPURL.ValueString()}
+	if diags := model.Headers.ElementsAs(ctx, &forwarder.Headers, false); diags.HasError() {
+		return nil, errors.New("invalid headers")
+	}
+
+	if syslog := model.Syslog.ValueString(); len(syslog) > 0 {
+		syslogURL, err := url.Parse(syslog)
+		if err != nil || (syslogURL.Scheme != "udp" && syslogURL.Scheme != "tcp") || len(syslogURL.Host) == 0 {
+			return nil, fmt.Errorf("the syslog server %q isn't an address like udp://logs.example.com:514 or tcp://logs.example.com:514", syslog)
+		}
+		forwarder.SyslogNetwork = syslogURL.Scheme
+		forwarder.SyslogAddress = syslogURL.Host
+	}
+
+	if len(forwarder.SyslogAddress) == 0 && len(forwarder.HTTPURL) == 0 {
+		return nil, errors.New("set syslog or http_url")
+	}
+	return forwarder, nil
+}
+
+// What the forwarded lines of a run are tagged with
+type LogTags struct {
+	RunId      string `json:"run_id"`
+	ResourceId string `json:"resource_id"`
+	Playbook   string `json:"playbook"`
+}
+
+// A line of the output of a run
+type LogLine struct {
+	LogTags
+	Time   time.Time `json:"time"`
+	Stream string    `json:"stream"`
+	Line   string    `json:"line"`
+}
+
+// The forwarding of the output of a run
+type LogStream struct {
+	forwarder *LogForwarder
+	tags      LogTags
+	redactor  *Redactor
+	writers   []*logLineWriter
+	lines     chan LogLine
+	done      chan struct{}
+
+	mu      sync.Mutex
+	dropped int
+	err     error
+}
+
+// Start forwarding the output of a run. A nil forwarder returns a nil stream,
+// which forwards nothing.
+func (f *LogForwarder) Start(ctx context.Context, tags LogTags, redactor *Redactor) *LogStream {
+	if f == nil {
+		return nil
+	}
+
+	s := &LogStream{
+		forwarder: f,
+		tags:      tags,
+		redactor:  redactor,
+		lines:     make(chan LogLine, logForwardingBuffer),
+		done:      make(chan struct{}),
+	}
+	go s.forward(context.WithoutCancel(ctx))
+	return s
+}
+
+// A writer writing to w and forwarding every line written as a line of the
+// stream, e.g. `stdout`. Returns w for a nil stream.
+func (s *LogStream) Tee(w io.Writer, stream string) io.Writer {
+	if s == nil {
+		return w
+	}
+
+	writer := &logLineWriter{stream: s, name: stream}
+	s.writers = append(s.writers, writer)
+	return io.MultiWriter(w, writer)
+}
+
+// Forward the lines not yet forwarded and stop. Returns why lines couldn't be
+// forwarded, if any.
+func (s *LogStream) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	for _, writer := range s.writers {
+		writer.flush()
+	}
+	close(s.lines)
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.err
+	if s.dropped > 0 {
+		err = errors.Join(err, fmt.Errorf("%d lines were dropped, as the log endpoint didn't keep up", s.dropped))
+	}
+	return err
+}
+
+func (s *LogStream) add(stream string, line string) {
+	logLine := LogLine{
+		LogTags: s.tags,
+		Time:    time.Now().UTC(),
+		Stream:  stream,
+		Line:    s.redactor.Redact(line),
+	}
+	select {
+	case s.lines <- logLine:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+}
+
+func (s *LogStream) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *LogStream) forward(ctx context.Context) {
+	defer close(s.done)
+
+	var syslog net.Conn
+	if len(s.forwarder.SyslogAddress) > 0 {
+		var err error
+		syslog, err = net.DialTimeout(s.forwarder.SyslogNetwork, s.forwarder.SyslogAddress, logForwardingDialTimeout)
+		if err != nil {
+			s.fail(fmt.Errorf("failed to connect to the syslog server: %s", err))
+		} else {
+			defer func() {
+				if syslog != nil {
+					syslog.Close()
+				}
+			}()
+		}
+	}
+
+	// An endpoint that failed once isn't used anymore, so a broken one
+	// doesn't hold up the end of the run
+	postLines := len(s.forwarder.HTTPURL) > 0
+	var batch []LogLine
+	send := func() {
+		if len(batch) > 0 && postLines {
+			if err := s.forwarder.post(ctx, batch); err != nil {
+				s.fail(err)
+				postLines = false
+			}
+		}
+		batch = nil
+	}
+
+	ticker := time.NewTicker(logForwardingBatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				send()
+				return
+			}
+			if syslog != nil {
+				if _, err := syslog.Write(syslogMessage(line, s.forwarder.SyslogNetwork)); err != nil {
+					s.fail(fmt.Errorf("failed to send to the syslog server: %s", err))
+					syslog.Close()
+					syslog = nil
+				}
+			}
+			batch = append(batch, line)
+			if len(batch) >= logForwardingBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		}
+	}
+}
+
+// POST the lines to the HTTP endpoint as a JSON array
+func (f *LogForwarder) post(ctx context.Context, lines []LogLine) error {
+	payload, err := json.Marshal(lines)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.HTTPURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range f.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the log endpoint responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// Escape a value of RFC 5424 structured data
+var syslogParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// The RFC 5424 message of the line, with the tags as structured data, one
+// per datagram for UDP and newline terminated for TCP. Stderr lines are
+// warnings, the others informational, of the user facility.
+func syslogMessage(line LogLine, network string) []byte {
+	priority := 1*8 + 6
+	if line.Stream == "stderr" {
+		priority = 1*8 + 4
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || len(hostname) == 0 {
+		hostname = "-"
+	}
+
+	message := fmt.Sprintf(`<%d>1 %s %s terraform-provider-ansible %d - [ansible@32473 run_id="%s" resource_id="%s" playbook="%s" stream="%s"] %s`,
+		priority, line.Time.Format(time.RFC3339Nano), hostname, os.Getpid(),
+		syslogParamEscaper.Replace(line.RunId), syslogParamEscaper.Replace(line.ResourceId),
+		syslogParamEscaper.Replace(line.Playbook), syslogParamEscaper.Replace(line.Stream), line.Line)
+	if network == "tcp" {
+		message = strings.ReplaceAll(message, "\n", " ") + "\n"
+	}
+	return []byte(message)
+}
+
+// Splits what's written to it into the lines of a stream
+type logLineWriter struct {
+	stream  *LogStream
+	name    string
+	partial []byte
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.stream.add(w.name, strings.TrimSuffix(string(w.partial[:i]), "\r"))
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Forward the last line, if it wasn't terminated
+func (w *logLineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.stream.add(w.name, string(w.partial))
+		w.partial = nil
+	}
+}
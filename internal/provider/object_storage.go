@@ -0,0 +1,307 @@
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const objectUploadTimeout = 5 * time.Minute
+
+// A bucket of S3, GCS or Azure Blob Storage to upload the artifacts of runs
+// to, with the credentials from the environment
+type ObjectStorage struct {
+	// s3, gs or az
+	Scheme string
+	// The bucket, or the container for Azure
+	Bucket string
+	// The storage account for Azure
+	Account string
+	// Prepended to the names of the objects
+	Prefix string
+}
+
+// Parse the URL of the bucket and prefix, `s3://bucket/prefix`,
+// `gs://bucket/prefix` or `az://account/container/prefix`
+func ParseObjectStorageURL(raw string) (*ObjectStorage, error) {
+	storageURL, err := url.Parse(raw)
+	if err != nil || len(storageURL.Host) == 0 {
+		return nil, fmt.Errorf("%q isn't a URL like s3://bucket/prefix, gs://bucket/prefix or az://account/container/prefix", raw)
+	}
+
+	storage := &ObjectStorage{Scheme: storageURL.Scheme, Bucket: storageURL.Host}
+	objectPath := strings.Trim(storageURL.Path, "/")
+	switch storageURL.Scheme {
+	case "s3", "gs":
+	case "az":
+		storage.Account = storageURL.Host
+		storage.Bucket, objectPath, _ = strings.Cut(objectPath, "/")
+		if len(storage.Bucket) == 0 {
+			return nil, fmt.Errorf("%q has no container, like az://account/container/prefix", raw)
+		}
+	default:
+		return nil, fmt.Errorf("%q isn't a URL of S3, GCS or Azure Blob Storage, s3://, gs:// or az://", raw)
+	}
+	if len(objectPath) > 0 {
+		storage.Prefix = objectPath + "/"
+	}
+	return storage, nil
+}
+
+// Upload the file as the object of the name below the prefix and return its
+// URL. env looks up the credentials:
+//   - S3: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN,
+//     AWS_REGION and AWS_ENDPOINT_URL_S3 for S3 compatible storage
+//   - GCS: GOOGLE_OAUTH_ACCESS_TOKEN
+//   - Azure: AZURE_STORAGE_SAS_TOKEN
+func (s *ObjectStorage) Upload(ctx context.Context, name string, file string, contentType string, env func(string) string) (string, error) {
+	key := s.Prefix + name
+
+	var objectURL string
+	var authorize func(req *http.Request, payloadHash string) error
+	switch s.Scheme {
+	case "s3":
+		region := env("AWS_REGION")
+		if len(region) == 0 {
+			region = env("AWS_DEFAULT_REGION")
+		}
+		if len(region) == 0 {
+			region = "us-east-1"
+		}
+		if endpoint := env("AWS_ENDPOINT_URL_S3"); len(endpoint) > 0 {
+			objectURL = strings.TrimSuffix(endpoint, "/") + "/" + s.Bucket + "/" + escapeObjectKey(key)
+		} else {
+			objectURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, region, escapeObjectKey(key))
+		}
+		authorize = func(req *http.Request, payloadHash string) error {
+			return signS3Request(req, payloadHash, region, time.Now(), env)
+		}
+	case "gs":
+		objectURL = fmt.Sprintf("https://storage.googleapis.com/%s/%s", s.Bucket, escapeObjectKey(key))
+		authorize = func(req *http.Request, payloadHash string) error {
+			token := env("GOOGLE_OAUTH_ACCESS_TOKEN")
+			if len(token) == 0 {
+				return fmt.Errorf("GOOGLE_OAUTH_ACCESS_TOKEN isn't set")
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		}
+	case "az":
+		objectURL = fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", s.Account, s.Bucket, escapeObjectKey(key))
+		authorize = func(req *http.Request, payloadHash string) error {
+			token := strings.TrimPrefix(env("AZURE_STORAGE_SAS_TOKEN"), "?")
+			if len(token) == 0 {
+				return fmt.Errorf("AZURE_STORAGE_SAS_TOKEN isn't set")
+			}
+			req.URL.RawQuery = token
+			req.Header.Set("x-ms-blob-type", "BlockBlob")
+			return nil
+		}
+	}
+
+	content, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	// S3 signs the hash of the content
+	hash := sha256.New()
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, objectUploadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, content)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	if err := authorize(req, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL of the request has the SAS token of Azure in its query
+		if urlErr, ok := err.(*url.Error); ok {
+			urlErr.URL = objectURL
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("uploading %s responded with %s: %s", objectURL, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return objectURL, nil
+}
+
+// Escape an object key for the path of a URL, everything but the unreserved
+// characters of RFC 3986 and slashes, as the S3 signature requires
+func escapeObjectKey(key string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(key) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', strings.IndexByte("-._~/", b) >= 0:
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+// Sign the request and all of its headers with AWS Signature Version 4
+func signS3Request(req *http.Request, payloadHash string, region string, now time.Time, env func(string) string) error {
+	accessKey := env("AWS_ACCESS_KEY_ID")
+	secretKey := env("AWS_SECRET_ACCESS_KEY")
+	if len(accessKey) == 0 || len(secretKey) == 0 {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY aren't set")
+	}
+
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if token := env("AWS_SESSION_TOKEN"); len(token) > 0 {
+		req.Header.Set("x-amz-security-token", token)
+	}
+
+	signedHeaders := []string{"host"}
+	for name := range req.Header {
+		signedHeaders = append(signedHeaders, strings.ToLower(name))
+	}
+	sort.Strings(signedHeaders)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, strings.Join(signedHeaders, ";"), signature))
+	return nil
+}
+
+// Upload the artifact of a run, redacted, and its log to artifact_upload_url,
+// named by the resource ID and the start of the run, and set their URLs.
+// Failed uploads are warnings. artifact is nil without the json callback.
+func UploadRunArtifacts(ctx context.Context, data *PlaybookResourceModel, env map[string]string, start time.Time, artifact io.Reader, redactor *Redactor, diags *diag.Diagnostics) {
+	data.ArtifactObjectURL = types.StringNull()
+	data.LogObjectURL = types.StringNull()
+	if len(data.ArtifactUploadURL.ValueString()) == 0 {
+		return
+	}
+
+	storage, err := ParseObjectStorageURL(data.ArtifactUploadURL.ValueString())
+	if err != nil {
+		diags.AddAttributeWarning(path.Root("artifact_upload_url"), "Failed to upload the artifacts", err.Error())
+		return
+	}
+	lookupEnv := func(name string) string {
+		if value, ok := env[name]; ok {
+			return value
+		}
+		return os.Getenv(name)
+	}
+	// Errors of the storage could quote the credentials
+	redactor = redactor.WithSecrets(objectStorageCredentials(lookupEnv)...)
+	name := data.Id.ValueString() + "/" + start.UTC().Format("20060102T150405.000000000Z")
+
+	if artifact != nil {
+		artifactFile, err := os.CreateTemp("", TempFilePattern("upload-*.json"))
+		if err != nil {
+			diags.AddAttributeWarning(path.Root("artifact_upload_url"), "Failed to upload the artifact", err.Error())
+			return
+		}
+		defer os.Remove(artifactFile.Name())
+		err = redactor.RedactLines(artifactFile, artifact)
+		if closeErr := artifactFile.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err == nil {
+			var objectURL string
+			objectURL, err = storage.Upload(ctx, name+".json", artifactFile.Name(), "application/json", lookupEnv)
+			data.ArtifactObjectURL = types.StringValue(objectURL)
+		}
+		if err != nil {
+			data.ArtifactObjectURL = types.StringNull()
+			diags.AddAttributeWarning(path.Root("artifact_upload_url"), "Failed to upload the artifact", redactor.Redact(err.Error()))
+		}
+	}
+
+	// The log was saved redacted already
+	if logFile := data.LogFile.ValueString(); len(logFile) > 0 {
+		objectURL, err := storage.Upload(ctx, name+".log", logFile, "text/plain", lookupEnv)
+		if err != nil {
+			diags.AddAttributeWarning(path.Root("artifact_upload_url"), "Failed to upload the log", redactor.Redact(err.Error()))
+		} else {
+			data.LogObjectURL = types.StringValue(objectURL)
+		}
+	}
+}
+
+// The credentials of the storages from the environment, to be redacted
+func objectStorageCredentials(env func(string) string) []string {
+	var credentials []string
+	for _, name := range []string{"AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "GOOGLE_OAUTH_ACCESS_TOKEN", "AZURE_STORAGE_SAS_TOKEN"} {
+		credentials = append(credentials, env(name))
+	}
+	// With and without the leading question mark
+	credentials = append(credentials, strings.TrimPrefix(env("AZURE_STORAGE_SAS_TOKEN"), "?"))
+	return credentials
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
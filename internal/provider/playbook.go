@@ -3,15 +3,65 @@ package provider
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-func Execute(ctx context.Context, diags *diag.Diagnostics, data *PlaybookResourceModel) {
+// How long ansible gets to stop after it was interrupted, before it's
+// terminated, and before it's killed
+const (
+	playbookTerminateDelay = 20 * time.Second
+	playbookInterruptDelay = 30 * time.Second
+)
+
+// Options of a single run, that don't come from the configuration
+type RunOptions struct {
+	// Only run tasks with these tags
+	Tags []string
+	// Only run on these hosts
+	Limit []string
+	// Set to the stats of the run, if not nil
+	Stats *Stats
+	// Run with --check, e.g. for the reads of a data source
+	CheckMode bool
+	// Don't record the run in the run history, the artifact_dir or the log
+	// forwarding, e.g. for the reads of a data source
+	ReadOnly bool
+}
+
+// The environment to enable or disable pipelining, if pipelining is set
+func PipeliningEnv(pipelining types.Bool) []string {
+	if pipelining.IsNull() || pipelining.IsUnknown() {
+		return nil
+	}
+	return []string{"ANSIBLE_PIPELINING=" + strconv.FormatBool(pipelining.ValueBool())}
+}
+
+// The environment to make any failed host abort the run, if fail_fast is set
+func FailFastEnv(failFast types.Bool) []string {
+	if failFast.IsNull() || failFast.IsUnknown() {
+		return nil
+	}
+	return []string{"ANSIBLE_ANY_ERRORS_FATAL=" + strconv.FormatBool(failFast.ValueBool())}
+}
+
+func Execute(ctx context.Context, diags *diag.Diagnostics, data *PlaybookResourceModel, providerData *ProviderData, options RunOptions) {
+	artifactStore, runHistory, logForwarder := providerData.GetArtifactStore(), providerData.GetRunHistory(), providerData.GetLogForwarder()
+	if options.ReadOnly {
+		artifactStore, runHistory, logForwarder = nil, nil, nil
+	}
 
 	var queriesModel map[string]ArtifactQueryModel
 	diags.Append(data.ArtifactQueries.ElementsAs(ctx, &queriesModel, false)...)
@@ -29,18 +79,123 @@ func Execute(ctx context.Context, diags *diag.Diagnostics, data *PlaybookResourc
 	var extraVars map[string]string
 	diags.Append(data.ExtraVars.ElementsAs(ctx, &extraVars, false)...)
 
+	var sensitiveExtraVars map[string]string
+	diags.Append(data.SensitiveExtraVars.ElementsAs(ctx, &sensitiveExtraVars, false)...)
+
+	var varFiles []string
+	diags.Append(data.VarFiles.ElementsAs(ctx, &varFiles, false)...)
+
+	var redact []string
+	diags.Append(data.Redact.ElementsAs(ctx, &redact, false)...)
+
+	var acceptableExitCodes []int64
+	diags.Append(data.AcceptableExitCodes.ElementsAs(ctx, &acceptableExitCodes, false)...)
+
+	var webhook *NotifyWebhook
+	if !data.NotifyWebhook.IsNull() {
+		var webhookModel NotifyWebhookModel
+		diags.Append(data.NotifyWebhook.As(ctx, &webhookModel, basetypes.ObjectAsOptions{})...)
+		webhook = &NotifyWebhook{}
+		diags.Append(webhookModel.Value(ctx, webhook)...)
+	}
+
 	if diags.HasError() {
 		return
 	}
 
-	if len(extraVars) != 0 {
-		for key, val := range extraVars {
-			args = append(args, "-e", key+"='"+val+"'")
-		}
+	for _, val := range sensitiveExtraVars {
+		redact = append(redact, val)
+	}
+
+	sensitiveVarsFile := WriteSensitiveExtraVars(ctx, sensitiveExtraVars, diags)
+	if len(sensitiveVarsFile) > 0 {
+		defer RemoveFile(sensitiveVarsFile, diags)
+	}
+	if diags.HasError() {
+		return
+	}
+
+	connectionVarsFile, connectionFiles, connectionSecrets := WriteConnectionVars(ctx, data, diags)
+	for _, file := range connectionFiles {
+		defer RemoveFile(file, diags)
+	}
+	if diags.HasError() {
+		return
+	}
+	redact = append(redact, connectionSecrets...)
+
+	vaultIdArgs, vaultIdFiles, vaultIdSecrets := VaultIdArgs(ctx, data.VaultIds, diags)
+	for _, file := range vaultIdFiles {
+		defer RemoveFile(file, diags)
+	}
+	if diags.HasError() {
+		return
+	}
+	redact = append(redact, vaultIdSecrets...)
+	redactor := NewRedactor(redact).WithPatterns(RedactPatterns(ctx, data.RedactPatterns, diags)).WithStripANSI(data.StripANSI.ValueBool())
+	if diags.HasError() {
+		return
+	}
+
+	effectiveEnv := EffectiveEnvironment(ctx, data, providerData, diags)
+	data.EffectiveEnvironment = AnsibleEnvironmentValue(ctx, effectiveEnv, redactor, diags)
+	if diags.HasError() {
+		return
+	}
+
+	if options.CheckMode {
+		args = append(args, "--check")
+	}
+
+	if data.DiffMode.ValueBool() {
+		args = append(args, "--diff")
+	}
+
+	if len(options.Tags) > 0 {
+		args = append(args, "--tags", strings.Join(options.Tags, ","))
+	}
+
+	limit, narrowed := LimitPattern(data.Limit.ValueString(), options.Limit)
+	if len(options.Limit) > 0 && !narrowed {
+		tflog.Warn(ctx, fmt.Sprintf("Running on all hosts of the limit %q, as it can't be narrowed to the triggered hosts", limit))
+	}
+	if len(limit) > 0 {
+		args = append(args, "--limit", limit)
+	}
+
+	if !data.ConnectionPlugin.IsNull() {
+		args = append(args, "-c", data.ConnectionPlugin.ValueString())
+	}
+
+	args = append(args, vaultIdArgs...)
+
+	// Before the var files and extra vars, which can override them
+	if len(connectionVarsFile) > 0 {
+		args = append(args, "-e", "@"+connectionVarsFile)
+	}
+
+	for _, varFile := range varFiles {
+		args = append(args, "-e", "@"+varFile)
+	}
+
+	args = append(args, ExtraVarsArgs(extraVars)...)
+
+	if len(sensitiveVarsFile) > 0 {
+		args = append(args, "-e", "@"+sensitiveVarsFile)
 	}
 
-	args = append(args, data.Playbook.ValueString())
-	tempInventoryFile := BuildInventory(ctx, ".inventory-*.yml", data.Inventory.ValueString(), diags)
+	playbook, removePlaybook := SelectPlays(ctx, data, diags)
+	defer removePlaybook()
+	if diags.HasError() {
+		return
+	}
+
+	args = append(args, playbook)
+	tempInventoryFile := SharedInventory(ctx, InventoryPattern(data.Inventory.ValueString(), data.InventoryFormat.ValueString()), data.Inventory.ValueString(), diags)
+	if len(tempInventoryFile) > 0 {
+		// Deferred, so the inventory is released on early returns and panics as well
+		defer ReleaseInventory(tempInventoryFile, diags)
+	}
 
 	if diags.HasError() {
 		return
@@ -48,51 +203,391 @@ func Execute(ctx context.Context, diags *diag.Diagnostics, data *PlaybookResourc
 
 	args = append(args, "-i", tempInventoryFile)
 
-	runAnsiblePlay := exec.Command(data.AnsiblePlaybookBinary.ValueString(), args...)
-	currentEnv := os.Environ()
-	currentEnv = append(currentEnv, "ANSIBLE_STDOUT_CALLBACK=json")
-	runAnsiblePlay.Env = currentEnv
+	inventories := []string{tempInventoryFile}
+	varsInventory := WriteVarsInventory(ctx, data, diags)
+	if len(varsInventory) > 0 {
+		defer RemoveFile(varsInventory, diags)
+		args = append(args, "-i", varsInventory)
+		inventories = append(inventories, varsInventory)
+	}
+	if diags.HasError() {
+		return
+	}
+
+	verifyInventory(ctx, data, providerData, inventories, diags)
+	if diags.HasError() {
+		return
+	}
+
+	requirementsEnv := InstallRequirements(ctx, data, providerData, effectiveEnv, diags)
+	if diags.HasError() {
+		return
+	}
+
+	releaseLock, err := AcquireRunLock(ctx, RunLockKey(data))
+	if err != nil {
+		diags.AddError("Failed to acquire the run lock", err.Error())
+		return
+	}
+	defer releaseLock()
+
+	connectionsArgs, releaseConnections, err := AcquireConnections(ctx, providerData, RunForks(data, effectiveEnv))
+	if err != nil {
+		diags.AddError("Failed to wait for free connections", err.Error())
+		return
+	}
+	defer releaseConnections()
+	args = append(args, connectionsArgs...)
+
+	var env []string
+	var callbacks []string
+
+	jsonCallback := data.JSONCallback.ValueBool()
+	stdoutCallback := StdoutCallback(data, effectiveEnv)
+	var artifactFile string
+	switch {
+	case !jsonCallback:
+		// Without an artifact, only stdout_callback is set, otherwise ansible
+		// picks its stdout callback itself
+		if !data.StdoutCallback.IsNull() {
+			env = []string{"ANSIBLE_STDOUT_CALLBACK=" + data.StdoutCallback.ValueString()}
+		}
+	case len(stdoutCallback) > 0:
+		// With another stdout callback, the json callback writes the artifact
+		// to a file instead, so stdout stays readable
+		artifactFile = WriteTempFile(ctx, "artifact-*.json", "", diags)
+		if len(artifactFile) > 0 {
+			defer RemoveFile(artifactFile, diags)
+		}
+		if diags.HasError() {
+			return
+		}
+		env = []string{"ANSIBLE_STDOUT_CALLBACK=" + stdoutCallback, "TF_ANSIBLE_ARTIFACT_FILE=" + artifactFile}
+		callbacks = append(callbacks, artifactCallbackName)
+	default:
+		env = []string{"ANSIBLE_STDOUT_CALLBACK=json"}
+	}
+
+	env = append(env, FactCachingEnv(data)...)
+	env = append(env, PipeliningEnv(data.Pipelining)...)
+	env = append(env, FailFastEnv(data.FailFast)...)
+
+	controlPathDir := RunControlPathDir(ctx, data, effectiveEnv, diags)
+	if diags.HasError() {
+		return
+	}
+	if len(controlPathDir) > 0 {
+		defer StopControlMasters(ctx, controlPathDir)
+		env = append(env, "ANSIBLE_SSH_CONTROL_PATH_DIR="+controlPathDir)
+	}
+	env = append(env, requirementsEnv...)
+
+	// Ansible logs to a temporary file, which is mounted in containers, and
+	// the log is copied to its path redacted after the run
+	runStart := time.Now()
+	logPath := data.LogPath.ValueString()
+	if len(logPath) == 0 {
+		logPath = artifactStore.LogPath(data.Id.ValueString(), runStart)
+	}
+	var tempLog string
+	if len(logPath) > 0 {
+		tempLog = WriteTempFile(ctx, "ansible-log-*.log", "", diags)
+		if len(tempLog) > 0 {
+			defer RemoveFile(tempLog, diags)
+		}
+		if diags.HasError() {
+			return
+		}
+		env = append(env, "ANSIBLE_LOG_PATH="+tempLog)
+	}
+
+	streamProgress := data.StreamProgress.ValueBool()
+	if streamProgress && !progressStreamingSupported {
+		tflog.Warn(ctx, "Progress streaming isn't supported on this platform")
+		streamProgress = false
+	} else if streamProgress && runsInContainer(data) {
+		tflog.Warn(ctx, "Progress streaming isn't supported in containers")
+		streamProgress = false
+	}
+
+	var progressReader, progressWriter *os.File
+	if streamProgress {
+		var err error
+		progressReader, progressWriter, err = os.Pipe()
+		if err != nil {
+			diags.AddWarning("Failed to set up progress streaming", err.Error())
+		} else {
+			env = append(env, ProgressCallbackEnv(3)...)
+			callbacks = append(callbacks, progressCallbackName)
+		}
+	}
+
+	var callbackDir string
+	if len(callbacks) > 0 {
+		var err error
+		callbackDir, err = WriteCallbackPlugins()
+		if err != nil {
+			diags.AddError("Failed to write the callback plugins", err.Error())
+			return
+		}
+		defer os.RemoveAll(callbackDir)
+		env = append(env, CallbackPluginsEnv(effectiveEnv, callbackDir, callbacks...)...)
+	}
+
+	// Interrupt ansible when the context ends, e.g. the operation timed out
+	// or Terraform was canceled, like Ctrl+C would, and only terminate and
+	// finally kill it if it doesn't stop in time.
+	runFiles := []string{sensitiveVarsFile, tempInventoryFile, varsInventory, artifactFile, tempLog, callbackDir}
+	runFiles = append(append(runFiles, connectionFiles...), vaultIdFiles...)
+	runAnsiblePlay, newDiags := providerData.PlaybookCommand(ctx, data, env, runFiles, args...)
+	diags.Append(newDiags...)
+	if diags.HasError() {
+		return
+	}
+	setProcessGroup(runAnsiblePlay)
+	var terminateTimer *time.Timer
+	runAnsiblePlay.Cancel = func() error {
+		tflog.Warn(ctx, "Interrupting ansible-playbook, as the operation ended")
+		terminateTimer = time.AfterFunc(playbookTerminateDelay, func() {
+			tflog.Warn(ctx, "Terminating ansible-playbook, as it didn't stop after the interrupt")
+			terminateProcess(runAnsiblePlay.Process)
+		})
+		return interruptProcess(runAnsiblePlay.Process)
+	}
+	runAnsiblePlay.WaitDelay = playbookInterruptDelay
+	if progressWriter != nil {
+		// The first extra file becomes file descriptor 3 of the child
+		runAnsiblePlay.ExtraFiles = []*os.File{progressWriter}
+	}
+
+	// Stdout may be the artifact of a very verbose run, it's spilled to a
+	// temporary file beyond the threshold
+	stdoutBuf := NewSpillBuffer(data.StdoutSpillThreshold.ValueInt64(), TempFilePattern("stdout-*"))
+	defer stdoutBuf.Close()
+	var stderrBuf bytes.Buffer
+	logStream := logForwarder.Start(ctx, LogTags{
+		RunId:      uuid.New().String(),
+		ResourceId: data.Id.ValueString(),
+		Playbook:   data.Playbook.ValueString(),
+	}, redactor)
+	runAnsiblePlay.Stdout = logStream.Tee(stdoutBuf, "stdout")
+	runAnsiblePlay.Stderr = logStream.Tee(&stderrBuf, "stderr")
 
-	var stdoutBuf, stderrBuf bytes.Buffer
-	runAnsiblePlay.Stdout = &stdoutBuf
-	runAnsiblePlay.Stderr = &stderrBuf
+	telemetry := providerData.GetTelemetry()
+	runCtx, runSpan := telemetry.StartRun(ctx, data.Playbook.ValueString())
 
-	executionError := runAnsiblePlay.Run()
-	stdout := stdoutBuf.String()
-	stderr := stderrBuf.String()
+	executionError := runAnsiblePlay.Start()
+	if progressWriter != nil {
+		progressWriter.Close()
+	}
+
+	var progress Progress
+	if executionError == nil {
+		progressDone := make(chan struct{})
+		if progressReader != nil {
+			go func() {
+				progress = StreamProgress(ctx, progressReader, redactor)
+				close(progressDone)
+			}()
+		} else {
+			close(progressDone)
+		}
+
+		executionError = runAnsiblePlay.Wait()
+		if terminateTimer != nil {
+			terminateTimer.Stop()
+		}
+		if ctx.Err() != nil {
+			// Children like ssh may outlive an interrupted ansible, and the
+			// container its engine's client
+			killProcessGroup(runAnsiblePlay.Process)
+			KillContainer(ctx, runAnsiblePlay)
+		}
+		<-progressDone
+	}
+
+	if progressReader != nil {
+		progressReader.Close()
+	}
+	if err := logStream.Close(); err != nil {
+		diags.AddWarning("Failed to forward the output of Ansible", redactor.Redact(err.Error()))
+	}
+	artifactBuf := stdoutBuf
+	if len(artifactFile) > 0 {
+		artifactFileBuf, err := OpenSpillFile(artifactFile)
+		if err != nil {
+			diags.AddWarning("Failed to read the playbook artifact", err.Error())
+			artifactFileBuf = NewSpillBuffer(0, "")
+		}
+		defer artifactFileBuf.Close()
+		artifactBuf = artifactFileBuf
+	}
+
+	// Only the part of spilled output kept in the state is read back
+	maxOutputSize := data.MaxOutputSize.ValueInt64()
+	stderr := redactor.Redact(stderrBuf.String())
+	artifact, _, err := artifactBuf.Text(maxOutputSize, redactor)
+	if err != nil {
+		diags.AddWarning("Failed to read the playbook artifact", err.Error())
+	}
 
 	if len(stderr) > 0 {
 		diags.AddWarning("Stderr from Ansible", stderr)
 	}
 
-	if executionError != nil {
-		summary := "Ansible playbook command finished with an error: " + executionError.Error()
-		details := ""
+	// The artifact is decoded once for everything reading it
+	parsedArtifact := EmptyArtifact()
+	if jsonCallback {
+		parsedArtifact = ParseArtifact(artifactBuf.Reader())
+	}
+	if options.Stats != nil {
+		*options.Stats = parsedArtifact.Root.Stats
+	}
 
-		formattedOutput, hadFailure, err := AnalyzeJSON(stdoutBuf)
+	if reportPath := data.JUnitReportPath.ValueString(); len(reportPath) > 0 {
+		err := WriteJUnitReport(parsedArtifact, data.Playbook.ValueString(), reportPath, redactor)
 		if err != nil {
-			diags.AddError("Error analyzing result JSON: "+err.Error(), "STDOUT:\n"+stdout)
-		} else if hadFailure {
-			details = formattedOutput
+			diags.AddAttributeWarning(path.Root("junit_report_path"), "Failed to write JUnit report", redactor.Redact(err.Error()))
+		}
+	}
+
+	exitCode := ExitCode(executionError)
+	telemetry.EndRun(runCtx, runSpan, data.Playbook.ValueString(), runStart, parsedArtifact.Root, exitCode)
+
+	historyEntry := NewRunHistoryEntry(data.Id.ValueString(), data.Playbook.ValueString(), args, runStart, exitCode, parsedArtifact.Root, redactor)
+	if err := runHistory.Append(historyEntry); err != nil {
+		diags.AddWarning("Failed to append to the run history", redactor.Redact(err.Error()))
+	}
+	data.LogFile = types.StringNull()
+	if len(tempLog) > 0 {
+		if err := SaveRunLog(tempLog, logPath, redactor); err != nil {
+			diags.AddAttributeWarning(path.Root("log_path"), "Failed to save the log", redactor.Redact(err.Error()))
+		} else {
+			data.LogFile = types.StringValue(logPath)
+		}
+	}
+	var uploadArtifact io.Reader
+	if jsonCallback {
+		if err := artifactStore.Save(data.Id.ValueString(), runStart, artifactBuf.Reader(), redactor); err != nil {
+			diags.AddWarning("Failed to save the artifact", redactor.Redact(err.Error()))
+		}
+		uploadArtifact = artifactBuf.Reader()
+	}
+	UploadRunArtifacts(ctx, data, effectiveEnv, runStart, uploadArtifact, redactor, diags)
+
+	if executionError != nil && slices.Contains(acceptableExitCodes, int64(exitCode)) {
+		diags.AddWarning(fmt.Sprintf("Ansible playbook command finished with the acceptable exit code %d", exitCode), "")
+		executionError = nil
+	}
+
+	hostSummary := parsedArtifact.Root.HostSummary()
+	ignoreUnreachable := data.IgnoreUnreachable.ValueBool()
+
+	unreachableHosts, newDiags := types.ListValueFrom(ctx, types.StringType, nonNilStrings(hostSummary.Unreachable))
+	diags.Append(newDiags...)
+	data.UnreachableHosts = unreachableHosts
+
+	if ignoreUnreachable && len(hostSummary.Unreachable) > 0 {
+		diags.AddWarning(fmt.Sprintf("%d of %d hosts were unreachable", len(hostSummary.Unreachable), hostSummary.Total),
+			"Unreachable hosts: "+strings.Join(hostSummary.Unreachable, ", "))
+	}
+
+	if executionError != nil && parsedArtifact.Err == nil && exitCode != ExitCodeFailedBreakPlay {
+		failedHosts := hostSummary.FailedHosts(ignoreUnreachable)
+		switch {
+		case ignoreUnreachable && len(failedHosts) == 0 && len(hostSummary.Unreachable) > 0:
+			executionError = nil
+		case len(failedHosts) > 0 && (!data.MaxFailedHosts.IsNull() || !data.MaxFailedPercentage.IsNull()) &&
+			withinFailureThreshold(data, len(failedHosts), hostSummary.Total):
+			diags.AddWarning(fmt.Sprintf("%d of %d hosts failed, within the failure threshold", len(failedHosts), hostSummary.Total),
+				"Failed hosts: "+strings.Join(failedHosts, ", "))
+			executionError = nil
+		}
+	}
+
+	if webhook != nil {
+		notification := NewRunNotification(data.Id.ValueString(), data.Playbook.ValueString(), executionError != nil, exitCode, parsedArtifact.Root, redactor)
+		if err := webhook.Send(context.WithoutCancel(ctx), notification); err != nil {
+			diags.AddAttributeWarning(path.Root("notify_webhook"), "Failed to notify the webhook", redactor.Redact(err.Error()))
+		}
+	}
+
+	if executionError != nil {
+		failures, _ := parsedArtifact.Root.Failures()
+		// An interrupted run has no artifact, its progress is reported instead
+		if parsedArtifact.Err != nil && ctx.Err() == nil {
+			diags.AddError("Incomplete result JSON of Ansible", redactor.Redact(parsedArtifact.RawOutputDetail(artifact, stderr)))
+		}
+		if !jsonCallback {
+			// The failures are only in the plain text output then
+			diags.AddError("Output of Ansible", "End of the output:\n"+lastLines(artifact, rawOutputTailLines))
+		}
+
+		unreachable := false
+		for _, failure := range failures {
+			unreachable = unreachable || failure.Unreachable
+			if failure.Unreachable && ignoreUnreachable {
+				continue
+			}
+			diags.AddError(redactor.Redact(failure.Summary()), redactor.Redact(failure.Detail()))
 		}
 
-		diags.AddError(summary, details)
+		if ctx.Err() == context.DeadlineExceeded {
+			diags.AddError("Ansible playbook command timed out",
+				strings.TrimSpace("The playbook was interrupted, as it didn't finish within the timeout of the operation. "+redactor.Redact(progress.Describe())))
+		} else if ctx.Err() == context.Canceled {
+			diags.AddError("Ansible playbook command was canceled",
+				strings.TrimSpace("The playbook was interrupted, as the operation was canceled. "+redactor.Redact(progress.Describe())))
+		} else if exitCode >= 0 {
+			diags.AddError(fmt.Sprintf("Ansible playbook command finished with exit code %d: %s", exitCode, DescribeExitCode(exitCode, unreachable)), "")
+		} else {
+			diags.AddError("Ansible playbook command finished with an error: "+executionError.Error(), "")
+		}
 	} else {
 		if data.StoreOutputInState.ValueBool() {
-			data.AnsiblePlaybookStdout = types.StringValue(stdout)
+			truncatedStdout, truncated, err := stdoutBuf.Text(maxOutputSize, redactor)
+			if err != nil {
+				diags.AddWarning("Failed to read the stdout of Ansible", err.Error())
+			}
+			if truncated {
+				diags.AddAttributeWarning(path.Root("ansible_playbook_stdout"), "Ansible stdout truncated",
+					fmt.Sprintf("The stdout of %d bytes exceeds max_output_size and was truncated to %d bytes in the state.", stdoutBuf.Len(), maxOutputSize))
+			}
+			data.AnsiblePlaybookStdout = storedOutput(truncatedStdout, data.CompressOutput.ValueBool(), diags)
 		} else {
 			data.AnsiblePlaybookStdout = types.StringValue("")
 		}
 
-		data.AnsiblePlaybookStderr = types.StringValue(stderr)
+		truncatedStderr, truncated := TruncateOutput(stderr, maxOutputSize)
+		if truncated {
+			diags.AddAttributeWarning(path.Root("ansible_playbook_stderr"), "Ansible stderr truncated",
+				fmt.Sprintf("The stderr of %d bytes exceeds max_output_size and was truncated to %d bytes in the state.", len(stderr), maxOutputSize))
+		}
+		data.AnsiblePlaybookStderr = storedOutput(truncatedStderr, data.CompressOutput.ValueBool(), diags)
+
+		diffs := map[string]string{}
+		if data.DiffMode.ValueBool() {
+			diffs = CollectDiffs(parsedArtifact.Root)
+			for task, diff := range diffs {
+				diffs[task] = redactor.Redact(diff)
+			}
+		}
+		diffsValue, newDiags := types.MapValueFrom(ctx, types.StringType, diffs)
+		diags.Append(newDiags...)
+		data.Diffs = diffsValue
 
-		err := QueryPlaybookArtifact(stdoutBuf, artifactQueries)
+		err := QueryPlaybookArtifact(parsedArtifact, stderrBuf.Bytes(), artifactQueries)
 		if err != nil {
-			diags.AddAttributeError(path.Root("artifact_queries"), "Playbook artifact queries failed", err.Error())
+			diags.AddAttributeError(path.Root("artifact_queries"), "Playbook artifact queries failed", redactor.Redact(err.Error()))
 		}
 
 		for name, model := range queriesModel {
-			diags.Append(model.Set(ctx, artifactQueries[name])...)
+			query := artifactQueries[name]
+			query.Result = redactor.Redact(query.Result)
+			for i, result := range query.Results {
+				query.Results[i] = redactor.Redact(result)
+			}
+			diags.Append(model.Set(ctx, query)...)
 			queriesModel[name] = model
 		}
 
@@ -100,15 +595,46 @@ func Execute(ctx context.Context, diags *diag.Diagnostics, data *PlaybookResourc
 		diags.Append(newDiags...)
 		data.ArtifactQueries = newQueriesModel
 
-		formattedOutput, hadFailure, err := AnalyzeJSON(stdoutBuf)
-		if err != nil {
-			diags.AddError("Error analyzing result JSON: "+err.Error(), "STDERR:\n"+stderr+"\n\nSTDOUT:\n"+stdout)
-		} else {
-			if hadFailure {
-				diags.AddWarning("Ansible results", formattedOutput)
+		artifactValues, newDiags := ArtifactValues(artifactQueries, redactor)
+		diags.Append(newDiags...)
+		data.ArtifactValues = artifactValues
+
+		failures, _ := parsedArtifact.Root.Failures()
+		// The run succeeded according to its exit code, so only warn
+		if parsedArtifact.Err != nil {
+			diags.AddWarning("Incomplete result JSON of Ansible", redactor.Redact(parsedArtifact.RawOutputDetail(artifact, stderr)))
+		}
+		for _, failure := range failures {
+			if failure.Unreachable && ignoreUnreachable {
+				continue
 			}
+			diags.AddWarning(redactor.Redact(failure.Summary()), redactor.Redact(failure.Detail()))
 		}
 	}
 
-	RemoveFile(tempInventoryFile, diags)
+}
+
+func withinFailureThreshold(data *PlaybookResourceModel, failedHosts int, totalHosts int) bool {
+	if !data.MaxFailedHosts.IsNull() && int64(failedHosts) > data.MaxFailedHosts.ValueInt64() {
+		return false
+	}
+	if !data.MaxFailedPercentage.IsNull() && totalHosts > 0 &&
+		float64(failedHosts)*100/float64(totalHosts) > data.MaxFailedPercentage.ValueFloat64() {
+		return false
+	}
+	return true
+}
+
+// The output as stored in the state, compressed if compress_output is set
+func storedOutput(output string, compress bool, diags *diag.Diagnostics) types.String {
+	if !compress {
+		return types.StringValue(output)
+	}
+
+	compressed, err := CompressOutput(output)
+	if err != nil {
+		diags.AddError("Failed to compress the output", err.Error())
+		return types.StringValue("")
+	}
+	return types.StringValue(compressed)
 }
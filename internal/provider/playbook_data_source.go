@@ -0,0 +1,341 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	resourceschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/defaults"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &PlaybookDataSource{}
+var _ datasource.DataSourceWithValidateConfig = &PlaybookDataSource{}
+var _ datasource.DataSourceWithConfigure = &PlaybookDataSource{}
+
+func NewPlaybookDataSource() datasource.DataSource {
+	return &PlaybookDataSource{}
+}
+
+type PlaybookDataSource struct {
+	providerData *ProviderData
+}
+
+// PlaybookDataSourceModel describes the data source data model.
+type PlaybookDataSourceModel struct {
+	Playbook              types.String  `tfsdk:"playbook"`
+	Inventory             types.String  `tfsdk:"inventory"`
+	AnsiblePlaybookBinary types.String  `tfsdk:"ansible_playbook_binary"`
+	CheckMode             types.Bool    `tfsdk:"check_mode"`
+	ExtraVars             types.Map     `tfsdk:"extra_vars"`
+	SensitiveExtraVars    types.Map     `tfsdk:"sensitive_extra_vars"`
+	VarFiles              types.List    `tfsdk:"var_files"`
+	Redact                types.List    `tfsdk:"redact"`
+	RedactPatterns        types.List    `tfsdk:"redact_patterns"`
+	ArtifactQueries       types.Map     `tfsdk:"artifact_queries"`
+	ArtifactValues        types.Dynamic `tfsdk:"artifact_values"`
+	AnsiblePlaybookStderr types.String  `tfsdk:"ansible_playbook_stderr"`
+	Id                    types.String  `tfsdk:"id"`
+}
+
+// The resource model of the defaults of the resource schema: the default of
+// every attribute that has one, null for other arguments and unknown for the
+// attributes only computed by a run. Derived from the schema once, so models
+// built from it keep up with new attributes of the resource.
+var defaultResourceModel = sync.OnceValues(func() (PlaybookResourceModel, diag.Diagnostics) {
+	ctx := context.Background()
+	var diags diag.Diagnostics
+
+	var schemaResp resource.SchemaResponse
+	(&PlaybookResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	diags.Append(schemaResp.Diagnostics...)
+
+	attributeTypes := make(map[string]attr.Type, len(schemaResp.Schema.Attributes))
+	attributes := make(map[string]attr.Value, len(schemaResp.Schema.Attributes))
+	for name, attribute := range schemaResp.Schema.Attributes {
+		attributeTypes[name] = attribute.GetType()
+		attributes[name] = attributeDefault(ctx, attribute, &diags)
+	}
+	if diags.HasError() {
+		return PlaybookResourceModel{}, diags
+	}
+
+	var model PlaybookResourceModel
+	object, newDiags := types.ObjectValue(attributeTypes, attributes)
+	diags.Append(newDiags...)
+	diags.Append(object.As(ctx, &model, basetypes.ObjectAsOptions{})...)
+	return model, diags
+})
+
+// The default value of a resource attribute, see defaultResourceModel
+func attributeDefault(ctx context.Context, attribute resourceschema.Attribute, diags *diag.Diagnostics) attr.Value {
+	var value attr.Value
+	switch a := attribute.(type) {
+	case resourceschema.StringAttribute:
+		if a.Default != nil {
+			var resp defaults.StringResponse
+			a.Default.DefaultString(ctx, defaults.StringRequest{}, &resp)
+			diags.Append(resp.Diagnostics...)
+			value = resp.PlanValue
+		}
+	case resourceschema.BoolAttribute:
+		if a.Default != nil {
+			var resp defaults.BoolResponse
+			a.Default.DefaultBool(ctx, defaults.BoolRequest{}, &resp)
+			diags.Append(resp.Diagnostics...)
+			value = resp.PlanValue
+		}
+	case resourceschema.Int64Attribute:
+		if a.Default != nil {
+			var resp defaults.Int64Response
+			a.Default.DefaultInt64(ctx, defaults.Int64Request{}, &resp)
+			diags.Append(resp.Diagnostics...)
+			value = resp.PlanValue
+		}
+	case resourceschema.Float64Attribute:
+		if a.Default != nil {
+			var resp defaults.Float64Response
+			a.Default.DefaultFloat64(ctx, defaults.Float64Request{}, &resp)
+			diags.Append(resp.Diagnostics...)
+			value = resp.PlanValue
+		}
+	case resourceschema.ListAttribute:
+		if a.Default != nil {
+			var resp defaults.ListResponse
+			a.Default.DefaultList(ctx, defaults.ListRequest{}, &resp)
+			diags.Append(resp.Diagnostics...)
+			value = resp.PlanValue
+		}
+	case resourceschema.MapAttribute:
+		if a.Default != nil {
+			var resp defaults.MapResponse
+			a.Default.DefaultMap(ctx, defaults.MapRequest{}, &resp)
+			diags.Append(resp.Diagnostics...)
+			value = resp.PlanValue
+		}
+	}
+	if value != nil {
+		return value
+	}
+
+	attributeType := attribute.GetType()
+	var raw tftypes.Value
+	if attribute.IsComputed() && !attribute.IsOptional() {
+		raw = tftypes.NewValue(attributeType.TerraformType(ctx), tftypes.UnknownValue)
+	} else {
+		raw = tftypes.NewValue(attributeType.TerraformType(ctx), nil)
+	}
+	value, err := attributeType.ValueFromTerraform(ctx, raw)
+	if err != nil {
+		diags.AddError("Failed to build the default resource model", err.Error())
+	}
+	return value
+}
+
+// The resource model Execute runs with, for the settings of the data source.
+// Everything else is left at the defaults of the resource.
+func (m PlaybookDataSourceModel) ResourceModel() (PlaybookResourceModel, diag.Diagnostics) {
+	data, diags := defaultResourceModel()
+
+	data.Playbook = m.Playbook
+	data.Inventory = m.Inventory
+	if !m.AnsiblePlaybookBinary.IsNull() {
+		data.AnsiblePlaybookBinary = m.AnsiblePlaybookBinary
+	}
+	data.ExtraVars = m.ExtraVars
+	data.SensitiveExtraVars = m.SensitiveExtraVars
+	data.VarFiles = m.VarFiles
+	data.Redact = m.Redact
+	data.RedactPatterns = m.RedactPatterns
+	data.ArtifactQueries = m.ArtifactQueries
+	data.Id = m.Id
+	return data, diags
+}
+
+func (d *PlaybookDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_playbook"
+}
+
+func (d *PlaybookDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Runs an Ansible playbook on every refresh, by default in check mode, to read information from hosts without managing a resource lifecycle. The other settings are the defaults of the `ansible_playbook` resource. Reads aren't recorded in the run history or `artifact_dir` of the provider, and their output isn't forwarded by `log_forwarding`.",
+
+		Attributes: map[string]schema.Attribute{
+			"playbook": schema.StringAttribute{
+				MarkdownDescription: "Path to ansible playbook.",
+				Required:            true,
+			},
+			"inventory": schema.StringAttribute{
+				MarkdownDescription: "The inventory to use. Not a path, the contents.",
+				Required:            true,
+			},
+			"ansible_playbook_binary": schema.StringAttribute{
+				MarkdownDescription: "Defaults to `ansible-playbook`.",
+				Optional:            true,
+			},
+			"check_mode": schema.BoolAttribute{
+				MarkdownDescription: "Run the playbook with `--check`. Defaults to true; only disable it for playbooks that don't change anything, like fact gathering.",
+				Optional:            true,
+			},
+			"extra_vars": schema.MapAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "A map of additional variables as: { keyString = \"value-1\", keyList = [\"list-value-1\", \"list-value-2\"], ... }.",
+			},
+			"sensitive_extra_vars": schema.MapAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				ElementType: types.StringType,
+				Description: "Like extra_vars, but for secret values. Their values are redacted from the artifact query results and the diagnostics.",
+			},
+			"var_files": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Paths to variable files, e.g. vault encrypted ones, passed as extra vars.",
+			},
+			"redact": schema.ListAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				ElementType: types.StringType,
+				Description: "Strings to replace with \"********\" in the artifact query results and the diagnostics.",
+			},
+			"redact_patterns": schema.ListAttribute{
+				Optional:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "Regular expressions whose matches are replaced with `********` like `redact`.",
+			},
+			"artifact_queries": schema.MapNestedAttribute{
+				MarkdownDescription: "Query the playbook artifact or stderr with [JSONPath](https://goessner.net/articles/JsonPath/), [jq](https://jqlang.github.io/jq/manual/) or a regular expression, the same way as the `ansible_playbook` resource does.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"jsonpath": schema.StringAttribute{
+							Description: "JSONPath expression. Exactly one of jsonpath, jq and regex must be set.",
+							Optional:    true,
+						},
+						"jq": schema.StringAttribute{
+							Description: "jq expression. Exactly one of jsonpath, jq and regex must be set.",
+							Optional:    true,
+						},
+						"regex": schema.StringAttribute{
+							Description: "Regular expression, the first capture group or the whole match of every match is a result. Exactly one of jsonpath, jq and regex must be set.",
+							Optional:    true,
+						},
+						"source": schema.StringAttribute{
+							Optional:            true,
+							Computed:            true,
+							MarkdownDescription: "The output to query: `artifact` (default) or `stderr`.",
+						},
+						"json_output": schema.BoolAttribute{
+							Optional:    true,
+							Computed:    true,
+							Description: "Output the result as valid JSON. Defaults to false.",
+						},
+						"fail_on_missing_key": schema.BoolAttribute{
+							Optional:    true,
+							Computed:    true,
+							Description: "Fail the data source, if there is no key specified by the JSON path. Defaults to false.",
+						},
+						"type": schema.StringAttribute{
+							Optional:            true,
+							Computed:            true,
+							MarkdownDescription: "Expected type of the result: `string` (default), `number`, `bool`, `list` or `object`.",
+						},
+						"result": schema.StringAttribute{
+							Description: "Result of the query. Result may be empty if a field or map key cannot be located.",
+							Computed:    true,
+						},
+						"results": schema.ListAttribute{
+							Description: "Every value matched by the query as a separate element.",
+							Computed:    true,
+							ElementType: types.StringType,
+						},
+					},
+				},
+			},
+			"artifact_values": schema.DynamicAttribute{
+				Computed:            true,
+				MarkdownDescription: "The results of `artifact_queries`, keyed by query name and converted to the `type` declared by the query.",
+			},
+			"ansible_playbook_stderr": schema.StringAttribute{
+				Computed:    true,
+				Description: "An ansible-playbook CLI stderr output.",
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier",
+			},
+		},
+	}
+}
+
+func (d *PlaybookDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.providerData = providerData
+}
+
+func (d *PlaybookDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var config PlaybookDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validateArtifactQueries(ctx, config.ArtifactQueries, &resp.Diagnostics)
+	RedactPatterns(ctx, config.RedactPatterns, &resp.Diagnostics)
+}
+
+func (d *PlaybookDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data PlaybookDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(uuid.New().String())
+	resourceData, diags := data.ResourceModel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Check mode unless disabled, and without recording the read like a run
+	checkMode := data.CheckMode.IsNull() || data.CheckMode.ValueBool()
+	Execute(ctx, &resp.Diagnostics, &resourceData, d.providerData, RunOptions{CheckMode: checkMode, ReadOnly: true})
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.ArtifactQueries = resourceData.ArtifactQueries
+	data.ArtifactValues = resourceData.ArtifactValues
+	data.AnsiblePlaybookStderr = resourceData.AnsiblePlaybookStderr
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,306 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &PlaybookFanoutResource{}
+var _ resource.ResourceWithValidateConfig = &PlaybookFanoutResource{}
+var _ resource.ResourceWithConfigure = &PlaybookFanoutResource{}
+var _ resource.ResourceWithModifyPlan = &PlaybookFanoutResource{}
+
+func NewPlaybookFanoutResource() resource.Resource {
+	return &PlaybookFanoutResource{}
+}
+
+// PlaybookFanoutResource runs the same playbook against several inventories
+// concurrently, every target like a run of the playbook resource.
+type PlaybookFanoutResource struct {
+	providerData *ProviderData
+}
+
+// PlaybookFanoutResourceModel describes the resource data model.
+type PlaybookFanoutResourceModel struct {
+	Playbook              types.String   `tfsdk:"playbook"`
+	Inventories           types.Map      `tfsdk:"inventories"`
+	AnsiblePlaybookBinary types.String   `tfsdk:"ansible_playbook_binary"`
+	ExtraVars             types.Map      `tfsdk:"extra_vars"`
+	Tags                  types.List     `tfsdk:"tags"`
+	MaxParallelism        types.Int64    `tfsdk:"max_parallelism"`
+	PlaybookHash          types.String   `tfsdk:"playbook_hash"`
+	Stats                 types.Map      `tfsdk:"stats"`
+	Timeouts              timeouts.Value `tfsdk:"timeouts"`
+	Id                    types.String   `tfsdk:"id"`
+}
+
+// The resource model Execute runs the playbook against the inventory with
+func (m PlaybookFanoutResourceModel) ResourceModel(inventory string) (PlaybookResourceModel, diag.Diagnostics) {
+	return PlaybookDataSourceModel{
+		Playbook:              m.Playbook,
+		Inventory:             types.StringValue(inventory),
+		AnsiblePlaybookBinary: m.AnsiblePlaybookBinary,
+		ExtraVars:             m.ExtraVars,
+		SensitiveExtraVars:    types.MapNull(types.StringType),
+		VarFiles:              types.ListNull(types.StringType),
+		Redact:                types.ListNull(types.StringType),
+		RedactPatterns:        types.ListNull(types.StringType),
+		ArtifactQueries:       types.MapNull(types.ObjectType{AttrTypes: ArtifactQueryModel{}.AttrTypes()}),
+		Id:                    m.Id,
+	}.ResourceModel()
+}
+
+func (r *PlaybookFanoutResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_playbook_fanout"
+}
+
+func (r *PlaybookFanoutResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Runs the same playbook against several inventories, e.g. the clusters of every region, concurrently with bounded parallelism, instead of `for_each` over `ansible_playbook` without control over concurrency. All targets run again when the playbook, its variables or any inventory changes.",
+
+		Attributes: map[string]schema.Attribute{
+			"playbook": schema.StringAttribute{
+				MarkdownDescription: "Path to the playbook.",
+				Required:            true,
+			},
+			"inventories": schema.MapAttribute{
+				MarkdownDescription: "The inventories to run the playbook against, by target name. Not paths, the contents.",
+				Required:            true,
+				ElementType:         types.StringType,
+			},
+			"ansible_playbook_binary": schema.StringAttribute{
+				MarkdownDescription: "Defaults to `ansible-playbook`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("ansible-playbook"),
+			},
+			"extra_vars": schema.MapAttribute{
+				MarkdownDescription: "Additional variables, the same for every target.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"tags": schema.ListAttribute{
+				MarkdownDescription: "Only run the tasks with these tags.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"max_parallelism": schema.Int64Attribute{
+				MarkdownDescription: "How many targets may run at the same time, in the order of their names. Defaults to 4.",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(4),
+			},
+			"playbook_hash": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Hash of the playbook, its roles, `group_vars` and `host_vars`, like the `playbook_hash` of `ansible_playbook`.",
+			},
+			"stats": schema.MapAttribute{
+				MarkdownDescription: "The stats of the last run per target and host, with `ok`, `changed`, `failures`, `unreachable`, `skipped`, `rescued` and `ignored`.",
+				Computed:            true,
+				ElementType:         types.MapType{ElemType: types.ObjectType{AttrTypes: HostStatsModel{}.AttrTypes()}},
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+			}),
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *PlaybookFanoutResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.providerData = providerData
+}
+
+func (r *PlaybookFanoutResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config PlaybookFanoutResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.Inventories.IsNull() && !config.Inventories.IsUnknown() && len(config.Inventories.Elements()) == 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("inventories"), "No inventories", "At least one inventory must be set.")
+	}
+	if !config.MaxParallelism.IsNull() && !config.MaxParallelism.IsUnknown() && config.MaxParallelism.ValueInt64() < 1 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_parallelism"), "Invalid parallelism", "max_parallelism must be at least 1.")
+	}
+}
+
+// The playbook hash of the playbook, which is the same for every target.
+// Unknown while the playbook is.
+func (m *PlaybookFanoutResourceModel) playbookHash(diags *diag.Diagnostics) types.String {
+	if m.Playbook.IsUnknown() {
+		return types.StringUnknown()
+	}
+	hash, err := calculatePlaybookHash(m.Playbook.ValueString(), PlaybookHashOptions{})
+	if err != nil {
+		diags.AddAttributeError(path.Root("playbook"), "Error Calculating Playbook Hash", err.Error())
+		return types.StringUnknown()
+	}
+	return types.StringValue(hash)
+}
+
+func (r *PlaybookFanoutResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var plan *PlaybookFanoutResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+
+	if resp.Diagnostics.HasError() || plan == nil {
+		return
+	}
+
+	hash := plan.playbookHash(&resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	planPlaybookHash(ctx, req, resp, hash, types.MapType{ElemType: types.ObjectType{AttrTypes: HostStatsModel{}.AttrTypes()}})
+}
+
+// Run the playbook against every inventory, at most max_parallelism at a
+// time, and set the stats per target. A failed target doesn't stop the
+// others.
+func (r *PlaybookFanoutResource) run(ctx context.Context, diags *diag.Diagnostics, data *PlaybookFanoutResourceModel) {
+	var inventories map[string]string
+	diags.Append(data.Inventories.ElementsAs(ctx, &inventories, false)...)
+
+	var tags []string
+	diags.Append(data.Tags.ElementsAs(ctx, &tags, false)...)
+
+	if diags.HasError() {
+		return
+	}
+
+	if data.PlaybookHash.IsUnknown() {
+		data.PlaybookHash = data.playbookHash(diags)
+	}
+	if diags.HasError() {
+		return
+	}
+
+	targets := SortedKeys(inventories)
+	targetDiags := make([]diag.Diagnostics, len(targets))
+	targetStats := make([]Stats, len(targets))
+
+	started := runBounded(ctx, len(targets), data.MaxParallelism.ValueInt64(), false, func(i int) bool {
+		targetData, newDiags := data.ResourceModel(inventories[targets[i]])
+		targetDiags[i].Append(newDiags...)
+		if targetDiags[i].HasError() {
+			return false
+		}
+		Execute(ctx, &targetDiags[i], &targetData, r.providerData, RunOptions{Tags: tags, Stats: &targetStats[i]})
+		return !targetDiags[i].HasError()
+	})
+
+	stats := make(map[string]attr.Value, started)
+	for i, target := range targets[:started] {
+		diags.Append(targetDiags[i]...)
+		if targetDiags[i].HasError() {
+			diags.AddAttributeError(path.Root("inventories").AtMapKey(target), fmt.Sprintf("Target %s failed", target), "")
+		}
+		stats[target] = StatsValue(ctx, targetStats[i], diags)
+	}
+	if started < len(targets) {
+		diags.AddWarning(fmt.Sprintf("%d of %d targets didn't run", len(targets)-started, len(targets)), "The operation ended before they were started.")
+	}
+
+	statsValue, newDiags := types.MapValue(types.MapType{ElemType: types.ObjectType{AttrTypes: HostStatsModel{}.AttrTypes()}}, stats)
+	diags.Append(newDiags...)
+	data.Stats = statsValue
+}
+
+func (r *PlaybookFanoutResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data PlaybookFanoutResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(uuid.New().String())
+
+	createTimeout, diags := data.Timeouts.Create(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *PlaybookFanoutResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+func (r *PlaybookFanoutResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data PlaybookFanoutResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := data.Timeouts.Update(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *PlaybookFanoutResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &PlaybookHashFunction{}
+
+func NewPlaybookHashFunction() function.Function {
+	return &PlaybookHashFunction{}
+}
+
+type PlaybookHashFunction struct {
+}
+
+func (f *PlaybookHashFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "playbook_hash"
+}
+
+func (f *PlaybookHashFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Compute the hash of a playbook",
+		MarkdownDescription: "Computes the hash of a playbook, its roles, imported playbooks, group_vars and host_vars, the same way as the `playbook_hash` attribute of `ansible_playbook`. Useful as trigger for other resources that should be replaced when the playbook changes. Pass the `var_files`, `hash_include` and `hash_exclude` of the resource as options to get the same hash as it.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "path",
+				MarkdownDescription: "Path to ansible playbook.",
+			},
+		},
+		VariadicParameter: function.DynamicParameter{
+			Name:                "options",
+			MarkdownDescription: "At most one object with the optional `var_files`, `hash_include` and `hash_exclude` lists of the resource, e.g. `{ var_files = [\"vars.yml\"] }`.",
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *PlaybookHashFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var playbookPath string
+	var options []types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &playbookPath, &options))
+	if resp.Error != nil {
+		return
+	}
+
+	hashOptions, err := playbookHashFunctionOptions(options)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, err.Error())
+		return
+	}
+
+	// A function must not have side effects, so the digest cache is only
+	// used, not saved
+	hash, err := hashPlaybook(playbookPath, hashOptions, DefaultFileDigestCache().Digest)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, hash))
+}
+
+// The hash options of the options argument of the function
+func playbookHashFunctionOptions(options []types.Dynamic) (PlaybookHashOptions, error) {
+	var hashOptions PlaybookHashOptions
+	if len(options) == 0 {
+		return hashOptions, nil
+	}
+	if len(options) > 1 {
+		return hashOptions, fmt.Errorf("at most one options object can be passed")
+	}
+
+	decoded, err := AttrValueToJSON(options[0])
+	if err != nil {
+		return hashOptions, err
+	}
+	if decoded == nil {
+		return hashOptions, nil
+	}
+	object, ok := decoded.(map[string]interface{})
+	if !ok {
+		return hashOptions, fmt.Errorf("options must be an object")
+	}
+
+	for name, value := range object {
+		var target *[]string
+		switch name {
+		case "var_files":
+			target = &hashOptions.VarFiles
+		case "hash_include":
+			target = &hashOptions.Include
+		case "hash_exclude":
+			target = &hashOptions.Exclude
+		default:
+			return hashOptions, fmt.Errorf("unsupported option %q, only var_files, hash_include and hash_exclude are", name)
+		}
+
+		if value == nil {
+			continue
+		}
+		elements, ok := value.([]interface{})
+		if !ok {
+			return hashOptions, fmt.Errorf("%s must be a list of strings", name)
+		}
+		for _, element := range elements {
+			str, ok := element.(string)
+			if !ok {
+				return hashOptions, fmt.Errorf("%s must be a list of strings", name)
+			}
+			*target = append(*target, str)
+		}
+	}
+	return hashOptions, nil
+}
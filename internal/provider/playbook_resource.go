@@ -7,14 +7,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
@@ -24,41 +32,124 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &PlaybookResource{}
 var _ resource.ResourceWithImportState = &PlaybookResource{}
+var _ resource.ResourceWithValidateConfig = &PlaybookResource{}
+var _ resource.ResourceWithConfigure = &PlaybookResource{}
+var _ resource.ResourceWithUpgradeState = &PlaybookResource{}
 
 func NewPlaybookResource() resource.Resource {
 	return &PlaybookResource{}
 }
 
 type PlaybookResource struct {
+	providerData *ProviderData
+	// Calculates the playbook hash instead of the playbook, if it was
+	// generated, see RoleResource
+	playbookHash func(options PlaybookHashOptions) (string, error)
 }
 
 // PlaybookResourceModel describes the resource data model.
 type PlaybookResourceModel struct {
-	Playbook              types.String `tfsdk:"playbook"`
-	Inventory             types.String `tfsdk:"inventory"`
-	StoreOutputInState    types.Bool   `tfsdk:"store_output_in_state"`
-	AnsiblePlaybookBinary types.String `tfsdk:"ansible_playbook_binary"`
-	ExtraVars             types.Map    `tfsdk:"extra_vars"`
-	ArtifactQueries       types.Map    `tfsdk:"artifact_queries"`
-	PlaybookHash          types.String `tfsdk:"playbook_hash"`
-	AnsiblePlaybookStdout types.String `tfsdk:"ansible_playbook_stdout"`
-	AnsiblePlaybookStderr types.String `tfsdk:"ansible_playbook_stderr"`
-	Id                    types.String `tfsdk:"id"`
+	Playbook                 types.String   `tfsdk:"playbook"`
+	Inventory                types.String   `tfsdk:"inventory"`
+	InventoryFormat          types.String   `tfsdk:"inventory_format"`
+	Plays                    types.List     `tfsdk:"plays"`
+	Limit                    types.String   `tfsdk:"limit"`
+	StoreOutputInState       types.Bool     `tfsdk:"store_output_in_state"`
+	StdoutCallback           types.String   `tfsdk:"stdout_callback"`
+	JSONCallback             types.Bool     `tfsdk:"json_callback"`
+	AnsiblePlaybookBinary    types.String   `tfsdk:"ansible_playbook_binary"`
+	AnsibleVersionConstraint types.String   `tfsdk:"ansible_version_constraint"`
+	CollectionsLockFile      types.String   `tfsdk:"collections_lock_file"`
+	RequirementsFile         types.String   `tfsdk:"requirements_file"`
+	MaxOutputSize            types.Int64    `tfsdk:"max_output_size"`
+	CompressOutput           types.Bool     `tfsdk:"compress_output"`
+	StripANSI                types.Bool     `tfsdk:"strip_ansi"`
+	StdoutSpillThreshold     types.Int64    `tfsdk:"stdout_spill_threshold"`
+	StreamProgress           types.Bool     `tfsdk:"stream_progress"`
+	JUnitReportPath          types.String   `tfsdk:"junit_report_path"`
+	LogPath                  types.String   `tfsdk:"log_path"`
+	DiffMode                 types.Bool     `tfsdk:"diff_mode"`
+	AcceptableExitCodes      types.List     `tfsdk:"acceptable_exit_codes"`
+	MaxFailedHosts           types.Int64    `tfsdk:"max_failed_hosts"`
+	MaxFailedPercentage      types.Float64  `tfsdk:"max_failed_percentage"`
+	FailFast                 types.Bool     `tfsdk:"fail_fast"`
+	IgnoreUnreachable        types.Bool     `tfsdk:"ignore_unreachable"`
+	OnFailure                types.String   `tfsdk:"on_failure"`
+	DestroyPlaybook          types.String   `tfsdk:"destroy_playbook"`
+	IgnoreDestroyFailure     types.Bool     `tfsdk:"ignore_destroy_failure"`
+	DestroyMode              types.String   `tfsdk:"destroy_mode"`
+	LockKey                  types.String   `tfsdk:"lock_key"`
+	UpdateTags               types.List     `tfsdk:"update_tags"`
+	HostTriggers             types.Map      `tfsdk:"host_triggers"`
+	FactCacheDir             types.String   `tfsdk:"fact_cache_dir"`
+	FactCaching              types.String   `tfsdk:"fact_caching"`
+	FactCachingConnection    types.String   `tfsdk:"fact_caching_connection"`
+	FactCachingTimeout       types.Int64    `tfsdk:"fact_caching_timeout"`
+	Pipelining               types.Bool     `tfsdk:"pipelining"`
+	ConnectionPlugin         types.String   `tfsdk:"connection_plugin"`
+	WinRM                    types.Object   `tfsdk:"winrm"`
+	SSH                      types.Object   `tfsdk:"ssh"`
+	Bastion                  types.Object   `tfsdk:"bastion"`
+	KnownHostsEntries        types.List     `tfsdk:"known_hosts_entries"`
+	ContainerImage           types.String   `tfsdk:"container_image"`
+	ContainerEngine          types.String   `tfsdk:"container_engine"`
+	ContainerVolumes         types.List     `tfsdk:"container_volumes"`
+	PreviewHosts             types.Bool     `tfsdk:"preview_hosts"`
+	PredictChanges           types.Bool     `tfsdk:"predict_changes"`
+	NotifyWebhook            types.Object   `tfsdk:"notify_webhook"`
+	Environment              types.Map      `tfsdk:"environment"`
+	ExtraVars                types.Map      `tfsdk:"extra_vars"`
+	GroupVars                types.Map      `tfsdk:"group_vars"`
+	HostVars                 types.Map      `tfsdk:"host_vars"`
+	SensitiveExtraVars       types.Map      `tfsdk:"sensitive_extra_vars"`
+	VarFiles                 types.List     `tfsdk:"var_files"`
+	VaultIds                 types.Map      `tfsdk:"vault_ids"`
+	HashInclude              types.List     `tfsdk:"hash_include"`
+	HashExclude              types.List     `tfsdk:"hash_exclude"`
+	Redact                   types.List     `tfsdk:"redact"`
+	RedactPatterns           types.List     `tfsdk:"redact_patterns"`
+	ArtifactQueries          types.Map      `tfsdk:"artifact_queries"`
+	ArtifactValues           types.Dynamic  `tfsdk:"artifact_values"`
+	PlaybookHash             types.String   `tfsdk:"playbook_hash"`
+	AnsiblePlaybookStdout    types.String   `tfsdk:"ansible_playbook_stdout"`
+	AnsiblePlaybookStderr    types.String   `tfsdk:"ansible_playbook_stderr"`
+	UnreachableHosts         types.List     `tfsdk:"unreachable_hosts"`
+	Diffs                    types.Map      `tfsdk:"diffs"`
+	LogFile                  types.String   `tfsdk:"log_file"`
+	ArtifactUploadURL        types.String   `tfsdk:"artifact_upload_url"`
+	ArtifactObjectURL        types.String   `tfsdk:"artifact_object_url"`
+	LogObjectURL             types.String   `tfsdk:"log_object_url"`
+	EffectiveEnvironment     types.Map      `tfsdk:"effective_environment"`
+	MatchedHosts             types.List     `tfsdk:"matched_hosts"`
+	PredictedChanges         types.Map      `tfsdk:"predicted_changes"`
+	Failed                   types.Bool     `tfsdk:"failed"`
+	Timeouts                 timeouts.Value `tfsdk:"timeouts"`
+	Id                       types.String   `tfsdk:"id"`
 }
 
 type ArtifactQueryModel struct {
 	JSONPath         types.String `tfsdk:"jsonpath"`
+	JQ               types.String `tfsdk:"jq"`
+	Regex            types.String `tfsdk:"regex"`
+	Source           types.String `tfsdk:"source"`
 	Result           types.String `tfsdk:"result"`
+	Results          types.List   `tfsdk:"results"`
 	FailOnMissingKey types.Bool   `tfsdk:"fail_on_missing_key"`
 	JsonOutput       types.Bool   `tfsdk:"json_output"`
+	Type             types.String `tfsdk:"type"`
 }
 
 func (ArtifactQueryModel) AttrTypes() map[string]attr.Type {
 	return map[string]attr.Type{
 		"jsonpath":            types.StringType,
+		"jq":                  types.StringType,
+		"regex":               types.StringType,
+		"source":              types.StringType,
 		"result":              types.StringType,
+		"results":             types.ListType{ElemType: types.StringType},
 		"fail_on_missing_key": types.BoolType,
 		"json_output":         types.BoolType,
+		"type":                types.StringType,
 	}
 }
 
@@ -66,9 +157,19 @@ func (m ArtifactQueryModel) Value(ctx context.Context, query *ArtifactQuery) dia
 	var diags diag.Diagnostics
 
 	query.JSONPath = m.JSONPath.ValueString()
+	query.JQ = m.JQ.ValueString()
+	query.Regex = m.Regex.ValueString()
+	query.Source = m.Source.ValueString()
+	if len(query.Source) == 0 {
+		query.Source = "artifact"
+	}
 	query.Result = m.Result.ValueString()
 	query.FailOnMissingKey = m.FailOnMissingKey.ValueBool()
 	query.JsonOutput = m.JsonOutput.ValueBool()
+	query.Type = m.Type.ValueString()
+	if len(query.Type) == 0 {
+		query.Type = "string"
+	}
 
 	return diags
 }
@@ -76,10 +177,23 @@ func (m ArtifactQueryModel) Value(ctx context.Context, query *ArtifactQuery) dia
 func (m *ArtifactQueryModel) Set(ctx context.Context, query ArtifactQuery) diag.Diagnostics {
 	var diags diag.Diagnostics
 
-	m.JSONPath = types.StringValue(query.JSONPath)
+	if len(query.JSONPath) > 0 {
+		m.JSONPath = types.StringValue(query.JSONPath)
+	}
+	if len(query.JQ) > 0 {
+		m.JQ = types.StringValue(query.JQ)
+	}
+	if len(query.Regex) > 0 {
+		m.Regex = types.StringValue(query.Regex)
+	}
+	m.Source = types.StringValue(query.Source)
 	m.Result = types.StringValue(query.Result)
+	results, newDiags := types.ListValueFrom(ctx, types.StringType, query.Results)
+	diags.Append(newDiags...)
+	m.Results = results
 	m.FailOnMissingKey = types.BoolValue(query.FailOnMissingKey)
 	m.JsonOutput = types.BoolValue(query.JsonOutput)
+	m.Type = types.StringValue(query.Type)
 
 	return diags
 }
@@ -93,6 +207,9 @@ func (r *PlaybookResource) Schema(ctx context.Context, req resource.SchemaReques
 		// This description is used by the documentation generator and the language server.
 		MarkdownDescription: "Provides an Ansible playbook resource.",
 
+		// Version 1 hashes the digests of the files instead of their contents
+		Version: 1,
+
 		Attributes: map[string]schema.Attribute{
 			"playbook": schema.StringAttribute{
 				MarkdownDescription: "Path to ansible playbook.",
@@ -104,6 +221,19 @@ func (r *PlaybookResource) Schema(ctx context.Context, req resource.SchemaReques
 				Optional:            false,
 				Required:            true,
 			},
+			"inventory_format": schema.StringAttribute{
+				MarkdownDescription: "The format of `inventory`: `yaml` (also for JSON), `ini` or `auto` (default), which detects it. The inventory is checked with `ansible-inventory` next to `ansible_playbook_binary` before every run, as ansible would otherwise run the playbook on no hosts if it can't parse it.",
+				Optional:            true,
+			},
+			"plays": schema.ListAttribute{
+				MarkdownDescription: "Only run the plays with these names, so one large playbook like `site.yml` can be shared by several resources. They run from a temporary copy of the playbook with only these plays, next to it.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"limit": schema.StringAttribute{
+				MarkdownDescription: "Only run on the hosts matching this host pattern, passed to `--limit` unmodified, e.g. `webservers:&staging:!db01`. When `host_triggers` narrow an update to the triggered hosts, the intersections and exclusions of the pattern still apply, and so does a single group or host. Updates of a pattern that's the union of several terms run on all its hosts.",
+				Optional:            true,
+			},
 			"store_output_in_state": schema.BoolAttribute{
 				MarkdownDescription: "Whether or not to store the output of running Ansible in the state. Enable only for debugging, because this is usually huge and may contain sensitive data.",
 				Optional:            true,
@@ -111,18 +241,401 @@ func (r *PlaybookResource) Schema(ctx context.Context, req resource.SchemaReques
 				Computed:            true,
 				Default:             booldefault.StaticBool(false),
 			},
+			"stdout_callback": schema.StringAttribute{
+				MarkdownDescription: "The stdout callback for `ansible_playbook_stdout`, e.g. `default` or `yaml`, to keep the output readable. The JSON artifact for `artifact_queries`, failures and reports is then written by the json callback to a temporary file instead. Defaults to the stdout callback of `ANSIBLE_STDOUT_CALLBACK` in the environment of the provider or of ansible.cfg, in that order, or else the json callback on stdout.",
+				Optional:            true,
+			},
+			"json_callback": schema.BoolAttribute{
+				MarkdownDescription: "Whether the json callback writes the artifact of the run. Set to false if it conflicts with other callbacks, so ansible runs with its own stdout callback only. Without the artifact, `artifact_queries` can only query `stderr`, failures are reported with the end of the output instead of per task and host, `stats` are empty, and `junit_report_path`, `diff_mode`, `max_failed_hosts`, `max_failed_percentage` and `ignore_unreachable` can't be used. Defaults to true.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"ansible_version_constraint": schema.StringAttribute{
+				MarkdownDescription: "Version constraint for ansible core, e.g. `>= 2.15, < 2.18`. The version reported by `ansible_playbook_binary --version` is checked during plan.",
+				Optional:            true,
+				Required:            false,
+			},
+			"stream_progress": schema.BoolAttribute{
+				MarkdownDescription: "Log play and task events while the playbook is running, instead of only once it finished. Visible with `TF_LOG=INFO` or higher. Uses an additional callback plugin, which is enabled through `ANSIBLE_CALLBACK_PLUGINS` and `ANSIBLE_CALLBACKS_ENABLED`. Not supported when the provider runs on Windows.",
+				Optional:            true,
+				Required:            false,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"junit_report_path": schema.StringAttribute{
+				MarkdownDescription: "Write a JUnit XML report of the run to this path, with one test case per task and host, so CI systems can display the results.",
+				Optional:            true,
+				Required:            false,
+			},
+			"log_path": schema.StringAttribute{
+				MarkdownDescription: "Write the log of ansible, `ANSIBLE_LOG_PATH`, of every run to this path, redacted, replacing the log of the previous run. Defaults to a log next to the artifacts in `artifact_dir` of the provider, if set. The path is exported as `log_file`.",
+				Optional:            true,
+			},
+			"artifact_upload_url": schema.StringAttribute{
+				MarkdownDescription: "Upload the JSON artifact, redacted, and the log of every run to this bucket and prefix, as `<id>/<start of the run>.json` and `.log`: `s3://bucket/prefix`, `gs://bucket/prefix` or `az://account/container/prefix`. The credentials come from the environment: `AWS_ACCESS_KEY_ID`, `AWS_SECRET_ACCESS_KEY`, `AWS_SESSION_TOKEN`, `AWS_REGION` and `AWS_ENDPOINT_URL_S3` for S3, `GOOGLE_OAUTH_ACCESS_TOKEN` for GCS and `AZURE_STORAGE_SAS_TOKEN` for Azure. Failed uploads are warnings.",
+				Optional:            true,
+			},
+			"diff_mode": schema.BoolAttribute{
+				MarkdownDescription: "Run the playbook with `--diff`, so tasks report the changes they make to files and templates.",
+				Optional:            true,
+				Required:            false,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"acceptable_exit_codes": schema.ListAttribute{
+				MarkdownDescription: "Exit codes of ansible-playbook that count as success. Failed tasks of a run with an acceptable exit code are reported as warnings. Defaults to `[0]`.",
+				Optional:            true,
+				Required:            false,
+				Computed:            true,
+				ElementType:         types.Int64Type,
+				Default:             listdefault.StaticValue(types.ListValueMust(types.Int64Type, []attr.Value{types.Int64Value(0)})),
+			},
+			"fail_fast": schema.BoolAttribute{
+				MarkdownDescription: "Whether a failed host aborts the run, instead of running the remaining tasks and plays on the other hosts, like `any_errors_fatal` of every play. Sets `ANSIBLE_ANY_ERRORS_FATAL`, so plays that set `any_errors_fatal` themselves keep their setting. Defaults to the setting of ansible.cfg. Can't be combined with `max_failed_hosts` and `max_failed_percentage`.",
+				Optional:            true,
+			},
+			"max_failed_hosts": schema.Int64Attribute{
+				MarkdownDescription: "Number of hosts that may fail or be unreachable without failing the resource. The failed hosts are reported as warnings instead.",
+				Optional:            true,
+				Required:            false,
+			},
+			"max_failed_percentage": schema.Float64Attribute{
+				MarkdownDescription: "Percentage of hosts that may fail or be unreachable without failing the resource. If `max_failed_hosts` is set as well, both thresholds must be met.",
+				Optional:            true,
+				Required:            false,
+			},
+			"ignore_unreachable": schema.BoolAttribute{
+				MarkdownDescription: "Report unreachable hosts as warnings instead of failing the resource. Failed tasks on reachable hosts still fail it. Unreachable hosts don't count towards `max_failed_hosts` and `max_failed_percentage` then.",
+				Optional:            true,
+				Required:            false,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"on_failure": schema.StringAttribute{
+				MarkdownDescription: "What to do when the playbook fails: `fail` (default) fails the apply, `warn` only reports the failure as warnings and `taint` reports warnings as well, but runs the playbook again on the next apply.",
+				Optional:            true,
+				Required:            false,
+				Computed:            true,
+				Default:             stringdefault.StaticString("fail"),
+			},
+			"destroy_playbook": schema.StringAttribute{
+				MarkdownDescription: "A playbook to run when the resource is destroyed, e.g. to deregister the hosts, with the inventory, variables and settings of the resource.",
+				Optional:            true,
+			},
+			"ignore_destroy_failure": schema.BoolAttribute{
+				MarkdownDescription: "Report a failure of the destroy run, of `destroy_playbook` or of `destroy_mode`, as warnings and destroy the resource anyway, so an unreachable host can't block `terraform destroy`. Defaults to false.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"destroy_mode": schema.StringAttribute{
+				MarkdownDescription: "What runs when the resource is destroyed: `destroy_playbook` (default) runs `destroy_playbook` if set, `rerun_with_var` runs the playbook again with the extra variable `ansible_provider_phase=destroy`, for playbooks that handle both converging and tearing down.",
+				Optional:            true,
+			},
+			"update_tags": schema.ListAttribute{
+				MarkdownDescription: "Tags passed with `--tags` when the resource is updated, so incremental applies only run a cheaper subset of the playbook. The full playbook still runs on create, on replacement and when the previous run failed.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"host_triggers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary trigger values keyed by host name, e.g. instance IDs. A change runs the playbook again, and an update only runs on the hosts whose trigger was added or changed, passed with `--limit`. It runs on all hosts if the playbook or variables changed as well, if the previous run failed, or if triggers were only removed.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"fact_cache_dir": schema.StringAttribute{
+				MarkdownDescription: "Cache facts in this directory with the jsonfile cache plugin and only gather them for hosts without cached facts, in plays that don't set `gather_facts`. Use the `fact_cache_dir` of an `ansible_facts` data source to gather them once for all playbooks. Use `fact_caching` for other caches.",
+				Optional:            true,
+			},
+			"fact_caching": schema.StringAttribute{
+				MarkdownDescription: "Cache facts with this cache plugin, `jsonfile` or `redis` (`community.general.redis`), and only gather them for hosts without cached facts, in plays that don't set `gather_facts`. Passed as `ANSIBLE_CACHE_PLUGIN`. Defaults to `jsonfile` if `fact_caching_connection` is set.",
+				Optional:            true,
+			},
+			"fact_caching_connection": schema.StringAttribute{
+				MarkdownDescription: "The connection of the fact cache, passed as `ANSIBLE_CACHE_PLUGIN_CONNECTION`: the directory of `jsonfile`, or `host:port:db[:password]` of `redis`. Can't be used with `fact_cache_dir`.",
+				Optional:            true,
+			},
+			"fact_caching_timeout": schema.Int64Attribute{
+				MarkdownDescription: "How long cached facts are valid, in seconds, passed as `ANSIBLE_CACHE_PLUGIN_TIMEOUT`. 0 keeps them forever. Defaults to the setting of ansible.cfg, usually 86400.",
+				Optional:            true,
+			},
+			"pipelining": schema.BoolAttribute{
+				MarkdownDescription: "Run modules through the SSH connection instead of copying them to the hosts first, which saves connections per task, passed as `ANSIBLE_PIPELINING`. Requires `requiretty` to be disabled in the sudoers of hosts using `become`. Defaults to the setting of ansible.cfg, usually false.",
+				Optional:            true,
+			},
+			"connection_plugin": schema.StringAttribute{
+				MarkdownDescription: "The connection plugin, passed with `-c`, e.g. `ssh`, `paramiko`, `local` or `community.docker.docker`. Defaults to ansible's default, `ssh`. Connection variables of the inventory still take precedence.",
+				Optional:            true,
+			},
+			"winrm": schema.SingleNestedAttribute{
+				MarkdownDescription: "Connect to Windows hosts with WinRM. The settings are passed as extra vars, `ansible_connection=winrm` and the `ansible_winrm_*` connection variables, so they apply to all hosts and override the inventory. Unset attributes keep ansible's defaults.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"port": schema.Int64Attribute{
+						MarkdownDescription: "`ansible_port`, usually 5986 for HTTPS and 5985 for HTTP.",
+						Optional:            true,
+					},
+					"scheme": schema.StringAttribute{
+						MarkdownDescription: "`ansible_winrm_scheme`, `http` or `https`.",
+						Optional:            true,
+					},
+					"transport": schema.StringAttribute{
+						MarkdownDescription: "`ansible_winrm_transport`, one of `basic`, `certificate`, `ntlm`, `kerberos` and `credssp`.",
+						Optional:            true,
+					},
+					"server_cert_validation": schema.StringAttribute{
+						MarkdownDescription: "`ansible_winrm_server_cert_validation`, `validate` or `ignore`.",
+						Optional:            true,
+					},
+					"ca_cert_path": schema.StringAttribute{
+						MarkdownDescription: "`ansible_winrm_ca_trust_path`, a CA bundle to validate the server certificates with.",
+						Optional:            true,
+					},
+					"user": schema.StringAttribute{
+						MarkdownDescription: "`ansible_user`, e.g. `Administrator` or `user@DOMAIN.COM` for Kerberos.",
+						Optional:            true,
+					},
+					"password": schema.StringAttribute{
+						MarkdownDescription: "`ansible_password`. Redacted from the output.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"kerberos_delegation": schema.BoolAttribute{
+						MarkdownDescription: "`ansible_winrm_kerberos_delegation`, to forward the Kerberos ticket to the host.",
+						Optional:            true,
+					},
+					"message_encryption": schema.StringAttribute{
+						MarkdownDescription: "`ansible_winrm_message_encryption`, one of `auto`, `always` and `never`.",
+						Optional:            true,
+					},
+					"read_timeout": schema.Int64Attribute{
+						MarkdownDescription: "`ansible_winrm_read_timeout_sec`, in seconds.",
+						Optional:            true,
+					},
+					"operation_timeout": schema.Int64Attribute{
+						MarkdownDescription: "`ansible_winrm_operation_timeout_sec`, in seconds. Must be lower than `read_timeout`.",
+						Optional:            true,
+					},
+				},
+			},
+			"ssh": schema.SingleNestedAttribute{
+				MarkdownDescription: "Tune the SSH connections, e.g. to reuse them for longer in runs against many hosts. The settings are passed as extra vars, `ansible_ssh_args`, `ansible_control_path_dir` and `ansible_ssh_timeout`, so they apply to all hosts and override the inventory and `ssh_args` of ansible.cfg. Unset attributes keep ansible's defaults.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"control_master": schema.StringAttribute{
+						MarkdownDescription: "The `ControlMaster` option, `auto`, `autoask`, `yes`, `ask` or `no` to not share connections. Defaults to `auto`.",
+						Optional:            true,
+					},
+					"control_persist": schema.StringAttribute{
+						MarkdownDescription: "The `ControlPersist` option, how long shared connections stay open after the last use, like `10m`, or `yes` to keep them open. Defaults to `60s`.",
+						Optional:            true,
+					},
+					"control_path_dir": schema.StringAttribute{
+						MarkdownDescription: "The directory of the control sockets, `ansible_control_path_dir`. Defaults to a directory of the run below `~/.ansible/cp`, unless the environment or ansible.cfg configure one, so the control masters are stopped with `ssh -O exit` when the run ends or is canceled. Use a short path if the socket paths get too long.",
+						Optional:            true,
+					},
+					"timeout": schema.Int64Attribute{
+						MarkdownDescription: "The connection timeout, `ansible_ssh_timeout`, in seconds. Defaults to 10.",
+						Optional:            true,
+					},
+				},
+			},
+			"bastion": schema.SingleNestedAttribute{
+				MarkdownDescription: "Reach the hosts through a bastion, or jump host, e.g. for hosts in a private subnet. It's passed as `ansible_ssh_common_args`, with `ProxyJump`, or a `ProxyCommand` if `private_key` is set, so it applies to all hosts and overrides `ssh_common_args` of the inventory.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"host": schema.StringAttribute{
+						MarkdownDescription: "Address of the bastion.",
+						Required:            true,
+					},
+					"user": schema.StringAttribute{
+						MarkdownDescription: "User to log in to the bastion as. Defaults to the user of the ssh configuration.",
+						Optional:            true,
+					},
+					"port": schema.Int64Attribute{
+						MarkdownDescription: "SSH port of the bastion. Defaults to the port of the ssh configuration, usually 22.",
+						Optional:            true,
+					},
+					"private_key": schema.StringAttribute{
+						MarkdownDescription: "The private key to log in to the bastion with. Not a path, the contents. Defaults to the keys of the ssh configuration and agent. Redacted from the output.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+				},
+			},
+			"known_hosts_entries": schema.ListAttribute{
+				MarkdownDescription: "known_hosts lines like `host.example.com ssh-ed25519 AAAA...`, e.g. with the host keys from cloud instance metadata. They're written to a temporary known_hosts file, which SSH uses instead of `~/.ssh/known_hosts` with strict host key checking, for the hosts and `bastion`. Hosts without an entry can't be connected to.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"container_image": schema.StringAttribute{
+				MarkdownDescription: "Run `ansible-playbook` in a container of this image instead of on the host, so the runner only needs docker or podman. The working directory, the directories of the playbook and var files and the temporary files of the run are mounted at the same paths, `~/.ssh` is mounted read-only at `/root/.ssh` and the SSH agent is forwarded. `ansible_playbook_binary` is the entrypoint in the container. The container is killed if the operation is canceled or times out. Progress streaming isn't supported in containers.",
+				Optional:            true,
+			},
+			"container_engine": schema.StringAttribute{
+				MarkdownDescription: "The container engine to run `container_image` with, `docker` (default) or `podman`.",
+				Optional:            true,
+			},
+			"container_volumes": schema.ListAttribute{
+				MarkdownDescription: "Additional volumes to mount in the container, as `host_path:container_path[:options]`, e.g. for keys or roles outside the project.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"preview_hosts": schema.BoolAttribute{
+				MarkdownDescription: "List the hosts the playbook will run on with `ansible-playbook --list-hosts` during plan, and show them in `matched_hosts`. Defaults to false.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"predict_changes": schema.BoolAttribute{
+				MarkdownDescription: "Run the playbook with `--check --diff` during plan, and show the tasks that would change per host in `predicted_changes`. Only use it with playbooks that support check mode. Defaults to false.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"lock_key": schema.StringAttribute{
+				MarkdownDescription: "Runs of playbooks with the same lock key don't run in parallel, e.g. to avoid package manager lock conflicts on shared hosts. Defaults to a hash of the inventory. Set to `\"\"` to disable locking.",
+				Optional:            true,
+				Required:            false,
+			},
+			"notify_webhook": schema.SingleNestedAttribute{
+				MarkdownDescription: "POST a summary of every run to a webhook when it completes, e.g. to notify chat or incident tooling. A failure to notify is reported as a warning.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"url": schema.StringAttribute{
+						MarkdownDescription: "The URL to POST to.",
+						Required:            true,
+					},
+					"headers": schema.MapAttribute{
+						MarkdownDescription: "Headers of the request, e.g. for authentication. `Content-Type` defaults to `application/json`.",
+						Optional:            true,
+						Sensitive:           true,
+						ElementType:         types.StringType,
+					},
+					"payload_template": schema.StringAttribute{
+						MarkdownDescription: "A Go template of the request body. It has the fields `.Id`, `.Playbook`, `.Status` (`ok` or `failed`), `.ExitCode`, `.Stats` and `.FailedTasks`, and the function `json` to encode a value. Defaults to all fields as JSON.",
+						Optional:            true,
+					},
+				},
+			},
 			"ansible_playbook_binary": schema.StringAttribute{
 				Required: false,
 				Optional: true,
 				Computed: true,
 				Default:  stringdefault.StaticString("ansible-playbook"),
 			},
+			"collections_lock_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a requirements file with the exact collection versions, e.g. `collections: [{name: community.general, version: \"8.6.0\"}]`. The installed collections, as listed by `ansible-galaxy collection list` next to `ansible_playbook_binary`, are compared with it during plan and before every run, which fails with the differences if they don't match.",
+				Optional:            true,
+			},
+			"requirements_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a requirements file of collections to install with `ansible-galaxy collection install` before every run. They're installed to the collections cache of the provider once per content of the file, e.g. `galaxy.collections_cache_dir`, so resources with the same requirements don't download them again. Can't be combined with `container_image`.",
+				Optional:            true,
+			},
+			"max_output_size": schema.Int64Attribute{
+				MarkdownDescription: "Maximum size in bytes of `ansible_playbook_stdout` and `ansible_playbook_stderr` as stored in the state. Larger outputs are truncated, keeping the beginning and the end. Set to 0 to disable truncation.",
+				Optional:            true,
+				Required:            false,
+				Computed:            true,
+				Default:             int64default.StaticInt64(1048576),
+			},
+			"stdout_spill_threshold": schema.Int64Attribute{
+				MarkdownDescription: "Size in bytes beyond which the stdout of `ansible-playbook` is written to a temporary file instead of being kept in memory during the run. Failure analysis and `artifact_queries` then read the file, and only the part kept by `max_output_size` is read back into memory for the state. Set to 0 to always keep stdout in memory.",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(67108864),
+			},
+			"compress_output": schema.BoolAttribute{
+				MarkdownDescription: "Whether to store `ansible_playbook_stdout` and `ansible_playbook_stderr` gzipped and base64 encoded, to keep the state small for large runs. Decode them with `provider::ansible::decompress_output`. `max_output_size` applies to the uncompressed output.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"strip_ansi": schema.BoolAttribute{
+				MarkdownDescription: "Whether to remove ANSI escape sequences like colors from `ansible_playbook_stdout`, `ansible_playbook_stderr` and diagnostics, e.g. if `ANSIBLE_FORCE_COLOR` is set. Defaults to true.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"environment": schema.MapAttribute{
+				MarkdownDescription: "Environment variables of the ansible commands of the resource. They override the `environment` of the provider and the environment of the provider process, and are overridden by the variables the provider sets for a run, except for `ANSIBLE_STDOUT_CALLBACK`, `ANSIBLE_CALLBACK_PLUGINS`, `ANSIBLE_CALLBACKS_ENABLED` and `ANSIBLE_COLLECTIONS_PATH`, which are respected or extended.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
 			"extra_vars": schema.MapAttribute{
 				Required:    false,
 				Optional:    true,
 				ElementType: types.StringType,
 				Description: "A map of additional variables as: { keyString = \"value-1\", keyList = [\"list-value-1\", \"list-value-2\"], ... }.",
 			},
+			"group_vars": schema.MapAttribute{
+				Optional:            true,
+				ElementType:         types.MapType{ElemType: types.StringType},
+				MarkdownDescription: "Variables per group, e.g. connection settings, as `{ web = { ansible_user = \"deploy\" } }`. They're passed as a second inventory source, which ansible merges into the groups of `inventory`, overriding its variables of the same groups. Use `all` for variables of all hosts.",
+			},
+			"host_vars": schema.MapAttribute{
+				Optional:            true,
+				ElementType:         types.MapType{ElemType: types.StringType},
+				MarkdownDescription: "Variables per host, e.g. the address, port and user of hosts created by other resources, as `{ web1 = { ansible_host = aws_instance.web1.private_ip } }`. They're passed with `group_vars` as a second inventory source, overriding the variables of the same hosts in `inventory`. Hosts that aren't in `inventory` are added to the `all` group.",
+			},
+			"sensitive_extra_vars": schema.MapAttribute{
+				Required:    false,
+				Optional:    true,
+				Sensitive:   true,
+				ElementType: types.StringType,
+				Description: "Like extra_vars, but for secret values. They're passed in a temporary file only readable by the user instead of on the command line, and their values are redacted from the stored output, the artifact query results and the diagnostics.",
+			},
+			"var_files": schema.ListAttribute{
+				Required:    false,
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Paths to variable files, e.g. vault encrypted ones, passed as extra vars. Their content is part of the playbook hash, so editing them triggers a new run.",
+			},
+			"vault_ids": schema.MapNestedAttribute{
+				MarkdownDescription: "Vault IDs to decrypt vaulted files and variables with, keyed by label and passed as `--vault-id label@source`. Each needs exactly one source of its password.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"password_file": schema.StringAttribute{
+							MarkdownDescription: "Path to a file with the password, or to an executable vault password client script.",
+							Optional:            true,
+						},
+						"password_env": schema.StringAttribute{
+							MarkdownDescription: "Name of an environment variable of the provider with the password.",
+							Optional:            true,
+						},
+						"password": schema.StringAttribute{
+							MarkdownDescription: "The password itself. Redacted from the output.",
+							Optional:            true,
+							Sensitive:           true,
+						},
+					},
+				},
+			},
+			"hash_include": schema.ListAttribute{
+				Required:            false,
+				Optional:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "Globs of the files in roles, `group_vars` and `host_vars` that feed into `playbook_hash`. Defaults to all files. Globs without a `/` match file names at any depth, all others match the path relative to the playbook directory. `**` matches any number of directories.",
+			},
+			"hash_exclude": schema.ListAttribute{
+				Required:            false,
+				Optional:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "Globs of files and directories to leave out of `playbook_hash`, e.g. `[\".git\", \"molecule\"]`. Matched the same way as `hash_include`, and take precedence over it.",
+			},
+			"redact": schema.ListAttribute{
+				Required:    false,
+				Optional:    true,
+				Sensitive:   true,
+				ElementType: types.StringType,
+				Description: "Strings to replace with \"********\" in the stored output, the artifact query results and the diagnostics.",
+			},
+			"redact_patterns": schema.ListAttribute{
+				Optional:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "Regular expressions whose matches are replaced with `********` like `redact`, in everything the provider stores or reports: the output, diffs, artifact query results, diagnostics, logs, artifacts and notifications, e.g. `ghp_[A-Za-z0-9]+` for tokens or `[a-z0-9-]+\\.internal\\.example\\.com` for host names.",
+			},
 			// From https://github.com/marshallford/terraform-provider-ansible/blob/2bbba6be0a59dd5b03e46e339a42032014662f67/internal/provider/navigator_run_resource.go#L429C1-L445C6
 			"artifact_queries": schema.MapNestedAttribute{
 				Description:         "Query the playbook artifact with JSONPath. The playbook artifact - the JSON output as generated by the JSON Callback Plugin - contains detailed information about every play and task from the playbook run.",
@@ -131,8 +644,22 @@ func (r *PlaybookResource) Schema(ctx context.Context, req resource.SchemaReques
 				NestedObject: schema.NestedAttributeObject{
 					Attributes: map[string]schema.Attribute{
 						"jsonpath": schema.StringAttribute{
-							Description: "JSONPath expression.",
-							Required:    true,
+							Description: "JSONPath expression. Exactly one of jsonpath, jq and regex must be set.",
+							Optional:    true,
+						},
+						"jq": schema.StringAttribute{
+							MarkdownDescription: "[jq](https://jqlang.github.io/jq/manual/) expression, for filters and transformations JSONPath can't express. Multiple results are separated by newlines. Exactly one of jsonpath, jq and regex must be set.",
+							Optional:            true,
+						},
+						"regex": schema.StringAttribute{
+							MarkdownDescription: "[Regular expression](https://github.com/google/re2/wiki/Syntax) to extract values from text, e.g. warnings or lines printed by callback plugins on stderr. Every match is a result, the first capture group if there is one, the whole match otherwise. Artifacts over 64 MiB can't be queried with a regex. Exactly one of jsonpath, jq and regex must be set.",
+							Optional:            true,
+						},
+						"source": schema.StringAttribute{
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString("artifact"),
+							MarkdownDescription: "The output to query: `artifact`, the JSON output of the json callback, or `stderr`. `jsonpath` and `jq` queries of `stderr` require it to be JSON.",
 						},
 						"json_output": schema.BoolAttribute{
 							Optional:    true,
@@ -148,13 +675,29 @@ func (r *PlaybookResource) Schema(ctx context.Context, req resource.SchemaReques
 							Default:     booldefault.StaticBool(false),
 							Description: "Fail the resource, if there is no key specified by the JSON path",
 						},
+						"type": schema.StringAttribute{
+							Optional:            true,
+							Computed:            true,
+							Required:            false,
+							Default:             stringdefault.StaticString("string"),
+							MarkdownDescription: "Expected type of the result: `string`, `number`, `bool`, `list` or `object`. The typed result is exposed in `artifact_values`.",
+						},
 						"result": schema.StringAttribute{
 							Description: "Result of the query. Result may be empty if a field or map key cannot be located.",
 							Computed:    true,
 						},
+						"results": schema.ListAttribute{
+							Description: "Every value matched by the query as a separate element, formatted the same way as in result.",
+							Computed:    true,
+							ElementType: types.StringType,
+						},
 					},
 				},
 			},
+			"artifact_values": schema.DynamicAttribute{
+				Computed:            true,
+				MarkdownDescription: "The results of `artifact_queries`, keyed by query name and converted to the `type` declared by the query.",
+			},
 			"playbook_hash": schema.StringAttribute{
 				Computed:    true,
 				Description: "Hash of playbook.",
@@ -167,6 +710,53 @@ func (r *PlaybookResource) Schema(ctx context.Context, req resource.SchemaReques
 				Computed:    true,
 				Description: "An ansible-playbook CLI stderr output.",
 			},
+			"diffs": schema.MapAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "The diffs reported by the tasks with `diff_mode`, keyed by task name, as unified diffs headed by the host name. Tasks without changes are omitted.",
+			},
+			"log_file": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The path of the log of the last run, `log_path` or the log in `artifact_dir` of the provider. Null if neither is set.",
+			},
+			"artifact_object_url": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The URL of the artifact of the last run uploaded to `artifact_upload_url`. Null if it wasn't uploaded.",
+			},
+			"log_object_url": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The URL of the log of the last run uploaded to `artifact_upload_url`. Null if it wasn't uploaded, e.g. without `log_file`.",
+			},
+			"effective_environment": schema.MapAttribute{
+				Computed:            true,
+				Sensitive:           true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "The `ANSIBLE_*` variables of the last run, merged from `environment`, the `environment` of the provider and the environment of the provider process. Values of variables like tokens, passwords and connection strings are masked, and the attribute is sensitive, as others may contain secrets as well. The variables the provider sets for a run aren't included.",
+			},
+			"unreachable_hosts": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Hosts that were unreachable during the last run.",
+			},
+			"matched_hosts": schema.ListAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "With `preview_hosts`, the hosts matched by the plays of the playbook, sorted. Known at plan time unless the inventory or variables aren't.",
+			},
+			"predicted_changes": schema.MapAttribute{
+				Computed:            true,
+				ElementType:         types.ListType{ElemType: types.StringType},
+				MarkdownDescription: "With `predict_changes`, the tasks that would change per host, according to a check mode run during the plan of the last run. Null if the inventory or variables weren't known during plan.",
+			},
+			"failed": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether the last run failed. Only ever true with `on_failure` set to `warn` or `taint`.",
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+				Delete: true,
+			}),
 			"id": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Identifier",
@@ -183,6 +773,17 @@ func (r *PlaybookResource) Configure(ctx context.Context, req resource.Configure
 	if req.ProviderData == nil {
 		return
 	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.providerData = providerData
 }
 
 func (r *PlaybookResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -196,7 +797,16 @@ func (r *PlaybookResource) Create(ctx context.Context, req resource.CreateReques
 
 	data.Id = types.StringValue(uuid.New().String())
 
-	Execute(ctx, &resp.Diagnostics, &data)
+	createTimeout, diags := data.Timeouts.Create(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	runPlaybook(ctx, &resp.Diagnostics, &data, r.providerData, RunOptions{})
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -205,6 +815,119 @@ func (r *PlaybookResource) Create(ctx context.Context, req resource.CreateReques
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// Apply the configured timeout of an operation. Without one, runs aren't
+// limited, as before timeouts could be configured.
+func contextWithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+var onFailureValues = []string{"fail", "warn", "taint"}
+
+const destroyModeRerunWithVar = "rerun_with_var"
+
+var destroyModes = []string{"destroy_playbook", destroyModeRerunWithVar}
+
+// The extra variable telling the playbook that it runs to tear down with
+// destroy_mode rerun_with_var
+const destroyPhaseVar = "ansible_provider_phase"
+
+// Execute the playbook and apply on_failure: unless it's "fail", errors are
+// reported as warnings, so the resource is still stored in the state.
+func runPlaybook(ctx context.Context, diags *diag.Diagnostics, data *PlaybookResourceModel, providerData *ProviderData, options RunOptions) {
+	// The runner may differ from the one of the plan, which may also not have
+	// had ansible
+	validateAnsibleVersion(ctx, data, providerData, diags)
+	verifyCollectionsLock(ctx, data, providerData, diags)
+	if diags.HasError() {
+		return
+	}
+
+	setMatchedHosts(ctx, diags, data, providerData)
+	setPredictedChanges(data)
+
+	var runDiags diag.Diagnostics
+	Execute(ctx, &runDiags, data, providerData, options)
+	data.Failed = types.BoolValue(runDiags.HasError())
+
+	onFailure := data.OnFailure.ValueString()
+	if !runDiags.HasError() || onFailure == "fail" {
+		diags.Append(runDiags...)
+		return
+	}
+
+	appendAsWarnings(diags, runDiags)
+	data.ClearUnknownOutputs(ctx, diags)
+
+	if onFailure == "taint" {
+		diags.AddWarning("Ansible playbook failed and will run again on the next apply", "")
+	}
+}
+
+// Append diagnostics, with errors turned into warnings
+func appendAsWarnings(diags *diag.Diagnostics, newDiags diag.Diagnostics) {
+	for _, newDiag := range newDiags {
+		if newDiag.Severity() == diag.SeverityError {
+			diags.AddWarning(newDiag.Summary(), newDiag.Detail())
+		} else {
+			diags.Append(newDiag)
+		}
+	}
+}
+
+// Replace the outputs a failed run left unknown with null values, as the
+// state must not contain unknown values.
+func (m *PlaybookResourceModel) ClearUnknownOutputs(ctx context.Context, diags *diag.Diagnostics) {
+	if m.AnsiblePlaybookStdout.IsUnknown() {
+		m.AnsiblePlaybookStdout = types.StringNull()
+	}
+	if m.AnsiblePlaybookStderr.IsUnknown() {
+		m.AnsiblePlaybookStderr = types.StringNull()
+	}
+	if m.Diffs.IsUnknown() {
+		m.Diffs = types.MapNull(types.StringType)
+	}
+	if m.LogFile.IsUnknown() {
+		m.LogFile = types.StringNull()
+	}
+	if m.ArtifactObjectURL.IsUnknown() {
+		m.ArtifactObjectURL = types.StringNull()
+	}
+	if m.LogObjectURL.IsUnknown() {
+		m.LogObjectURL = types.StringNull()
+	}
+	if m.EffectiveEnvironment.IsUnknown() {
+		m.EffectiveEnvironment = types.MapNull(types.StringType)
+	}
+	if m.UnreachableHosts.IsUnknown() {
+		m.UnreachableHosts = types.ListNull(types.StringType)
+	}
+	if m.ArtifactValues.IsUnknown() {
+		m.ArtifactValues = types.DynamicNull()
+	}
+
+	var queriesModel map[string]ArtifactQueryModel
+	diags.Append(m.ArtifactQueries.ElementsAs(ctx, &queriesModel, false)...)
+
+	for name, model := range queriesModel {
+		if model.Result.IsUnknown() {
+			model.Result = types.StringNull()
+		}
+		if model.Results.IsUnknown() {
+			model.Results = types.ListNull(types.StringType)
+		}
+		queriesModel[name] = model
+	}
+
+	if queriesModel != nil {
+		newQueriesModel, newDiags := types.MapValueFrom(ctx, types.ObjectType{AttrTypes: ArtifactQueryModel{}.AttrTypes()}, queriesModel)
+		diags.Append(newDiags...)
+		m.ArtifactQueries = newQueriesModel
+	}
+}
+
 func (r *PlaybookResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 }
 
@@ -217,12 +940,45 @@ func (r *PlaybookResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	Execute(ctx, &resp.Diagnostics, &data)
+	updateTimeout, diags := data.Timeouts.Update(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	var state PlaybookResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
+	var options RunOptions
+	// A failed run is repeated in full, as it may have stopped before the
+	// tagged tasks
+	if !state.Failed.ValueBool() {
+		resp.Diagnostics.Append(data.UpdateTags.ElementsAs(ctx, &options.Tags, false)...)
+	}
+
+	triggeredHosts, limited, diags := TriggeredHosts(ctx, &state, &data)
+	resp.Diagnostics.Append(diags...)
+	if limited {
+		options.Limit = triggeredHosts
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	runPlaybook(ctx, &resp.Diagnostics, &data, r.providerData, options)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	// The hash includes all options after a run
+	if resp.Private != nil {
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, importedPrivateKey, nil)...)
+	}
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
@@ -231,9 +987,165 @@ func (r *PlaybookResource) Delete(ctx context.Context, req resource.DeleteReques
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
 
+	rerun := data.DestroyMode.ValueString() == destroyModeRerunWithVar
+	if resp.Diagnostics.HasError() || (data.DestroyPlaybook.IsNull() && !rerun) {
+		return
+	}
+
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, 0)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	ctx, cancel := contextWithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if rerun {
+		// Run the playbook again, telling it to tear down
+		var extraVars map[string]string
+		resp.Diagnostics.Append(data.ExtraVars.ElementsAs(ctx, &extraVars, false)...)
+		if extraVars == nil {
+			extraVars = map[string]string{}
+		}
+		extraVars[destroyPhaseVar] = "destroy"
+		extraVarsValue, diags := types.MapValueFrom(ctx, types.StringType, extraVars)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		data.ExtraVars = extraVarsValue
+	} else {
+		// Run the destroy playbook with everything else of the resource
+		data.Playbook = data.DestroyPlaybook
+		data.Plays = types.ListNull(types.StringType)
+	}
+
+	var runDiags diag.Diagnostics
+	Execute(ctx, &runDiags, &data, r.providerData, RunOptions{})
+	if !runDiags.HasError() || !data.IgnoreDestroyFailure.ValueBool() {
+		resp.Diagnostics.Append(runDiags...)
+		return
+	}
+
+	appendAsWarnings(&resp.Diagnostics, runDiags)
+	resp.Diagnostics.AddWarning("The destroy run failed, the resource was destroyed anyway", "")
+}
+
+func (r *PlaybookResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config PlaybookResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.AnsibleVersionConstraint.IsNull() && !config.AnsibleVersionConstraint.IsUnknown() {
+		_, err := version.NewConstraint(config.AnsibleVersionConstraint.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("ansible_version_constraint"), "Invalid version constraint", err.Error())
+		}
+	}
+
+	if !config.MaxFailedHosts.IsNull() && !config.MaxFailedHosts.IsUnknown() && config.MaxFailedHosts.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_failed_hosts"), "Invalid failure threshold",
+			"max_failed_hosts must not be negative.")
+	}
+
+	if !config.MaxFailedPercentage.IsNull() && !config.MaxFailedPercentage.IsUnknown() {
+		if percentage := config.MaxFailedPercentage.ValueFloat64(); percentage < 0 || percentage > 100 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_failed_percentage"), "Invalid failure threshold",
+				"max_failed_percentage must be between 0 and 100.")
+		}
+	}
+
+	validateOneOf(path.Root("destroy_mode"), config.DestroyMode, destroyModes, &resp.Diagnostics)
+	if config.DestroyMode.ValueString() == destroyModeRerunWithVar && !config.DestroyPlaybook.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("destroy_mode"), "Conflicting destroy_mode",
+			"rerun_with_var runs the playbook itself when the resource is destroyed, so destroy_playbook must not be set.")
+	}
+
+	if config.FailFast.ValueBool() && (!config.MaxFailedHosts.IsNull() || !config.MaxFailedPercentage.IsNull()) {
+		resp.Diagnostics.AddAttributeError(path.Root("fail_fast"), "Conflicting fail_fast",
+			"fail_fast aborts the run on the first failed host, so max_failed_hosts and max_failed_percentage can't be used with it.")
+	}
+
+	if !config.OnFailure.IsNull() && !config.OnFailure.IsUnknown() && !slices.Contains(onFailureValues, config.OnFailure.ValueString()) {
+		resp.Diagnostics.AddAttributeError(path.Root("on_failure"), "Invalid on_failure",
+			fmt.Sprintf("on_failure must be one of %s.", strings.Join(onFailureValues, ", ")))
+	}
+
+	for _, attribute := range []string{"hash_include", "hash_exclude"} {
+		var globs []types.String
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(attribute), &globs)...)
+
+		for i, glob := range globs {
+			if glob.IsNull() || glob.IsUnknown() {
+				continue
+			}
+			if _, err := newHashPatterns([]string{glob.ValueString()}); err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root(attribute).AtListIndex(i), "Invalid glob", err.Error())
+			}
+		}
+	}
+
+	validateArtifactQueries(ctx, config.ArtifactQueries, &resp.Diagnostics)
+	validateJSONCallback(ctx, &config, &resp.Diagnostics)
+	RedactPatterns(ctx, config.RedactPatterns, &resp.Diagnostics)
+	validateFactCaching(&config, &resp.Diagnostics)
+	validateVaultIds(ctx, config.VaultIds, &resp.Diagnostics)
+	validateWinRM(ctx, config.WinRM, &resp.Diagnostics)
+	if !config.WinRM.IsNull() && !config.ConnectionPlugin.IsNull() && !config.ConnectionPlugin.IsUnknown() && config.ConnectionPlugin.ValueString() != "winrm" {
+		resp.Diagnostics.AddAttributeError(path.Root("connection_plugin"), "Conflicting connection",
+			"winrm sets the connection to winrm for all hosts, so connection_plugin must be unset or winrm.")
+	}
+	validateSSH(ctx, config.SSH, &resp.Diagnostics)
+	validateBastion(ctx, config.Bastion, &resp.Diagnostics)
+	validateKnownHostsEntries(ctx, config.KnownHostsEntries, &resp.Diagnostics)
+	if !config.RequirementsFile.IsNull() && !config.ContainerImage.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("requirements_file"), "Conflicting requirements_file",
+			"The collections cache isn't mounted into containers, install the requirements into container_image instead.")
+	}
+	validateOneOf(path.Root("container_engine"), config.ContainerEngine, containerEngines, &resp.Diagnostics)
+	validateOneOf(path.Root("inventory_format"), config.InventoryFormat, inventoryFormats, &resp.Diagnostics)
+}
+
+func validateArtifactQueries(ctx context.Context, artifactQueries types.Map, diags *diag.Diagnostics) {
+	var queriesModel map[string]ArtifactQueryModel
+	diags.Append(artifactQueries.ElementsAs(ctx, &queriesModel, false)...)
+
+	for name, model := range queriesModel {
+		if !model.Source.IsNull() && !model.Source.IsUnknown() && !slices.Contains(artifactQuerySources, model.Source.ValueString()) {
+			diags.AddAttributeError(path.Root("artifact_queries").AtMapKey(name).AtName("source"), "Invalid artifact query source",
+				fmt.Sprintf("Source must be one of %s.", strings.Join(artifactQuerySources, ", ")))
+		}
+
+		if !model.Regex.IsNull() && !model.Regex.IsUnknown() {
+			if _, err := regexp.Compile(model.Regex.ValueString()); err != nil {
+				diags.AddAttributeError(path.Root("artifact_queries").AtMapKey(name).AtName("regex"), "Invalid regular expression", err.Error())
+			}
+		}
+
+		if model.JSONPath.IsUnknown() || model.JQ.IsUnknown() || model.Regex.IsUnknown() {
+			continue
+		}
+
+		expressions := 0
+		for _, expression := range []types.String{model.JSONPath, model.JQ, model.Regex} {
+			if !expression.IsNull() {
+				expressions++
+			}
+		}
+		if expressions != 1 {
+			diags.AddAttributeError(path.Root("artifact_queries").AtMapKey(name), "Invalid artifact query",
+				"Exactly one of jsonpath, jq and regex must be set.")
+		}
+
+		if !model.Type.IsNull() && !model.Type.IsUnknown() && !slices.Contains(artifactQueryTypes, model.Type.ValueString()) {
+			diags.AddAttributeError(path.Root("artifact_queries").AtMapKey(name).AtName("type"), "Invalid artifact query type",
+				fmt.Sprintf("Type must be one of %s.", strings.Join(artifactQueryTypes, ", ")))
+		}
+	}
 }
 
 func (r *PlaybookResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
@@ -257,48 +1169,352 @@ func (r *PlaybookResource) ModifyPlan(ctx context.Context, req resource.ModifyPl
 		resp.Plan.SetAttribute(ctx, path.Root("ansible_playbook_stdout"), types.StringValue(""))
 	}
 
-	currentHash, err := calculatePlaybookHash(config.Playbook.ValueString())
+	validateReadableFile(path.Root("playbook"), config.Playbook, &resp.Diagnostics)
+	validatePlays(ctx, config, &resp.Diagnostics)
+	validateReadableFile(path.Root("destroy_playbook"), config.DestroyPlaybook, &resp.Diagnostics)
+	validateWritableDirectory(path.Root("junit_report_path"), config.JUnitReportPath, &resp.Diagnostics)
+	validateWritableDirectory(path.Root("log_path"), config.LogPath, &resp.Diagnostics)
+	if !config.ArtifactUploadURL.IsNull() && !config.ArtifactUploadURL.IsUnknown() {
+		if _, err := ParseObjectStorageURL(config.ArtifactUploadURL.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("artifact_upload_url"), "Invalid artifact upload URL", err.Error())
+		}
+	}
+	validateReadableFile(path.Root("collections_lock_file"), config.CollectionsLockFile, &resp.Diagnostics)
+	validateReadableFile(path.Root("requirements_file"), config.RequirementsFile, &resp.Diagnostics)
+	ansibleFound := ansibleFoundAtPlan(ctx, plan, r.providerData, &resp.Diagnostics)
+	if ansibleFound {
+		validateAnsibleVersion(ctx, plan, r.providerData, &resp.Diagnostics)
+	}
+	if ansibleFound && !resp.Diagnostics.HasError() {
+		verifyCollectionsLock(ctx, plan, r.providerData, &resp.Diagnostics)
+	}
+
+	var varFiles []types.String
+	resp.Diagnostics.Append(config.VarFiles.ElementsAs(ctx, &varFiles, false)...)
+
+	hashOptions := PlaybookHashOptions{}
+	for i, varFile := range varFiles {
+		validateReadableFile(path.Root("var_files").AtListIndex(i), varFile, &resp.Diagnostics)
+		if !varFile.IsUnknown() {
+			hashOptions.VarFiles = append(hashOptions.VarFiles, varFile.ValueString())
+		}
+	}
+
+	var hashInclude, hashExclude []string
+	resp.Diagnostics.Append(config.HashInclude.ElementsAs(ctx, &hashInclude, false)...)
+	resp.Diagnostics.Append(config.HashExclude.ElementsAs(ctx, &hashExclude, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	hashOptions.Include = hashInclude
+	hashOptions.Exclude = hashExclude
+
+	currentHash, err := r.hash(config.Playbook.ValueString(), hashOptions)
 	if err != nil {
 		resp.Diagnostics.AddError("Error Calculating Playbook Hash", err.Error())
 		return
 	}
 
+	if !config.PreviewHosts.ValueBool() {
+		resp.Plan.SetAttribute(ctx, path.Root("matched_hosts"), types.ListNull(types.StringType))
+	} else if ansibleFound && planRunInputsKnown(plan) {
+		hosts, diags := ListHosts(ctx, plan, r.providerData)
+		resp.Diagnostics.Append(diags...)
+		if diags.HasError() {
+			return
+		}
+		matchedHosts, diags := types.ListValueFrom(ctx, types.StringType, hosts)
+		resp.Diagnostics.Append(diags...)
+		resp.Plan.SetAttribute(ctx, path.Root("matched_hosts"), matchedHosts)
+	} else {
+		// Listed during apply
+		resp.Plan.SetAttribute(ctx, path.Root("matched_hosts"), types.ListUnknown(types.StringType))
+	}
+
+	if !config.PredictChanges.ValueBool() {
+		resp.Plan.SetAttribute(ctx, path.Root("predicted_changes"), types.MapNull(predictedChangesType.ElemType))
+	} else if state != nil {
+		// Keep the prediction of the last run, unless the playbook runs again
+		resp.Plan.SetAttribute(ctx, path.Root("predicted_changes"), state.PredictedChanges)
+	}
+
 	planHash := types.StringValue(currentHash)
 	resp.Plan.SetAttribute(ctx, path.Root("playbook_hash"), planHash)
-	if state == nil || !plan.Playbook.Equal(state.Playbook) || !plan.Inventory.Equal(state.Inventory) ||
-		!plan.ExtraVars.Equal(state.ExtraVars) || !planHash.Equal(state.PlaybookHash) {
+
+	// The hash of an imported resource was calculated without var_files,
+	// hash_include and hash_exclude, which aren't known during import. It
+	// still counts as unchanged if the files didn't change since the import.
+	var stateHash, stateVarFiles attr.Value
+	if state != nil {
+		stateHash, stateVarFiles = state.PlaybookHash, state.VarFiles
+		imported, diags := req.Private.GetKey(ctx, importedPrivateKey)
+		resp.Diagnostics.Append(diags...)
+		if len(imported) > 0 {
+			importHash, err := r.hash(state.Playbook.ValueString(), PlaybookHashOptions{})
+			if err == nil && importHash == state.PlaybookHash.ValueString() {
+				stateHash, stateVarFiles = planHash, plan.VarFiles
+			}
+		}
+	}
+
+	if state == nil || !plan.Playbook.Equal(state.Playbook) || !plan.Plays.Equal(state.Plays) || !plan.Limit.Equal(state.Limit) || !plan.Inventory.Equal(state.Inventory) ||
+		!plan.ExtraVars.Equal(state.ExtraVars) || !plan.GroupVars.Equal(state.GroupVars) || !plan.HostVars.Equal(state.HostVars) || !plan.SensitiveExtraVars.Equal(state.SensitiveExtraVars) ||
+		!plan.VarFiles.Equal(stateVarFiles) || !plan.HostTriggers.Equal(state.HostTriggers) ||
+		!planHash.Equal(stateHash) ||
+		(state.Failed.ValueBool() && plan.OnFailure.ValueString() == "taint") {
 
 		if config.StoreOutputInState.ValueBool() {
 			resp.Plan.SetAttribute(ctx, path.Root("ansible_playbook_stdout"), types.StringUnknown())
 		}
 		resp.Plan.SetAttribute(ctx, path.Root("ansible_playbook_stderr"), types.StringUnknown())
+		resp.Plan.SetAttribute(ctx, path.Root("unreachable_hosts"), types.ListUnknown(types.StringType))
+		resp.Plan.SetAttribute(ctx, path.Root("diffs"), types.MapUnknown(types.StringType))
+		resp.Plan.SetAttribute(ctx, path.Root("log_file"), types.StringUnknown())
+		resp.Plan.SetAttribute(ctx, path.Root("artifact_object_url"), types.StringUnknown())
+		resp.Plan.SetAttribute(ctx, path.Root("log_object_url"), types.StringUnknown())
+		resp.Plan.SetAttribute(ctx, path.Root("failed"), types.BoolUnknown())
+
+		if config.PredictChanges.ValueBool() && ansibleFound && planRunInputsKnown(plan) {
+			changes, diags := PredictChanges(ctx, plan, r.providerData)
+			resp.Diagnostics.Append(diags...)
+			if diags.HasError() {
+				return
+			}
+			predictedChanges, diags := types.MapValueFrom(ctx, predictedChangesType.ElemType, changes)
+			resp.Diagnostics.Append(diags...)
+			resp.Plan.SetAttribute(ctx, path.Root("predicted_changes"), predictedChanges)
+		} else if config.PredictChanges.ValueBool() {
+			resp.Plan.SetAttribute(ctx, path.Root("predicted_changes"), types.MapUnknown(predictedChangesType.ElemType))
+		}
+
 		var queriesModel map[string]ArtifactQueryModel
 		resp.Diagnostics.Append(plan.ArtifactQueries.ElementsAs(ctx, &queriesModel, false)...)
 
 		for name, model := range queriesModel {
 			model.Result = types.StringUnknown()
+			model.Results = types.ListUnknown(types.StringType)
 			queriesModel[name] = model
 		}
 		newQueriesModel, newDiags := types.MapValueFrom(ctx, types.ObjectType{AttrTypes: ArtifactQueryModel{}.AttrTypes()}, queriesModel)
 		resp.Diagnostics.Append(newDiags...)
 		resp.Plan.SetAttribute(ctx, path.Root("artifact_queries"), newQueriesModel)
+		resp.Plan.SetAttribute(ctx, path.Root("artifact_values"), types.DynamicUnknown())
 	}
 }
 
+// Private state key marking a resource imported from a playbook and an
+// inventory file until its first run
+const importedPrivateKey = "imported"
+
+// Import an ID, or `<playbook path>:<inventory file>`, which sets the
+// playbook, the inventory from the file and the hash of the playbook, so the
+// imported resource only runs again if the configuration differs. The hash
+// options of the configuration are applied by ModifyPlan.
 func (r *PlaybookResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	playbook, inventoryFile, found := splitImportID(req.ID)
+	if !found {
+		resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+		return
+	}
+
+	inventory, err := os.ReadFile(inventoryFile)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read the inventory", err.Error())
+		return
+	}
+	hash, err := calculatePlaybookHash(playbook, PlaybookHashOptions{})
+	if err != nil {
+		resp.Diagnostics.AddError("Error Calculating Playbook Hash", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), uuid.New().String())...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("playbook"), playbook)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("inventory"), string(inventory))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("playbook_hash"), hash)...)
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, importedPrivateKey, []byte("true"))...)
+}
+
+func (r *PlaybookResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	priorSchema := schemaResp.Schema
+	priorSchema.Version = 0
+
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema:   &priorSchema,
+			StateUpgrader: upgradePlaybookHash,
+		},
+	}
+}
+
+// Replace a playbook hash of state version 0 by the current one, if the files
+// didn't change since. Otherwise the old hash is kept, so the playbook runs
+// again as it would have before.
+func upgradePlaybookHash(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var data PlaybookResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	options := PlaybookHashOptions{}
+	resp.Diagnostics.Append(data.VarFiles.ElementsAs(ctx, &options.VarFiles, false)...)
+	resp.Diagnostics.Append(data.HashInclude.ElementsAs(ctx, &options.Include, false)...)
+	resp.Diagnostics.Append(data.HashExclude.ElementsAs(ctx, &options.Exclude, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Files that can't be hashed anymore fail the plan with a proper error
+	legacyHash, err := calculateLegacyPlaybookHash(data.Playbook.ValueString())
+	if err == nil && legacyHash == data.PlaybookHash.ValueString() {
+		currentHash, err := calculatePlaybookHash(data.Playbook.ValueString(), options)
+		if err == nil {
+			data.PlaybookHash = types.StringValue(currentHash)
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Split an import ID of the form `<playbook path>:<inventory file>` at the
+// colon where both files exist, as paths may contain colons themselves, like
+// Windows drive letters
+func splitImportID(id string) (string, string, bool) {
+	for i, c := range id {
+		if c != ':' {
+			continue
+		}
+		playbook, inventory := id[:i], id[i+1:]
+		if fileExists(playbook) && fileExists(inventory) {
+			return playbook, inventory, true
+		}
+	}
+	return "", "", false
+}
+
+// Report an error, if the version of ansible doesn't satisfy ansible_version_constraint
+func validateAnsibleVersion(ctx context.Context, plan *PlaybookResourceModel, providerData *ProviderData, diags *diag.Diagnostics) {
+	if plan.AnsibleVersionConstraint.IsNull() || plan.AnsibleVersionConstraint.IsUnknown() || plan.AnsiblePlaybookBinary.IsUnknown() ||
+		plan.ContainerImage.IsUnknown() || plan.ContainerEngine.IsUnknown() || plan.ContainerVolumes.IsUnknown() {
+		return
+	}
+
+	constraint, err := version.NewConstraint(plan.AnsibleVersionConstraint.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("ansible_version_constraint"), "Invalid version constraint", err.Error())
+		return
+	}
+
+	cmd, newDiags := providerData.PlaybookCommand(ctx, plan, nil, nil, "--version")
+	diags.Append(newDiags...)
+	if diags.HasError() {
+		return
+	}
+
+	ansibleVersion, err := AnsibleVersion(cmd)
+	if err != nil {
+		diags.AddAttributeError(path.Root("ansible_playbook_binary"), "Failed to determine the ansible version", err.Error())
+		return
+	}
+
+	if !constraint.Check(ansibleVersion) {
+		diags.AddAttributeError(path.Root("ansible_version_constraint"), "Unsupported ansible version",
+			fmt.Sprintf("Ansible core %s doesn't satisfy the constraint %q.", ansibleVersion, constraint))
+	}
+}
+
+// Report an error on attribute, if value names a file that doesn't exist or can't be read
+func validateReadableFile(attribute path.Path, value types.String, diags *diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return
+	}
+
+	info, err := os.Stat(value.ValueString())
+	if err != nil {
+		diags.AddAttributeError(attribute, "File not found", fmt.Sprintf("Can't access %s: %s", value.ValueString(), err))
+		return
+	}
+	if info.IsDir() {
+		diags.AddAttributeError(attribute, "Not a file", fmt.Sprintf("%s is a directory.", value.ValueString()))
+		return
+	}
+
+	file, err := os.Open(value.ValueString())
+	if err != nil {
+		diags.AddAttributeError(attribute, "File not readable", fmt.Sprintf("Can't read %s: %s", value.ValueString(), err))
+		return
+	}
+	file.Close()
+}
+
+// Report an error on attribute, if the directory of the file named by value doesn't exist
+func validateWritableDirectory(attribute path.Path, value types.String, diags *diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return
+	}
+
+	dir := filepath.Dir(value.ValueString())
+	if !directoryExists(dir) {
+		diags.AddAttributeError(attribute, "Directory not found", fmt.Sprintf("The directory %s doesn't exist.", dir))
+	}
 }
 
 func directoryExists(path string) bool {
 	info, err := os.Stat(path)
-	if os.IsNotExist(err) {
+	if err != nil {
 		return false
 	}
 	return info.IsDir()
 }
 
-func calculatePlaybookHash(playbookPath string) (string, error) {
-	roles, err := ParsePlaybookRoles(playbookPath)
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return !info.IsDir()
+}
+
+// The playbook hash of the playbook, or of what it was generated from
+func (r *PlaybookResource) hash(playbookPath string, options PlaybookHashOptions) (string, error) {
+	if r.playbookHash != nil {
+		return r.playbookHash(options)
+	}
+	return calculatePlaybookHash(playbookPath, options)
+}
+
+// Additional inputs of the playbook hash besides the playbook and its roles
+type PlaybookHashOptions struct {
+	VarFiles []string
+	Include  []string
+	Exclude  []string
+}
+
+// The playbook hash of the playbook, its roles and options. Digests come from
+// the file digest cache, so unchanged files aren't read again.
+func calculatePlaybookHash(playbookPath string, options PlaybookHashOptions) (string, error) {
+	playbookHash, err := hashPlaybook(playbookPath, options, DefaultFileDigestCache().Digest)
+
+	// The cache only speeds up the next plan, failing to persist it is harmless
+	_ = DefaultFileDigestCache().Save()
+
+	return playbookHash, err
+}
+
+// The playbook hash of state version 0, as it was calculated before digests
+// were cached and the hash options were added: the contents of the roles of
+// the plays found in roles/ next to the playbook, followed by the playbook.
+// It must not change, or existing state isn't recognized as unchanged.
+func calculateLegacyPlaybookHash(playbookPath string) (string, error) {
+	roles, err := parseLegacyPlaybookRoles(playbookPath)
 	if err != nil {
 		return "", fmt.Errorf("ERROR: couldn't parse playbook roles! %s", err)
 	}
@@ -307,18 +1523,79 @@ func calculatePlaybookHash(playbookPath string) (string, error) {
 	for _, role := range roles {
 		path := filepath.Join(filepath.Dir(playbookPath), "roles", role)
 		if directoryExists(path) {
-			err := HashDirectory(hash, path)
+			err := HashDirectory(hash, path, nil, os.ReadFile)
+			if err != nil {
+				return "", fmt.Errorf("ERROR: couldn't hash playbook roles! %s", err)
+			}
+		}
+	}
+
+	err = HashFile(hash, playbookPath, os.ReadFile)
+	if err != nil {
+		return "", fmt.Errorf("ERROR: couldn't hash playbook! %s", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashPlaybook(playbookPath string, options PlaybookHashOptions, digest FileDigest) (string, error) {
+	roles, importedPlaybooks, err := ParsePlaybookDependencies(playbookPath)
+	if err != nil {
+		return "", fmt.Errorf("ERROR: couldn't parse playbook roles! %s", err)
+	}
+
+	filter, err := NewHashFilter(filepath.Dir(playbookPath), options.Include, options.Exclude)
+	if err != nil {
+		return "", fmt.Errorf("ERROR: couldn't parse hash globs! %s", err)
+	}
+
+	hash := sha256.New()
+	// Roles are resolved next to the playbook using them, which may be an
+	// imported one in another directory. A role found by several of them is
+	// hashed once.
+	hashedRoles := map[string]bool{}
+	for _, role := range roles {
+		path, found := ResolveRole(role.PlaybookDir, role.Name)
+		if found && !hashedRoles[path] {
+			hashedRoles[path] = true
+			err := HashDirectory(hash, path, filter, digest)
 			if err != nil {
 				return "", fmt.Errorf("ERROR: couldn't hash playbook roles! %s", err)
 			}
 		}
 	}
 
-	err = HashFile(hash, playbookPath)
+	err = HashFile(hash, playbookPath, digest)
 	if err != nil {
 		return "", fmt.Errorf("ERROR: couldn't hash playbook! %s", err)
 	}
 
+	for _, importedPlaybook := range importedPlaybooks {
+		err = HashFile(hash, importedPlaybook, digest)
+		if err != nil {
+			return "", fmt.Errorf("ERROR: couldn't hash imported playbook! %s", err)
+		}
+	}
+
+	for _, varFile := range options.VarFiles {
+		err = HashFile(hash, varFile, digest)
+		if err != nil {
+			return "", fmt.Errorf("ERROR: couldn't hash var file! %s", err)
+		}
+	}
+
+	// The inventory is written to a temporary file, so ansible only loads
+	// group_vars and host_vars from next to the playbook
+	for _, name := range []string{"group_vars", "host_vars"} {
+		path := filepath.Join(filepath.Dir(playbookPath), name)
+		if directoryExists(path) {
+			err := HashDirectory(hash, path, filter, digest)
+			if err != nil {
+				return "", fmt.Errorf("ERROR: couldn't hash %s! %s", name, err)
+			}
+		}
+	}
+
 	playbook_hash := hex.EncodeToString(hash.Sum(nil))
 	return playbook_hash, nil
 }
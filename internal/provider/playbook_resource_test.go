@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCalculateLegacyPlaybookHash(t *testing.T) {
+	// The hashes were calculated by the provider before state version 1
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "playbook only",
+			files: map[string]string{
+				"site.yml": "- hosts: all\n  tasks: []\n",
+			},
+			want: "22cda2efeae903be182ed028f39dc7ccbb7030ba92f15d0f51dcd234e6e34559",
+		},
+		{
+			name: "roles",
+			files: map[string]string{
+				"site.yml":                    "- hosts: all\n  roles:\n    - web\n    - role: db\n    - web\n    - missing\n  tasks:\n    - include_role:\n        name: extra\n",
+				"roles/web/tasks/main.yml":    "- debug: msg=web\n",
+				"roles/web/defaults/main.yml": "port: 80\n",
+				"roles/db/tasks/main.yml":     "- debug: msg=db\n",
+				// Neither included roles nor group_vars were hashed
+				"roles/extra/tasks/main.yml": "- debug: msg=extra\n",
+				"group_vars/all.yml":         "x: 1\n",
+			},
+			want: "df3f79dcb89e6e6e67c925358596fbf48b9d70dc60dfb132003f4c08c7ebde2c",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range test.files {
+				file := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := calculateLegacyPlaybookHash(filepath.Join(dir, "site.yml"))
+			if err != nil {
+				t.Fatalf("calculateLegacyPlaybookHash() error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("calculateLegacyPlaybookHash() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
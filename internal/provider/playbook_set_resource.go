@@ -0,0 +1,472 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &PlaybookSetResource{}
+var _ resource.ResourceWithValidateConfig = &PlaybookSetResource{}
+var _ resource.ResourceWithConfigure = &PlaybookSetResource{}
+var _ resource.ResourceWithModifyPlan = &PlaybookSetResource{}
+
+func NewPlaybookSetResource() resource.Resource {
+	return &PlaybookSetResource{}
+}
+
+// PlaybookSetResource runs several playbooks against one inventory as a
+// single resource, every step like a run of the playbook resource.
+type PlaybookSetResource struct {
+	providerData *ProviderData
+}
+
+// PlaybookSetResourceModel describes the resource data model.
+type PlaybookSetResourceModel struct {
+	Inventory             types.String   `tfsdk:"inventory"`
+	AnsiblePlaybookBinary types.String   `tfsdk:"ansible_playbook_binary"`
+	Steps                 types.List     `tfsdk:"steps"`
+	MaxParallelism        types.Int64    `tfsdk:"max_parallelism"`
+	PlaybookHash          types.String   `tfsdk:"playbook_hash"`
+	Stats                 types.Map      `tfsdk:"stats"`
+	Timeouts              timeouts.Value `tfsdk:"timeouts"`
+	Id                    types.String   `tfsdk:"id"`
+}
+
+type PlaybookStepModel struct {
+	Playbook  types.String `tfsdk:"playbook"`
+	ExtraVars types.Map    `tfsdk:"extra_vars"`
+	Tags      types.List   `tfsdk:"tags"`
+}
+
+// The stats of a host in the state
+type HostStatsModel struct {
+	Ok          types.Int64 `tfsdk:"ok"`
+	Changed     types.Int64 `tfsdk:"changed"`
+	Failures    types.Int64 `tfsdk:"failures"`
+	Unreachable types.Int64 `tfsdk:"unreachable"`
+	Skipped     types.Int64 `tfsdk:"skipped"`
+	Rescued     types.Int64 `tfsdk:"rescued"`
+	Ignored     types.Int64 `tfsdk:"ignored"`
+}
+
+func (HostStatsModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"ok":          types.Int64Type,
+		"changed":     types.Int64Type,
+		"failures":    types.Int64Type,
+		"unreachable": types.Int64Type,
+		"skipped":     types.Int64Type,
+		"rescued":     types.Int64Type,
+		"ignored":     types.Int64Type,
+	}
+}
+
+func NewHostStatsModel(stats HostStats) HostStatsModel {
+	return HostStatsModel{
+		Ok:          types.Int64Value(int64(stats.Ok)),
+		Changed:     types.Int64Value(int64(stats.Changed)),
+		Failures:    types.Int64Value(int64(stats.Failures)),
+		Unreachable: types.Int64Value(int64(stats.Unreachable)),
+		Skipped:     types.Int64Value(int64(stats.Skipped)),
+		Rescued:     types.Int64Value(int64(stats.Rescued)),
+		Ignored:     types.Int64Value(int64(stats.Ignored)),
+	}
+}
+
+// The stats per host in the state
+func StatsValue(ctx context.Context, stats Stats, diags *diag.Diagnostics) types.Map {
+	statsModel := make(map[string]HostStatsModel, len(stats))
+	for host, hostStats := range stats {
+		statsModel[host] = NewHostStatsModel(hostStats)
+	}
+	value, newDiags := types.MapValueFrom(ctx, types.ObjectType{AttrTypes: HostStatsModel{}.AttrTypes()}, statsModel)
+	diags.Append(newDiags...)
+	return value
+}
+
+// Add the stats of another run
+func (s Stats) Add(other Stats) {
+	for host, stats := range other {
+		sum := s[host]
+		sum.Ok += stats.Ok
+		sum.Changed += stats.Changed
+		sum.Failures += stats.Failures
+		sum.Unreachable += stats.Unreachable
+		sum.Skipped += stats.Skipped
+		sum.Rescued += stats.Rescued
+		sum.Ignored += stats.Ignored
+		s[host] = sum
+	}
+}
+
+// The resource model Execute runs the step with, the index-th of the set
+func (m PlaybookStepModel) ResourceModel(set *PlaybookSetResourceModel, index int) (PlaybookResourceModel, diag.Diagnostics) {
+	data, diags := PlaybookDataSourceModel{
+		Playbook:              m.Playbook,
+		Inventory:             set.Inventory,
+		AnsiblePlaybookBinary: set.AnsiblePlaybookBinary,
+		ExtraVars:             m.ExtraVars,
+		SensitiveExtraVars:    types.MapNull(types.StringType),
+		VarFiles:              types.ListNull(types.StringType),
+		Redact:                types.ListNull(types.StringType),
+		RedactPatterns:        types.ListNull(types.StringType),
+		ArtifactQueries:       types.MapNull(types.ObjectType{AttrTypes: ArtifactQueryModel{}.AttrTypes()}),
+		Id:                    set.Id,
+	}.ResourceModel()
+
+	// Steps running in parallel must not wait for each other on the lock of
+	// their inventory
+	if set.MaxParallelism.ValueInt64() > 1 {
+		data.LockKey = types.StringValue(fmt.Sprintf("%s:%d", set.Id.ValueString(), index))
+	}
+	return data, diags
+}
+
+func (r *PlaybookSetResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_playbook_set"
+}
+
+func (r *PlaybookSetResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Runs several playbooks against the same inventory as one unit, instead of a chain of `ansible_playbook` resources with `depends_on`. All steps run again when any of them or the inventory changes.",
+
+		Attributes: map[string]schema.Attribute{
+			"inventory": schema.StringAttribute{
+				MarkdownDescription: "The inventory to use. Not a path, the contents.",
+				Required:            true,
+			},
+			"ansible_playbook_binary": schema.StringAttribute{
+				MarkdownDescription: "Defaults to `ansible-playbook`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("ansible-playbook"),
+			},
+			"steps": schema.ListNestedAttribute{
+				MarkdownDescription: "The playbooks to run, in order. No step is started after one failed.",
+				Required:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"playbook": schema.StringAttribute{
+							MarkdownDescription: "Path to the playbook.",
+							Required:            true,
+						},
+						"extra_vars": schema.MapAttribute{
+							MarkdownDescription: "Additional variables of the step.",
+							Optional:            true,
+							ElementType:         types.StringType,
+						},
+						"tags": schema.ListAttribute{
+							MarkdownDescription: "Only run the tasks with these tags.",
+							Optional:            true,
+							ElementType:         types.StringType,
+						},
+					},
+				},
+			},
+			"max_parallelism": schema.Int64Attribute{
+				MarkdownDescription: "How many steps may run at the same time. They are started in order, but a step doesn't wait for the previous ones to finish while fewer are running, so only raise it for steps that don't depend on each other. Parallel steps also don't wait for each other on the lock of the inventory. Defaults to 1, one step after the other.",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(1),
+			},
+			"playbook_hash": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Hash of the playbooks of all steps, their roles, `group_vars` and `host_vars`, like the `playbook_hash` of `ansible_playbook`.",
+			},
+			"stats": schema.MapNestedAttribute{
+				MarkdownDescription: "The stats of the last run per host, summed over all steps.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"ok":          schema.Int64Attribute{Computed: true},
+						"changed":     schema.Int64Attribute{Computed: true},
+						"failures":    schema.Int64Attribute{Computed: true},
+						"unreachable": schema.Int64Attribute{Computed: true},
+						"skipped":     schema.Int64Attribute{Computed: true},
+						"rescued":     schema.Int64Attribute{Computed: true},
+						"ignored":     schema.Int64Attribute{Computed: true},
+					},
+				},
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+			}),
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *PlaybookSetResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.providerData = providerData
+}
+
+func (r *PlaybookSetResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config PlaybookSetResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.Steps.IsNull() && !config.Steps.IsUnknown() && len(config.Steps.Elements()) == 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("steps"), "No steps", "At least one step must be set.")
+	}
+	if !config.MaxParallelism.IsNull() && !config.MaxParallelism.IsUnknown() && config.MaxParallelism.ValueInt64() < 1 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_parallelism"), "Invalid parallelism", "max_parallelism must be at least 1.")
+	}
+}
+
+// The playbook hash of the set, the hash of the playbook hashes of its steps.
+// Unknown while a playbook is.
+func (m *PlaybookSetResourceModel) playbookHash(ctx context.Context, diags *diag.Diagnostics) types.String {
+	if m.Steps.IsUnknown() {
+		return types.StringUnknown()
+	}
+
+	var steps []PlaybookStepModel
+	diags.Append(m.Steps.ElementsAs(ctx, &steps, false)...)
+	if diags.HasError() {
+		return types.StringUnknown()
+	}
+
+	hash := sha256.New()
+	for i, step := range steps {
+		if step.Playbook.IsUnknown() {
+			return types.StringUnknown()
+		}
+		stepHash, err := calculatePlaybookHash(step.Playbook.ValueString(), PlaybookHashOptions{})
+		if err != nil {
+			diags.AddAttributeError(path.Root("steps").AtListIndex(i).AtName("playbook"), "Error Calculating Playbook Hash", err.Error())
+			return types.StringUnknown()
+		}
+		hash.Write([]byte(stepHash))
+	}
+	return types.StringValue(hex.EncodeToString(hash.Sum(nil)))
+}
+
+// Plan the playbook_hash of a resource running several playbooks, so it runs
+// again when a playbook, its roles or variables changed, like ansible_playbook
+// does. Resources created before they had a hash get one with their next run
+// instead of running again for it.
+func planPlaybookHash(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, hash types.String, statsType attr.Type) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	if !req.State.Raw.IsNull() {
+		var planned, prior types.String
+		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("playbook_hash"), &planned)...)
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("playbook_hash"), &prior)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		// Nothing else changed, the hash is planned as it is in the state
+		if !planned.IsUnknown() {
+			if prior.IsNull() {
+				return
+			}
+			if !hash.Equal(prior) {
+				resp.Plan.SetAttribute(ctx, path.Root("stats"), types.MapUnknown(statsType))
+			}
+		}
+	}
+
+	resp.Plan.SetAttribute(ctx, path.Root("playbook_hash"), hash)
+}
+
+func (r *PlaybookSetResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var plan *PlaybookSetResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+
+	if resp.Diagnostics.HasError() || plan == nil {
+		return
+	}
+
+	hash := plan.playbookHash(ctx, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	planPlaybookHash(ctx, req, resp, hash, types.ObjectType{AttrTypes: HostStatsModel{}.AttrTypes()})
+}
+
+// Call run for 0 to n-1 in order, at most parallelism at a time, until the
+// context ends. With stopOnFailure, no more runs are started after one
+// returned false. Returns the number of runs started.
+func runBounded(ctx context.Context, n int, parallelism int64, stopOnFailure bool, run func(i int) bool) int {
+	slots := make(chan struct{}, max(parallelism, 1))
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+
+	started := 0
+	for i := 0; i < n; i++ {
+		slots <- struct{}{}
+		if (stopOnFailure && failed.Load()) || ctx.Err() != nil {
+			<-slots
+			break
+		}
+		started++
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if !run(i) {
+				failed.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return started
+}
+
+// Run the steps, at most max_parallelism at a time, and set the summed stats
+func (r *PlaybookSetResource) run(ctx context.Context, diags *diag.Diagnostics, data *PlaybookSetResourceModel) {
+	var steps []PlaybookStepModel
+	diags.Append(data.Steps.ElementsAs(ctx, &steps, false)...)
+	if diags.HasError() {
+		return
+	}
+
+	if data.PlaybookHash.IsUnknown() {
+		data.PlaybookHash = data.playbookHash(ctx, diags)
+	}
+	if diags.HasError() {
+		return
+	}
+
+	stepDiags := make([]diag.Diagnostics, len(steps))
+	stepStats := make([]Stats, len(steps))
+
+	started := runBounded(ctx, len(steps), data.MaxParallelism.ValueInt64(), true, func(i int) bool {
+		var tags []string
+		stepDiags[i].Append(steps[i].Tags.ElementsAs(ctx, &tags, false)...)
+		if stepDiags[i].HasError() {
+			return false
+		}
+
+		stepData, newDiags := steps[i].ResourceModel(data, i)
+		stepDiags[i].Append(newDiags...)
+		if stepDiags[i].HasError() {
+			return false
+		}
+		Execute(ctx, &stepDiags[i], &stepData, r.providerData, RunOptions{Tags: tags, Stats: &stepStats[i]})
+		return !stepDiags[i].HasError()
+	})
+
+	stats := Stats{}
+	for i, step := range steps[:started] {
+		diags.Append(stepDiags[i]...)
+		if stepDiags[i].HasError() {
+			diags.AddAttributeError(path.Root("steps").AtListIndex(i), fmt.Sprintf("Step %d of %d failed", i+1, len(steps)), step.Playbook.ValueString())
+		}
+		stats.Add(stepStats[i])
+	}
+	if started < len(steps) {
+		diags.AddWarning(fmt.Sprintf("%d of %d steps didn't run", len(steps)-started, len(steps)), "No step is started after one failed.")
+	}
+
+	data.Stats = StatsValue(ctx, stats, diags)
+}
+
+func (r *PlaybookSetResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data PlaybookSetResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(uuid.New().String())
+
+	createTimeout, diags := data.Timeouts.Create(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *PlaybookSetResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+func (r *PlaybookSetResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data PlaybookSetResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := data.Timeouts.Update(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *PlaybookSetResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
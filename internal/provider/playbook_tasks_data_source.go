@@ -0,0 +1,335 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &PlaybookTasksDataSource{}
+var _ datasource.DataSourceWithConfigure = &PlaybookTasksDataSource{}
+
+func NewPlaybookTasksDataSource() datasource.DataSource {
+	return &PlaybookTasksDataSource{}
+}
+
+type PlaybookTasksDataSource struct {
+	providerData *ProviderData
+}
+
+// PlaybookTasksDataSourceModel describes the data source data model.
+type PlaybookTasksDataSourceModel struct {
+	Playbook              types.String `tfsdk:"playbook"`
+	Inventory             types.String `tfsdk:"inventory"`
+	ExtraVars             types.Map    `tfsdk:"extra_vars"`
+	AnsiblePlaybookBinary types.String `tfsdk:"ansible_playbook_binary"`
+	Plays                 types.List   `tfsdk:"plays"`
+	Tasks                 types.List   `tfsdk:"tasks"`
+	Tags                  types.List   `tfsdk:"tags"`
+}
+
+type PlaybookTaskModel struct {
+	Play types.String `tfsdk:"play"`
+	Name types.String `tfsdk:"name"`
+	Tags types.List   `tfsdk:"tags"`
+}
+
+func (m PlaybookTaskModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"play": types.StringType,
+		"name": types.StringType,
+		"tags": types.ListType{ElemType: types.StringType},
+	}
+}
+
+type PlaybookPlayModel struct {
+	Name  types.String `tfsdk:"name"`
+	Hosts types.String `tfsdk:"hosts"`
+	Tags  types.List   `tfsdk:"tags"`
+}
+
+func (m PlaybookPlayModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"name":  types.StringType,
+		"hosts": types.StringType,
+		"tags":  types.ListType{ElemType: types.StringType},
+	}
+}
+
+// The output of `ansible-playbook --list-tasks --list-tags`
+type PlaybookListing struct {
+	Plays []ListedPlay
+	Tasks []ListedTask
+	// All tags of plays and tasks, sorted
+	Tags []string
+}
+
+type ListedPlay struct {
+	Name  string
+	Hosts string
+	Tags  []string
+}
+
+type ListedTask struct {
+	Play string
+	Name string
+	Tags []string
+}
+
+var (
+	listedPlayRegexp     = regexp.MustCompile(`^play #\d+ \((.*)\): (.*?)\s*TAGS: \[(.*)\]$`)
+	listedTaskTagsRegexp = regexp.MustCompile(`^TASK TAGS: \[(.*)\]$`)
+	listedTaskRegexp     = regexp.MustCompile(`^(.*?)\s*TAGS: \[(.*)\]$`)
+)
+
+func parseListedTags(tags string) []string {
+	result := []string{}
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); len(tag) > 0 {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+func ParsePlaybookListing(output []byte) (*PlaybookListing, error) {
+	listing := &PlaybookListing{Plays: []ListedPlay{}, Tasks: []ListedTask{}}
+	tags := map[string]bool{}
+	var play *ListedPlay
+
+	// Not with bufio.Scanner, which stops at lines longer than 64KB
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+
+		if match := listedPlayRegexp.FindStringSubmatch(line); match != nil {
+			listing.Plays = append(listing.Plays, ListedPlay{Name: match[2], Hosts: match[1], Tags: parseListedTags(match[3])})
+			play = &listing.Plays[len(listing.Plays)-1]
+			for _, tag := range play.Tags {
+				tags[tag] = true
+			}
+			continue
+		}
+
+		if play == nil {
+			continue
+		}
+
+		if match := listedTaskTagsRegexp.FindStringSubmatch(line); match != nil {
+			for _, tag := range parseListedTags(match[1]) {
+				tags[tag] = true
+			}
+			continue
+		}
+
+		if match := listedTaskRegexp.FindStringSubmatch(line); match != nil {
+			task := ListedTask{Play: play.Name, Name: match[1], Tags: parseListedTags(match[2])}
+			listing.Tasks = append(listing.Tasks, task)
+			for _, tag := range task.Tags {
+				tags[tag] = true
+			}
+		}
+	}
+	if len(listing.Plays) == 0 {
+		return nil, fmt.Errorf("no plays found in the output")
+	}
+
+	listing.Tags = []string{}
+	for tag := range tags {
+		listing.Tags = append(listing.Tags, tag)
+	}
+	sort.Strings(listing.Tags)
+
+	return listing, nil
+}
+
+func (d *PlaybookTasksDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_playbook_tasks"
+}
+
+func (d *PlaybookTasksDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists the plays, tasks and tags of a playbook with `ansible-playbook --list-tasks --list-tags`, without running it. Useful in preconditions, e.g. to check that a tag exists.",
+
+		Attributes: map[string]schema.Attribute{
+			"playbook": schema.StringAttribute{
+				MarkdownDescription: "Path to ansible playbook.",
+				Required:            true,
+			},
+			"inventory": schema.StringAttribute{
+				MarkdownDescription: "The inventory to use. Not a path, the contents. Only needed if task names or includes depend on inventory variables.",
+				Optional:            true,
+			},
+			"extra_vars": schema.MapAttribute{
+				MarkdownDescription: "Extra variables, for task names or includes that depend on them.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"ansible_playbook_binary": schema.StringAttribute{
+				MarkdownDescription: "Defaults to `ansible-playbook`.",
+				Optional:            true,
+			},
+			"plays": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The plays of the playbook, in order.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Computed:    true,
+							Description: "Name of the play.",
+						},
+						"hosts": schema.StringAttribute{
+							Computed:    true,
+							Description: "The host pattern of the play.",
+						},
+						"tags": schema.ListAttribute{
+							Computed:    true,
+							ElementType: types.StringType,
+							Description: "Tags of the play.",
+						},
+					},
+				},
+			},
+			"tasks": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The tasks of all plays, in order.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"play": schema.StringAttribute{
+							Computed:    true,
+							Description: "Name of the play of the task.",
+						},
+						"name": schema.StringAttribute{
+							Computed:    true,
+							Description: "Name of the task, prefixed with its role if it has one.",
+						},
+						"tags": schema.ListAttribute{
+							Computed:    true,
+							ElementType: types.StringType,
+							Description: "Tags of the task, including inherited ones.",
+						},
+					},
+				},
+			},
+			"tags": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "All tags of the playbook, sorted.",
+			},
+		},
+	}
+}
+
+func (d *PlaybookTasksDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.providerData = providerData
+}
+
+func (d *PlaybookTasksDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data PlaybookTasksDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	var extraVars map[string]string
+	resp.Diagnostics.Append(data.ExtraVars.ElementsAs(ctx, &extraVars, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	args := []string{"--list-tasks", "--list-tags"}
+	args = append(args, ExtraVarsArgs(extraVars)...)
+
+	if !data.Inventory.IsNull() {
+		tempInventory := SharedInventory(ctx, InventoryPattern(data.Inventory.ValueString(), "auto"), data.Inventory.ValueString(), &resp.Diagnostics)
+		if len(tempInventory) > 0 {
+			defer ReleaseInventory(tempInventory, &resp.Diagnostics)
+		}
+		args = append(args, "-i", tempInventory)
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	args = append(args, data.Playbook.ValueString())
+
+	binary := "ansible-playbook"
+	if !data.AnsiblePlaybookBinary.IsNull() {
+		binary = data.AnsiblePlaybookBinary.ValueString()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := d.providerData.AnsibleCommand(ctx, binary, nil, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd, nil)
+
+	if err := cmd.Run(); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("%s --list-tasks failed", binary), fmt.Sprintf("%s\n%s", err, stderr.String()))
+		return
+	}
+
+	listing, err := ParsePlaybookListing(stdout.Bytes())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to parse the output of ansible-playbook --list-tasks", fmt.Sprintf("%s\n\nSTDOUT:\n%s", err, stdout.String()))
+		return
+	}
+
+	resp.Diagnostics.Append(listing.SetModel(ctx, &data)...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (listing *PlaybookListing) SetModel(ctx context.Context, data *PlaybookTasksDataSourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	plays := make([]PlaybookPlayModel, 0, len(listing.Plays))
+	for _, play := range listing.Plays {
+		tags, newDiags := types.ListValueFrom(ctx, types.StringType, play.Tags)
+		diags.Append(newDiags...)
+		plays = append(plays, PlaybookPlayModel{Name: types.StringValue(play.Name), Hosts: types.StringValue(play.Hosts), Tags: tags})
+	}
+	playsValue, newDiags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: PlaybookPlayModel{}.AttrTypes()}, plays)
+	diags.Append(newDiags...)
+	data.Plays = playsValue
+
+	tasks := make([]PlaybookTaskModel, 0, len(listing.Tasks))
+	for _, task := range listing.Tasks {
+		tags, newDiags := types.ListValueFrom(ctx, types.StringType, task.Tags)
+		diags.Append(newDiags...)
+		tasks = append(tasks, PlaybookTaskModel{Play: types.StringValue(task.Play), Name: types.StringValue(task.Name), Tags: tags})
+	}
+	tasksValue, newDiags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: PlaybookTaskModel{}.AttrTypes()}, tasks)
+	diags.Append(newDiags...)
+	data.Tasks = tasksValue
+
+	tags, newDiags := types.ListValueFrom(ctx, types.StringType, listing.Tags)
+	diags.Append(newDiags...)
+	data.Tags = tags
+
+	return diags
+}
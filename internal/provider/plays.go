@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"gopkg.in/yaml.v2"
+)
+
+// Only keep the plays of a playbook with the given names, in the order of
+// the playbook. Imported playbooks are only kept if their entry is named.
+// Returns an error if a name doesn't match any play.
+func FilterPlays(content []byte, names []string) ([]byte, error) {
+	var plays []yaml.MapSlice
+	if err := yaml.Unmarshal(content, &plays); err != nil {
+		return nil, err
+	}
+
+	var selected []yaml.MapSlice
+	found := map[string]bool{}
+	for _, play := range plays {
+		for _, item := range play {
+			if key, ok := item.Key.(string); !ok || key != "name" {
+				continue
+			}
+			if name, ok := item.Value.(string); ok && slices.Contains(names, name) {
+				selected = append(selected, play)
+				found[name] = true
+			}
+		}
+	}
+
+	var missing []string
+	for _, name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("the playbook has no plays named %s", strings.Join(missing, ", "))
+	}
+
+	return yaml.Marshal(selected)
+}
+
+// The playbook to run: the playbook of the resource, or a temporary copy with
+// only the plays selected by plays. The copy is written next to the playbook,
+// so relative paths like roles still resolve, and is removed by the returned
+// function.
+func SelectPlays(ctx context.Context, data *PlaybookResourceModel, diags *diag.Diagnostics) (string, func()) {
+	playbook := data.Playbook.ValueString()
+	if data.Plays.IsNull() {
+		return playbook, func() {}
+	}
+
+	var names []string
+	diags.Append(data.Plays.ElementsAs(ctx, &names, false)...)
+	if diags.HasError() {
+		return "", func() {}
+	}
+
+	content, err := os.ReadFile(playbook)
+	if err != nil {
+		diags.AddAttributeError(path.Root("playbook"), "Failed to read the playbook", err.Error())
+		return "", func() {}
+	}
+	filtered, err := FilterPlays(content, names)
+	if err != nil {
+		diags.AddAttributeError(path.Root("plays"), "Failed to select the plays", err.Error())
+		return "", func() {}
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(playbook), ".ansible-plays-*.yml")
+	if err != nil {
+		diags.AddAttributeError(path.Root("plays"), "Failed to write the playbook with the selected plays", err.Error())
+		return "", func() {}
+	}
+	defer file.Close()
+
+	if _, err := file.Write(filtered); err != nil {
+		diags.AddAttributeError(path.Root("plays"), "Failed to write the playbook with the selected plays", err.Error())
+	}
+
+	return file.Name(), func() { RemoveFile(file.Name(), diags) }
+}
+
+// Report plays that aren't in the playbook during plan already
+func validatePlays(ctx context.Context, data *PlaybookResourceModel, diags *diag.Diagnostics) {
+	if data.Plays.IsNull() || data.Plays.IsUnknown() || data.Playbook.IsUnknown() {
+		return
+	}
+
+	var names []string
+	diags.Append(data.Plays.ElementsAs(ctx, &names, false)...)
+	content, err := os.ReadFile(data.Playbook.ValueString())
+	if diags.HasError() || err != nil {
+		// An unreadable playbook is reported by validateReadableFile
+		return
+	}
+	if _, err := FilterPlays(content, names); err != nil {
+		diags.AddAttributeError(path.Root("plays"), "Invalid plays", err.Error())
+	}
+}
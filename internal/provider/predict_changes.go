@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var predictedChangesType = types.MapType{ElemType: types.ListType{ElemType: types.StringType}}
+
+// The tasks that reported a change per host, in the order they ran. Every
+// host of the stats is included, with an empty list if nothing changed.
+func ChangedTasks(root Root) map[string][]string {
+	changes := map[string][]string{}
+	for host := range root.Stats {
+		changes[host] = []string{}
+	}
+	for _, play := range root.Plays {
+		for _, task := range play.Tasks {
+			for host, result := range task.Hosts {
+				if result.Changed {
+					changes[host] = append(changes[host], task.Task.Name)
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// Run the playbook with `--check --diff` and return the tasks that would
+// change per host. Failed tasks are reported as warnings, as tasks that
+// depend on changes of earlier ones often fail in check mode.
+func PredictChanges(ctx context.Context, data *PlaybookResourceModel, providerData *ProviderData) (map[string][]string, diag.Diagnostics) {
+	artifact, diags := runPlaybookAtPlan(ctx, data, providerData, []string{"ANSIBLE_STDOUT_CALLBACK=json"},
+		[]int{ExitCodeFailedHosts, ExitCodeUnreachable}, "--check", "--diff")
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	parsedArtifact := ParseArtifact(io.NewSectionReader(bytes.NewReader(artifact), 0, int64(len(artifact))))
+	if parsedArtifact.Err != nil {
+		diags.AddError("Error analyzing result JSON of the check mode run", parsedArtifact.Err.Error())
+		return nil, diags
+	}
+	changes := ChangedTasks(parsedArtifact.Root)
+
+	failures, _ := parsedArtifact.Root.Failures()
+	if len(failures) > 0 {
+		summaries := make([]string, 0, len(failures))
+		for _, failure := range failures {
+			summaries = append(summaries, failure.Summary())
+		}
+		diags.AddAttributeWarning(path.Root("predicted_changes"), "Predicted changes may be incomplete",
+			"Tasks failed in the check mode run:\n"+strings.Join(summaries, "\n"))
+	}
+
+	return changes, diags
+}
+
+// Set predicted_changes, if it wasn't known at plan time. It's only predicted
+// during plan, so it's null then.
+func setPredictedChanges(data *PlaybookResourceModel) {
+	if data.PredictedChanges.IsUnknown() {
+		data.PredictedChanges = types.MapNull(predictedChangesType.ElemType)
+	}
+}
@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const progressCallbackName = "terraform_progress"
+
+// Ansible callback plugin that reports play and task events as JSON lines
+// on the file descriptor given in TF_ANSIBLE_PROGRESS_FD. It runs next to
+// the json stdout callback, which only prints once the playbook finished.
+const progressCallbackSource = `from __future__ import (absolute_import, division, print_function)
+__metaclass__ = type
+
+import json
+import os
+
+from ansible.plugins.callback import CallbackBase
+
+
+class CallbackModule(CallbackBase):
+    CALLBACK_VERSION = 2.0
+    CALLBACK_TYPE = 'aggregate'
+    CALLBACK_NAME = 'terraform_progress'
+    CALLBACK_NEEDS_ENABLED = True
+
+    def __init__(self):
+        super(CallbackModule, self).__init__()
+        self._events = None
+        fd = os.environ.get('TF_ANSIBLE_PROGRESS_FD')
+        if fd:
+            try:
+                self._events = os.fdopen(int(fd), 'w', buffering=1)
+            except (OSError, ValueError):
+                self._events = None
+
+    def _emit(self, event, **data):
+        if self._events is None:
+            return
+        data['event'] = event
+        try:
+            self._events.write(json.dumps(data) + '\n')
+        except (OSError, ValueError):
+            self._events = None
+
+    def _emit_result(self, result, status):
+        self._emit('task_end', task=result._task.get_name(), host=result._host.get_name(), status=status)
+
+    def v2_playbook_on_play_start(self, play):
+        self._emit('play_start', play=play.get_name())
+
+    def v2_playbook_on_task_start(self, task, is_conditional):
+        self._emit('task_start', task=task.get_name())
+
+    def v2_playbook_on_handler_task_start(self, task):
+        self._emit('task_start', task=task.get_name())
+
+    def v2_runner_on_ok(self, result):
+        self._emit_result(result, 'changed' if result._result.get('changed', False) else 'ok')
+
+    def v2_runner_on_failed(self, result, ignore_errors=False):
+        self._emit_result(result, 'ignored' if ignore_errors else 'failed')
+
+    def v2_runner_on_skipped(self, result):
+        self._emit_result(result, 'skipping')
+
+    def v2_runner_on_unreachable(self, result):
+        self._emit_result(result, 'unreachable')
+
+    def v2_playbook_on_stats(self, stats):
+        self._emit('stats')
+`
+
+type ProgressEvent struct {
+	Event  string `json:"event"`
+	Play   string `json:"play"`
+	Task   string `json:"task"`
+	Host   string `json:"host"`
+	Status string `json:"status"`
+}
+
+// Environment that makes the progress callback report on file descriptor fd.
+// The callback has to be enabled with CallbackPluginsEnv as well.
+func ProgressCallbackEnv(fd int) []string {
+	return []string{fmt.Sprintf("TF_ANSIBLE_PROGRESS_FD=%d", fd)}
+}
+
+func FormatProgressEvent(event ProgressEvent) string {
+	switch event.Event {
+	case "play_start":
+		return fmt.Sprintf("PLAY [%s]", event.Play)
+	case "task_start":
+		return fmt.Sprintf("TASK [%s]", event.Task)
+	case "task_end":
+		return fmt.Sprintf("TASK [%s] %s: [%s]", event.Task, event.Status, event.Host)
+	case "stats":
+		return "PLAY RECAP"
+	}
+	return ""
+}
+
+// What a run did according to its progress events, to report what an
+// interrupted run got done, as there's no artifact then.
+type Progress struct {
+	Play string
+	Task string
+	// The number of task results per host and status
+	Results map[string]map[string]int
+}
+
+func (p *Progress) add(event ProgressEvent) {
+	switch event.Event {
+	case "play_start":
+		p.Play = event.Play
+	case "task_start":
+		p.Task = event.Task
+	case "task_end":
+		if p.Results == nil {
+			p.Results = map[string]map[string]int{}
+		}
+		if p.Results[event.Host] == nil {
+			p.Results[event.Host] = map[string]int{}
+		}
+		p.Results[event.Host][event.Status]++
+	}
+}
+
+// Describe the progress, e.g. for the diagnostic of an interrupted run.
+// Empty if there were no events.
+func (p Progress) Describe() string {
+	if len(p.Play) == 0 && len(p.Task) == 0 {
+		return ""
+	}
+
+	var description strings.Builder
+	fmt.Fprintf(&description, "It was running TASK [%s] of PLAY [%s].", p.Task, p.Play)
+	if len(p.Results) > 0 {
+		description.WriteString(" Task results per host until then:")
+		hosts := make([]string, 0, len(p.Results))
+		for host := range p.Results {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+		for _, host := range hosts {
+			var counts []string
+			for _, status := range []string{"ok", "changed", "failed", "unreachable", "skipping", "ignored"} {
+				if count := p.Results[host][status]; count > 0 {
+					counts = append(counts, fmt.Sprintf("%s=%d", status, count))
+				}
+			}
+			fmt.Fprintf(&description, "\n%s: %s", host, strings.Join(counts, " "))
+		}
+	}
+	return description.String()
+}
+
+// Log progress events as they are reported by the callback plugin, until
+// the reader is closed, and return the progress of the run. Events are read
+// with bufio.Reader, as the results of a task can make them longer than
+// bufio.Scanner allows.
+func StreamProgress(ctx context.Context, reader io.Reader, redactor *Redactor) Progress {
+	var progress Progress
+	lines := bufio.NewReader(reader)
+	for {
+		line, err := lines.ReadString('\n')
+		if err != nil && len(line) == 0 {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var event ProgressEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			tflog.Debug(ctx, fmt.Sprintf("Ignoring unparsable progress event: %s", err))
+			continue
+		}
+
+		progress.add(event)
+		if message := FormatProgressEvent(event); message != "" {
+			tflog.Info(ctx, redactor.Redact(message))
+		}
+	}
+
+	// Keep draining, so the playbook never blocks on a full pipe
+	io.Copy(io.Discard, reader)
+	return progress
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestStreamProgress(t *testing.T) {
+	tests := []struct {
+		name   string
+		events string
+		want   string
+	}{
+		{name: "no events", events: "", want: ""},
+		{
+			name:   "task started",
+			events: `{"event": "play_start", "play": "web"}` + "\n" + `{"event": "task_start", "task": "install"}`,
+			want:   "It was running TASK [install] of PLAY [web].",
+		},
+		{
+			name: "results per host",
+			events: `{"event": "play_start", "play": "web"}
+{"event": "task_start", "task": "ping"}
+{"event": "task_end", "task": "ping", "host": "b", "status": "ok"}
+{"event": "task_end", "task": "ping", "host": "a", "status": "unreachable"}
+not json
+
+{"event": "task_start", "task": "install"}
+{"event": "task_end", "task": "install", "host": "b", "status": "changed"}
+{"event": "task_end", "task": "install", "host": "b", "status": "ok"}`,
+			want: "It was running TASK [install] of PLAY [web]. Task results per host until then:\na: unreachable=1\nb: ok=2 changed=1",
+		},
+		{
+			name:   "next play",
+			events: `{"event": "play_start", "play": "web"}` + "\n" + `{"event": "task_start", "task": "ping"}` + "\n" + `{"event": "play_start", "play": "db"}`,
+			want:   "It was running TASK [ping] of PLAY [db].",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			progress := StreamProgress(context.Background(), strings.NewReader(test.events), nil)
+			if got := progress.Describe(); got != test.want {
+				t.Errorf("Describe() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
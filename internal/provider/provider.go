@@ -2,16 +2,21 @@ package provider
 
 import (
     "context"
+    "time"
 
     "github.com/hashicorp/terraform-plugin-framework/datasource"
+    "github.com/hashicorp/terraform-plugin-framework/function"
+    "github.com/hashicorp/terraform-plugin-framework/path"
     "github.com/hashicorp/terraform-plugin-framework/provider"
     "github.com/hashicorp/terraform-plugin-framework/provider/schema"
     "github.com/hashicorp/terraform-plugin-framework/resource"
+    "github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
     _ provider.Provider = &AnsibleProvider{}
+    _ provider.ProviderWithFunctions = &AnsibleProvider{}
 )
 
 // New is a helper function to simplify provider server and testing implementation.
@@ -31,6 +36,145 @@ type AnsibleProvider struct {
     version string
 }
 
+// AnsibleProviderModel describes the provider data model.
+type AnsibleProviderModel struct {
+    TempFileMaxAge    types.String    `tfsdk:"temp_file_max_age"`
+    Telemetry         *TelemetryModel `tfsdk:"telemetry"`
+    RunHistoryFile    types.String    `tfsdk:"run_history_file"`
+    PythonInterpreter types.String    `tfsdk:"python_interpreter"`
+    Galaxy            *GalaxyModel    `tfsdk:"galaxy"`
+    ArtifactDir       types.String    `tfsdk:"artifact_dir"`
+    ArtifactRetention types.Int64     `tfsdk:"artifact_retention"`
+    BinaryCheck       types.String    `tfsdk:"binary_check"`
+    MaxConnections    types.Int64     `tfsdk:"max_connections"`
+    Environment       types.Map       `tfsdk:"environment"`
+    AWX               *AWXModel       `tfsdk:"awx"`
+    Semaphore         *SemaphoreModel `tfsdk:"semaphore"`
+    EDA               *EDAModel       `tfsdk:"eda"`
+    LogForwarding     *LogForwardingModel `tfsdk:"log_forwarding"`
+}
+
+type TelemetryModel struct {
+    Endpoint    types.String `tfsdk:"endpoint"`
+    Insecure    types.Bool   `tfsdk:"insecure"`
+    Headers     types.Map    `tfsdk:"headers"`
+    ServiceName types.String `tfsdk:"service_name"`
+}
+
+// ProviderData is shared with the resources and data sources.
+type ProviderData struct {
+    Telemetry         *Telemetry
+    RunHistory        *RunHistory
+    PythonInterpreter string
+    Galaxy            *GalaxyConfig
+    ArtifactStore     *ArtifactStore
+    // Whether a missing ansible binary fails the plan
+    StrictBinaryCheck bool
+    ConnectionLimiter *ConnectionLimiter
+    // Variables of all ansible commands, overriding the process environment
+    Environment       map[string]string
+    AWX               *AWXClient
+    Semaphore         *SemaphoreClient
+    EDA               *EDAClient
+    LogForwarder      *LogForwarder
+}
+
+// GetTelemetry returns nil, if telemetry isn't configured.
+func (d *ProviderData) GetTelemetry() *Telemetry {
+    if d == nil {
+        return nil
+    }
+    return d.Telemetry
+}
+
+// GetPythonInterpreter returns "", if no python interpreter is configured.
+func (d *ProviderData) GetPythonInterpreter() string {
+    if d == nil {
+        return ""
+    }
+    return d.PythonInterpreter
+}
+
+// GetGalaxy returns nil, if galaxy isn't configured.
+func (d *ProviderData) GetGalaxy() *GalaxyConfig {
+    if d == nil {
+        return nil
+    }
+    return d.Galaxy
+}
+
+// GetRunHistory returns nil, if the run history isn't configured.
+func (d *ProviderData) GetRunHistory() *RunHistory {
+    if d == nil {
+        return nil
+    }
+    return d.RunHistory
+}
+
+// GetArtifactStore returns nil, if artifact_dir isn't configured.
+func (d *ProviderData) GetArtifactStore() *ArtifactStore {
+    if d == nil {
+        return nil
+    }
+    return d.ArtifactStore
+}
+
+// GetStrictBinaryCheck returns false, if binary_check isn't strict.
+func (d *ProviderData) GetStrictBinaryCheck() bool {
+    if d == nil {
+        return false
+    }
+    return d.StrictBinaryCheck
+}
+
+// GetConnectionLimiter returns nil, if max_connections isn't configured.
+func (d *ProviderData) GetConnectionLimiter() *ConnectionLimiter {
+    if d == nil {
+        return nil
+    }
+    return d.ConnectionLimiter
+}
+
+// GetEnvironment returns nil, if no environment is configured.
+func (d *ProviderData) GetEnvironment() map[string]string {
+    if d == nil {
+        return nil
+    }
+    return d.Environment
+}
+
+// GetAWX returns nil, if awx isn't configured.
+func (d *ProviderData) GetAWX() *AWXClient {
+    if d == nil {
+        return nil
+    }
+    return d.AWX
+}
+
+// GetSemaphore returns nil, if semaphore isn't configured.
+func (d *ProviderData) GetSemaphore() *SemaphoreClient {
+    if d == nil {
+        return nil
+    }
+    return d.Semaphore
+}
+
+// GetEDA returns nil, if eda isn't configured.
+func (d *ProviderData) GetEDA() *EDAClient {
+    if d == nil {
+        return nil
+    }
+    return d.EDA
+}
+
+// GetLogForwarder returns nil, if log_forwarding isn't configured.
+func (d *ProviderData) GetLogForwarder() *LogForwarder {
+    if d == nil {
+        return nil
+    }
+    return d.LogForwarder
+}
+
 // Metadata returns the provider type name.
 func (p *AnsibleProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
     resp.TypeName = "ansible"
@@ -39,21 +183,365 @@ func (p *AnsibleProvider) Metadata(_ context.Context, _ provider.MetadataRequest
 
 // Schema defines the provider-level schema for configuration data.
 func (p *AnsibleProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
-    resp.Schema = schema.Schema{}
+    resp.Schema = schema.Schema{
+        Attributes: map[string]schema.Attribute{
+            "temp_file_max_age": schema.StringAttribute{
+                MarkdownDescription: "Temporary inventory files older than this, left behind by crashed or killed runs, are removed when the provider starts. A duration like `12h` or `30m`, defaults to `24h`. Set to `0` to disable.",
+                Optional:            true,
+            },
+            "python_interpreter": schema.StringAttribute{
+                MarkdownDescription: "Path to a python interpreter with the ansible-core package installed, e.g. of a virtualenv. If an ansible binary like `ansible-playbook` isn't found, it's run as `python_interpreter -m ansible playbook` instead.",
+                Optional:            true,
+            },
+            "run_history_file": schema.StringAttribute{
+                MarkdownDescription: "Append a JSON line per playbook run to this file, with the timestamp, resource ID, playbook, redacted arguments, duration, exit code and host stats, for auditing which playbooks were run and when.",
+                Optional:            true,
+            },
+            "artifact_dir": schema.StringAttribute{
+                MarkdownDescription: "Write the redacted JSON artifact of every playbook run to this directory, named by the resource ID and the start of the run, as a local run history that doesn't bloat the state.",
+                Optional:            true,
+            },
+            "artifact_retention": schema.Int64Attribute{
+                MarkdownDescription: "The number of artifacts kept in `artifact_dir` per resource, older ones are removed after every run. Defaults to 10, set to 0 to keep all.",
+                Optional:            true,
+            },
+            "binary_check": schema.StringAttribute{
+                MarkdownDescription: "What to do if `ansible_playbook_binary` isn't found during plan: `strict` fails the plan, e.g. for CI, `lenient` (default) only warns and defers the checks that run ansible during plan, like `ansible_version_constraint` and `preview_hosts`, to the apply, e.g. for plans on machines without ansible like plan-only runs in Terraform Cloud.",
+                Optional:            true,
+            },
+            "environment": schema.MapAttribute{
+                MarkdownDescription: "Environment variables of all ansible commands the provider runs, e.g. `ANSIBLE_ROLES_PATH`. They override the environment of the provider process and are overridden by the `environment` of a resource.",
+                Optional:            true,
+                ElementType:         types.StringType,
+            },
+            "max_connections": schema.Int64Attribute{
+                MarkdownDescription: "The maximum number of connections to the hosts of all playbook runs of the provider at the same time, e.g. to stay below the connection limits of a bastion host or firewall. Every run reserves its forks, of `ANSIBLE_FORKS`, ansible.cfg or 5 by default. Runs wait to start until connections are free and their forks are limited to the connections they got with `--forks`. Unlimited by default.",
+                Optional:            true,
+            },
+            "galaxy": schema.SingleNestedAttribute{
+                MarkdownDescription: "Galaxy settings for the `ansible-galaxy` commands the provider runs, e.g. for air-gapped environments with a private Automation Hub mirror.",
+                Optional:            true,
+                Attributes: map[string]schema.Attribute{
+                    "servers": schema.ListNestedAttribute{
+                        MarkdownDescription: "The galaxy servers to use, in order, instead of the ones of ansible.cfg.",
+                        Optional:            true,
+                        NestedObject: schema.NestedAttributeObject{
+                            Attributes: map[string]schema.Attribute{
+                                "name": schema.StringAttribute{
+                                    MarkdownDescription: "Name of the server, letters, digits and underscores.",
+                                    Required:            true,
+                                },
+                                "url": schema.StringAttribute{
+                                    MarkdownDescription: "URL of the server, e.g. `https://hub.example.com/api/galaxy/content/published/`.",
+                                    Required:            true,
+                                },
+                                "token": schema.StringAttribute{
+                                    MarkdownDescription: "API token of the server.",
+                                    Optional:            true,
+                                    Sensitive:           true,
+                                },
+                                "auth_url": schema.StringAttribute{
+                                    MarkdownDescription: "URL of the SSO server to exchange the token at, for Automation Hub.",
+                                    Optional:            true,
+                                },
+                            },
+                        },
+                    },
+                    "offline": schema.BoolAttribute{
+                        MarkdownDescription: "Install collections from local sources only, without contacting any server. Defaults to false.",
+                        Optional:            true,
+                    },
+                    "ignore_certs": schema.BoolAttribute{
+                        MarkdownDescription: "Don't validate the TLS certificates of the servers. Defaults to false.",
+                        Optional:            true,
+                    },
+                    "collections_cache_dir": schema.StringAttribute{
+                        MarkdownDescription: "The directory the collections of `requirements_file` are installed to, shared by all resources and applies, with a directory per hash of the requirements. Defaults to `terraform-provider-ansible/collections` in the user cache directory, e.g. `~/.cache`.",
+                        Optional:            true,
+                    },
+                },
+            },
+            "awx": schema.SingleNestedAttribute{
+                MarkdownDescription: "The AWX or Automation Controller the `ansible_awx_*` resources launch jobs on. The attributes default to the environment variables of the `awx.awx` collection, like `CONTROLLER_HOST` and `CONTROLLER_OAUTH_TOKEN`.",
+                Optional:            true,
+                Attributes: map[string]schema.Attribute{
+                    "url": schema.StringAttribute{
+                        MarkdownDescription: "URL of AWX, e.g. `https://awx.example.com`. Defaults to `CONTROLLER_HOST`.",
+                        Optional:            true,
+                    },
+                    "token": schema.StringAttribute{
+                        MarkdownDescription: "OAuth2 token to authenticate with. Defaults to `CONTROLLER_OAUTH_TOKEN`.",
+                        Optional:            true,
+                        Sensitive:           true,
+                    },
+                    "username": schema.StringAttribute{
+                        MarkdownDescription: "User to authenticate as without a token. Defaults to `CONTROLLER_USERNAME`.",
+                        Optional:            true,
+                    },
+                    "password": schema.StringAttribute{
+                        MarkdownDescription: "Password of the user. Defaults to `CONTROLLER_PASSWORD`.",
+                        Optional:            true,
+                        Sensitive:           true,
+                    },
+                    "insecure": schema.BoolAttribute{
+                        MarkdownDescription: "Don't validate the TLS certificate of AWX. Defaults to false.",
+                        Optional:            true,
+                    },
+                },
+            },
+            "semaphore": schema.SingleNestedAttribute{
+                MarkdownDescription: "The Ansible Semaphore the `ansible_semaphore_task` resources run task templates on.",
+                Optional:            true,
+                Attributes: map[string]schema.Attribute{
+                    "url": schema.StringAttribute{
+                        MarkdownDescription: "URL of Semaphore, e.g. `https://semaphore.example.com`.",
+                        Required:            true,
+                    },
+                    "token": schema.StringAttribute{
+                        MarkdownDescription: "API token to authenticate with.",
+                        Required:            true,
+                        Sensitive:           true,
+                    },
+                    "insecure": schema.BoolAttribute{
+                        MarkdownDescription: "Don't validate the TLS certificate of Semaphore. Defaults to false.",
+                        Optional:            true,
+                    },
+                },
+            },
+            "eda": schema.SingleNestedAttribute{
+                MarkdownDescription: "The Event-Driven Ansible controller the `ansible_eda_rulebook_activation` resources manage activations on.",
+                Optional:            true,
+                Attributes: map[string]schema.Attribute{
+                    "url": schema.StringAttribute{
+                        MarkdownDescription: "URL of the EDA controller, e.g. `https://eda.example.com`, or of the platform gateway.",
+                        Required:            true,
+                    },
+                    "token": schema.StringAttribute{
+                        MarkdownDescription: "OAuth2 token to authenticate with.",
+                        Optional:            true,
+                        Sensitive:           true,
+                    },
+                    "username": schema.StringAttribute{
+                        MarkdownDescription: "User to authenticate as without a token.",
+                        Optional:            true,
+                    },
+                    "password": schema.StringAttribute{
+                        MarkdownDescription: "Password of the user.",
+                        Optional:            true,
+                        Sensitive:           true,
+                    },
+                    "insecure": schema.BoolAttribute{
+                        MarkdownDescription: "Don't validate the TLS certificate of the EDA controller. Defaults to false.",
+                        Optional:            true,
+                    },
+                },
+            },
+            "log_forwarding": schema.SingleNestedAttribute{
+                MarkdownDescription: "Forward the lines of stdout and stderr of every playbook run while it runs, redacted and tagged with a run ID, the resource ID and the playbook, e.g. for a central audit of infrastructure changes. Lines are dropped rather than slowing down runs if the endpoints don't keep up.",
+                Optional:            true,
+                Attributes: map[string]schema.Attribute{
+                    "syslog": schema.StringAttribute{
+                        MarkdownDescription: "The syslog server to send the lines to in the RFC 5424 format, `udp://host:port` or `tcp://host:port`.",
+                        Optional:            true,
+                    },
+                    "http_url": schema.StringAttribute{
+                        MarkdownDescription: "URL to POST the lines to as JSON arrays of objects with `time`, `stream`, `line`, `run_id`, `resource_id` and `playbook`, at least every second.",
+                        Optional:            true,
+                    },
+                    "headers": schema.MapAttribute{
+                        MarkdownDescription: "Headers sent to `http_url`, e.g. for authentication.",
+                        Optional:            true,
+                        Sensitive:           true,
+                        ElementType:         types.StringType,
+                    },
+                },
+            },
+            "telemetry": schema.SingleNestedAttribute{
+                MarkdownDescription: "Export an OpenTelemetry trace of every playbook run, with spans for its plays and tasks, and metrics about the runs via OTLP/HTTP.",
+                Optional:            true,
+                Attributes: map[string]schema.Attribute{
+                    "endpoint": schema.StringAttribute{
+                        MarkdownDescription: "Base URL of the OTLP/HTTP collector, e.g. `https://otel-collector:4318`.",
+                        Required:            true,
+                    },
+                    "insecure": schema.BoolAttribute{
+                        MarkdownDescription: "Use HTTP instead of HTTPS. Defaults to false.",
+                        Optional:            true,
+                    },
+                    "headers": schema.MapAttribute{
+                        MarkdownDescription: "Headers sent with every export, e.g. for authentication.",
+                        Optional:            true,
+                        Sensitive:           true,
+                        ElementType:         types.StringType,
+                    },
+                    "service_name": schema.StringAttribute{
+                        MarkdownDescription: "The `service.name` of the exported telemetry. Defaults to `terraform-provider-ansible`.",
+                        Optional:            true,
+                    },
+                },
+            },
+        },
+    }
 }
 
 // Configure prepares a HashiCups API client for data sources and resources.
 func (p *AnsibleProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+    var config AnsibleProviderModel
+    resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+    if resp.Diagnostics.HasError() {
+        return
+    }
+
+    maxAge := defaultTempFileMaxAge
+    if !config.TempFileMaxAge.IsNull() && !config.TempFileMaxAge.IsUnknown() {
+        var err error
+        maxAge, err = time.ParseDuration(config.TempFileMaxAge.ValueString())
+        if err != nil {
+            resp.Diagnostics.AddAttributeError(path.Root("temp_file_max_age"), "Invalid duration", err.Error())
+            return
+        }
+    }
+
+    if maxAge > 0 {
+        RemoveStaleTempFiles(ctx, maxAge)
+    }
+
+    providerData := &ProviderData{}
+
+    if config.Telemetry != nil {
+        telemetryConfig := TelemetryConfig{
+            Endpoint:    config.Telemetry.Endpoint.ValueString(),
+            Insecure:    config.Telemetry.Insecure.ValueBool(),
+            ServiceName: "terraform-provider-ansible",
+        }
+        if !config.Telemetry.ServiceName.IsNull() {
+            telemetryConfig.ServiceName = config.Telemetry.ServiceName.ValueString()
+        }
+        resp.Diagnostics.Append(config.Telemetry.Headers.ElementsAs(ctx, &telemetryConfig.Headers, false)...)
+
+        telemetry, err := NewTelemetry(ctx, telemetryConfig)
+        if err != nil {
+            resp.Diagnostics.AddAttributeError(path.Root("telemetry"), "Failed to set up telemetry", err.Error())
+            return
+        }
+        providerData.Telemetry = telemetry
+    }
+
+    if !config.RunHistoryFile.IsNull() && len(config.RunHistoryFile.ValueString()) > 0 {
+        providerData.RunHistory = NewRunHistory(config.RunHistoryFile.ValueString())
+    }
+
+    if !config.ArtifactDir.IsNull() && len(config.ArtifactDir.ValueString()) > 0 {
+        retention := int64(defaultArtifactRetention)
+        if !config.ArtifactRetention.IsNull() && !config.ArtifactRetention.IsUnknown() {
+            retention = config.ArtifactRetention.ValueInt64()
+        }
+        if retention < 0 {
+            resp.Diagnostics.AddAttributeError(path.Root("artifact_retention"), "Invalid artifact retention", "The retention must not be negative.")
+            return
+        }
+        providerData.ArtifactStore = NewArtifactStore(config.ArtifactDir.ValueString(), int(retention))
+    }
+
+    providerData.PythonInterpreter = config.PythonInterpreter.ValueString()
+
+    resp.Diagnostics.Append(config.Environment.ElementsAs(ctx, &providerData.Environment, false)...)
+    if resp.Diagnostics.HasError() {
+        return
+    }
+
+    validateOneOf(path.Root("binary_check"), config.BinaryCheck, binaryChecks, &resp.Diagnostics)
+    if resp.Diagnostics.HasError() {
+        return
+    }
+    providerData.StrictBinaryCheck = config.BinaryCheck.ValueString() == "strict"
+
+    if !config.MaxConnections.IsNull() && !config.MaxConnections.IsUnknown() {
+        if config.MaxConnections.ValueInt64() < 1 {
+            resp.Diagnostics.AddAttributeError(path.Root("max_connections"), "Invalid max connections", "max_connections must be at least 1.")
+            return
+        }
+        providerData.ConnectionLimiter = NewConnectionLimiter(int(config.MaxConnections.ValueInt64()))
+    }
+
+    if config.Galaxy != nil {
+        for i, server := range config.Galaxy.Servers {
+            if !server.Name.IsUnknown() && !galaxyServerNameRegexp.MatchString(server.Name.ValueString()) {
+                resp.Diagnostics.AddAttributeError(path.Root("galaxy").AtName("servers").AtListIndex(i).AtName("name"), "Invalid galaxy server name",
+                    "The name may only contain letters, digits and underscores.")
+            }
+        }
+        if resp.Diagnostics.HasError() {
+            return
+        }
+        providerData.Galaxy = NewGalaxyConfig(config.Galaxy)
+    }
+
+    if config.AWX != nil {
+        providerData.AWX = NewAWXClient(config.AWX)
+        if len(providerData.AWX.URL) == 0 {
+            resp.Diagnostics.AddAttributeError(path.Root("awx").AtName("url"), "Missing AWX URL", "Set url or CONTROLLER_HOST.")
+            return
+        }
+    }
+
+    if config.Semaphore != nil {
+        providerData.Semaphore = NewSemaphoreClient(config.Semaphore)
+    }
+
+    if config.EDA != nil {
+        providerData.EDA = NewEDAClient(config.EDA)
+    }
+
+    if config.LogForwarding != nil {
+        logForwarder, err := NewLogForwarder(ctx, config.LogForwarding)
+        if err != nil {
+            resp.Diagnostics.AddAttributeError(path.Root("log_forwarding"), "Invalid log forwarding", err.Error())
+            return
+        }
+        providerData.LogForwarder = logForwarder
+    }
+
+    resp.DataSourceData = providerData
+    resp.ResourceData = providerData
 }
 
 // DataSources defines the data sources implemented in the provider.
 func (p *AnsibleProvider) DataSources(_ context.Context) []func() datasource.DataSource {
-    return nil
+    return []func() datasource.DataSource {
+        NewPlaybookDataSource,
+        NewInventoryListDataSource,
+        NewConfigDumpDataSource,
+        NewPlaybookTasksDataSource,
+        NewAnsibleLintDataSource,
+        NewFactsDataSource,
+    }
 }
 
 // Resources defines the resources implemented in the provider.
 func (p *AnsibleProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource {
 		NewPlaybookResource,
+		NewRoleResource,
+		NewPlaybookSetResource,
+		NewPlaybookFanoutResource,
+		NewVaultFileResource,
+		NewAWXJobResource,
+		NewAWXWorkflowJobResource,
+		NewSemaphoreTaskResource,
+		NewEDARulebookActivationResource,
+    }
+}
+
+// Functions defines the provider-defined functions implemented in the provider.
+func (p *AnsibleProvider) Functions(_ context.Context) []func() function.Function {
+    return []func() function.Function {
+        NewInventoryFunction,
+        NewVaultEncryptFunction,
+        NewVaultDecryptFunction,
+        NewQueryFunction,
+        NewPlaybookHashFunction,
+        NewDecompressOutputFunction,
     }
 }
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &QueryFunction{}
+
+func NewQueryFunction() function.Function {
+	return &QueryFunction{}
+}
+
+type QueryFunction struct {
+}
+
+func (f *QueryFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "query"
+}
+
+func (f *QueryFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Query JSON with JSONPath",
+		MarkdownDescription: "Queries a JSON document with [JSONPath](https://goessner.net/articles/JsonPath/), the same way as the `jsonpath` of `artifact_queries` does, e.g. to post-process a stored playbook artifact.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "json",
+				MarkdownDescription: "The JSON document to query.",
+			},
+			function.StringParameter{
+				Name:                "expression",
+				MarkdownDescription: "JSONPath expression, e.g. `.plays[0].tasks[*].hosts.*.msg`.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *QueryFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var document, expression string
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &document, &expression))
+	if resp.Error != nil {
+		return
+	}
+
+	query := ArtifactQuery{JSONPath: expression}
+	blob, err := decodeJSON(strings.NewReader(document))
+	if err == nil {
+		err = jsonPath(blob, &query)
+	}
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, query.Result))
+}
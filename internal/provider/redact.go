@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const redactedPlaceholder = "********"
+
+// ANSI escape sequences: colors and other CSI sequences, OSC sequences like
+// hyperlinks, and single character escapes
+var ansiEscapeRegexp = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// Replaces secret values and matches of patterns in any text the provider
+// stores in the state, writes to the log or reports as a diagnostic.
+type Redactor struct {
+	secrets   []string
+	patterns  []*regexp.Regexp
+	stripANSI bool
+}
+
+func NewRedactor(secrets []string) *Redactor {
+	var filtered []string
+	seen := map[string]bool{}
+	for _, secret := range secrets {
+		if len(secret) == 0 {
+			continue
+		}
+		// Secrets with quotes, backslashes, newlines or other special
+		// characters appear escaped in JSON, e.g. in the artifact of the
+		// json callback
+		for _, form := range append([]string{secret}, jsonEscapedForms(secret)...) {
+			if !seen[form] {
+				seen[form] = true
+				filtered = append(filtered, form)
+			}
+		}
+	}
+
+	// Replace longer secrets first, so a secret containing another one
+	// doesn't leave parts of itself behind
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return len(filtered[i]) > len(filtered[j])
+	})
+
+	return &Redactor{secrets: filtered}
+}
+
+// The ways JSON encoders escape the secret inside of a string: Go's, with and
+// without escaping HTML characters, and Python's, which also escapes
+// everything but ASCII by default
+func jsonEscapedForms(secret string) []string {
+	var forms []string
+	if encoded, err := json.Marshal(secret); err == nil {
+		forms = append(forms, string(encoded[1:len(encoded)-1]))
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(secret); err != nil {
+		return forms
+	}
+	encoded := strings.TrimSpace(buffer.String())
+	encoded = encoded[1 : len(encoded)-1]
+	forms = append(forms, encoded)
+
+	var ascii strings.Builder
+	for _, r := range encoded {
+		switch {
+		case r < utf8.RuneSelf:
+			ascii.WriteRune(r)
+		case r > 0xffff:
+			high, low := utf16.EncodeRune(r)
+			fmt.Fprintf(&ascii, "\\u%04x\\u%04x", high, low)
+		default:
+			fmt.Fprintf(&ascii, "\\u%04x", r)
+		}
+	}
+	return append(forms, ascii.String())
+}
+
+// A redactor replacing the additional secrets as well, leaving r as it is
+func (r *Redactor) WithSecrets(secrets ...string) *Redactor {
+	if r == nil {
+		return NewRedactor(secrets)
+	}
+	redactor := NewRedactor(append(append([]string{}, r.secrets...), secrets...))
+	redactor.patterns = r.patterns
+	redactor.stripANSI = r.stripANSI
+	return redactor
+}
+
+// A redactor replacing the matches of patterns as well, after the secrets,
+// leaving r as it is
+func (r *Redactor) WithPatterns(patterns []*regexp.Regexp) *Redactor {
+	redactor := r.copy()
+	redactor.patterns = patterns
+	return redactor
+}
+
+// A redactor removing ANSI escape sequences as well, before the secrets are
+// replaced so colored secrets are found, leaving r as it is
+func (r *Redactor) WithStripANSI(stripANSI bool) *Redactor {
+	redactor := r.copy()
+	redactor.stripANSI = stripANSI
+	return redactor
+}
+
+func (r *Redactor) copy() *Redactor {
+	if r == nil {
+		return &Redactor{}
+	}
+	redactor := *r
+	return &redactor
+}
+
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+
+	if r.stripANSI {
+		text = ansiEscapeRegexp.ReplaceAllLiteralString(text, "")
+	}
+
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redactedPlaceholder)
+	}
+	for _, pattern := range r.patterns {
+		text = pattern.ReplaceAllLiteralString(text, redactedPlaceholder)
+	}
+
+	return text
+}
+
+// Copy src to dst redacted line by line, so large output isn't read into
+// memory at once. The json callback escapes newlines in strings, so secrets
+// in its output don't span lines.
+func (r *Redactor) RedactLines(dst io.Writer, src io.Reader) error {
+	reader := bufio.NewReader(src)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if _, writeErr := io.WriteString(dst, r.Redact(line)); writeErr != nil {
+				return writeErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Compile the regular expressions of redact_patterns. Unknown patterns are
+// left out.
+func RedactPatterns(ctx context.Context, value types.List, diags *diag.Diagnostics) []*regexp.Regexp {
+	var expressions []types.String
+	diags.Append(value.ElementsAs(ctx, &expressions, false)...)
+
+	var patterns []*regexp.Regexp
+	for i, expression := range expressions {
+		if expression.IsNull() || expression.IsUnknown() {
+			continue
+		}
+		pattern, err := regexp.Compile(expression.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("redact_patterns").AtListIndex(i), "Invalid regular expression", err.Error())
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// A redactor of the redact_patterns of the resource, e.g. for the commands
+// of the checks at plan. Invalid patterns are reported by ValidateConfig, and
+// unknown ones are left out.
+func PatternRedactor(ctx context.Context, data *PlaybookResourceModel) *Redactor {
+	var diags diag.Diagnostics
+	if data.RedactPatterns.IsUnknown() {
+		return NewRedactor(nil)
+	}
+	return NewRedactor(nil).WithPatterns(RedactPatterns(ctx, data.RedactPatterns, &diags))
+}
@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRedactorRedact(t *testing.T) {
+	tests := []struct {
+		name     string
+		redactor *Redactor
+		text     string
+		want     string
+	}{
+		{
+			name:     "nil",
+			redactor: nil,
+			text:     "password hunter2",
+			want:     "password hunter2",
+		},
+		{
+			name:     "no secrets",
+			redactor: NewRedactor(nil),
+			text:     "password hunter2",
+			want:     "password hunter2",
+		},
+		{
+			name:     "secret",
+			redactor: NewRedactor([]string{"hunter2", ""}),
+			text:     "password hunter2, again hunter2",
+			want:     "password ********, again ********",
+		},
+		{
+			name:     "several secrets",
+			redactor: NewRedactor([]string{"hunter2", "s3cr3t"}),
+			text:     "hunter2 and s3cr3t",
+			want:     "******** and ********",
+		},
+		{
+			name:     "longer secret first",
+			redactor: NewRedactor([]string{"pass", "passphrase"}),
+			text:     "passphrase and pass",
+			want:     "******** and ********",
+		},
+		{
+			name:     "JSON escaped quotes and backslashes",
+			redactor: NewRedactor([]string{`pa"ss\word`}),
+			text:     `{"password": "pa\"ss\\word"}`,
+			want:     `{"password": "********"}`,
+		},
+		{
+			name:     "JSON escaped newline",
+			redactor: NewRedactor([]string{"line1\nline2"}),
+			text:     `{"key": "line1\nline2"}`,
+			want:     `{"key": "********"}`,
+		},
+		{
+			name:     "JSON escaped HTML characters",
+			redactor: NewRedactor([]string{"a<b>&c"}),
+			text:     `{"go": "a\u003cb\u003e\u0026c", "python": "a<b>&c"}`,
+			want:     `{"go": "********", "python": "********"}`,
+		},
+		{
+			name:     "Python escaped non-ASCII",
+			redactor: NewRedactor([]string{"pässwörd😀"}),
+			text:     `{"password": "p\u00e4ssw\u00f6rd\ud83d\ude00"}`,
+			want:     `{"password": "********"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.redactor.Redact(test.text); got != test.want {
+				t.Errorf("Redact(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRedactorPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		secrets  []string
+		patterns []string
+		text     string
+		want     string
+	}{
+		{
+			name:     "match",
+			patterns: []string{`token=\w+`},
+			text:     "url?token=abc123&page=2",
+			want:     "url?********&page=2",
+		},
+		{
+			name:     "several matches",
+			patterns: []string{`ghp_[A-Za-z0-9]+`},
+			text:     "ghp_abc and ghp_XYZ",
+			want:     "******** and ********",
+		},
+		{
+			name:     "several patterns",
+			patterns: []string{`[a-z0-9-]+\.internal\.example\.com`, `\d+\.\d+\.\d+\.\d+`},
+			text:     "db-1.internal.example.com at 10.0.0.1",
+			want:     "******** at ********",
+		},
+		{
+			name:     "no match",
+			patterns: []string{`token=\w+`},
+			text:     "nothing to see",
+			want:     "nothing to see",
+		},
+		{
+			name:     "secrets first",
+			secrets:  []string{"abc"},
+			patterns: []string{`token=\w+`},
+			text:     "token=abc1",
+			want:     "token=********1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var patterns []*regexp.Regexp
+			for _, pattern := range test.patterns {
+				patterns = append(patterns, regexp.MustCompile(pattern))
+			}
+			redactor := NewRedactor(test.secrets).WithPatterns(patterns)
+			if got := redactor.Redact(test.text); got != test.want {
+				t.Errorf("Redact(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRedactPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []attr.Value
+		want     int
+		invalid  bool
+	}{
+		{name: "valid", patterns: []attr.Value{types.StringValue(`token=\w+`), types.StringValue(`ghp_.*`)}, want: 2},
+		{name: "unknown left out", patterns: []attr.Value{types.StringValue(`token=\w+`), types.StringUnknown()}, want: 1},
+		{name: "invalid", patterns: []attr.Value{types.StringValue(`token=(`)}, want: 0, invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			patterns := RedactPatterns(context.Background(), types.ListValueMust(types.StringType, test.patterns), &diags)
+			if len(patterns) != test.want {
+				t.Errorf("%d patterns, want %d", len(patterns), test.want)
+			}
+			if diags.HasError() != test.invalid {
+				t.Errorf("errors: %v", diags)
+			}
+		})
+	}
+}
+
+func TestRedactorStripANSI(t *testing.T) {
+	tests := []struct {
+		name      string
+		stripANSI bool
+		text      string
+		want      string
+	}{
+		{name: "colors", stripANSI: true, text: "\x1b[0;32mok\x1b[0m: [host1]", want: "ok: [host1]"},
+		{name: "bold and colors", stripANSI: true, text: "\x1b[1;31mfatal\x1b[0m", want: "fatal"},
+		{name: "hyperlink", stripANSI: true, text: "\x1b]8;;https://example.com\x07link\x1b]8;;\x07", want: "link"},
+		{name: "colored secret", stripANSI: true, text: "\x1b[0;31mhunt\x1b[0mer2", want: "********"},
+		{name: "disabled", stripANSI: false, text: "\x1b[0;32mok\x1b[0m", want: "\x1b[0;32mok\x1b[0m"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			redactor := NewRedactor([]string{"hunter2"}).WithStripANSI(test.stripANSI)
+			if got := redactor.Redact(test.text); got != test.want {
+				t.Errorf("Redact(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRedactorWithCopies(t *testing.T) {
+	pattern := regexp.MustCompile(`token=\w+`)
+	text := "\x1b[0;31mhunter2\x1b[0m token=abc swordfish"
+
+	base := NewRedactor([]string{"hunter2"})
+	combined := base.WithPatterns([]*regexp.Regexp{pattern}).WithStripANSI(true).WithSecrets("swordfish")
+
+	if got, want := base.Redact(text), "\x1b[0;31m********\x1b[0m token=abc swordfish"; got != want {
+		t.Errorf("base Redact() = %q, want %q", got, want)
+	}
+	if got, want := combined.Redact(text), "******** ******** ********"; got != want {
+		t.Errorf("combined Redact() = %q, want %q", got, want)
+	}
+
+	var nilRedactor *Redactor
+	if got, want := nilRedactor.WithPatterns([]*regexp.Regexp{pattern}).Redact(text), "\x1b[0;31mhunter2\x1b[0m ******** swordfish"; got != want {
+		t.Errorf("nil WithPatterns Redact() = %q, want %q", got, want)
+	}
+	if got, want := nilRedactor.WithStripANSI(true).Redact(text), "hunter2 token=abc swordfish"; got != want {
+		t.Errorf("nil WithStripANSI Redact() = %q, want %q", got, want)
+	}
+}
@@ -1,15 +1,24 @@
 package provider
 
 import (
-	"bytes"
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
 )
 
 // Define structs to match the JSON structure
 type HostStats struct {
+	Ok          int `json:"ok"`
+	Changed     int `json:"changed"`
 	Failures    int `json:"failures"`
 	Unreachable int `json:"unreachable"`
+	Skipped     int `json:"skipped"`
+	Rescued     int `json:"rescued"`
+	Ignored     int `json:"ignored"`
 }
 
 type Stats map[string]HostStats
@@ -30,11 +39,16 @@ func (m *MsgType) UnmarshalJSON(data []byte) error {
 }
 
 type Result struct {
-	Failed bool    `json:"failed"`
-	Stderr string  `json:"stderr"`
-	Stdout string  `json:"stdout"`
-	Msg    MsgType `json:"msg"`
-	Reason string  `json:"reason"`
+	Failed     bool    `json:"failed"`
+	Changed    bool    `json:"changed"`
+	Skipped    bool    `json:"skipped"`
+	SkipReason string  `json:"skip_reason"`
+	Stderr     string  `json:"stderr"`
+	Stdout     string  `json:"stdout"`
+	Msg        MsgType `json:"msg"`
+	Reason     string  `json:"reason"`
+	// A diff or a list of diffs, see ParseModuleDiffs
+	Diff json.RawMessage `json:"diff"`
 }
 
 type Host struct {
@@ -43,16 +57,36 @@ type Host struct {
 	Results     []Result `json:"results"`
 }
 
+// Start and end of a play or task, as reported by the json callback
+type Duration struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+func (d Duration) Times() (time.Time, time.Time, bool) {
+	start, err := time.Parse(time.RFC3339Nano, d.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	end, err := time.Parse(time.RFC3339Nano, d.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
 type Task struct {
 	Hosts map[string]Host `json:"hosts"`
 	Task  struct {
-		Name string `json:"name"`
+		Name     string   `json:"name"`
+		Duration Duration `json:"duration"`
 	} `json:"task"`
 }
 
 type Play struct {
 	Play struct {
-		Name string `json:"name"`
+		Name     string   `json:"name"`
+		Duration Duration `json:"duration"`
 	} `json:"play"`
 	Tasks []Task `json:"tasks"`
 }
@@ -62,6 +96,134 @@ type Root struct {
 	Stats Stats  `json:"stats"`
 }
 
+// Decode the output of the JSON callback from r. The plays and their tasks
+// are decoded one task at a time, so only the fields of Root are held in
+// memory and never the whole artifact, which may be hundreds of MB for large
+// inventories. If the output ends early, e.g. because ansible was killed,
+// the plays and tasks decoded until then are returned with the error.
+func DecodeArtifact(r io.Reader) (Root, error) {
+	var root Root
+	decoder := json.NewDecoder(r)
+
+	err := decodeObject(decoder, func(key string) error {
+		switch key {
+		case "plays":
+			return decodeArray(decoder, func() error {
+				play, err := decodePlay(decoder)
+				root.Plays = append(root.Plays, play)
+				return err
+			})
+		case "stats":
+			return decoder.Decode(&root.Stats)
+		default:
+			return skipValue(decoder)
+		}
+	})
+	return root, err
+}
+
+func decodePlay(decoder *json.Decoder) (Play, error) {
+	var play Play
+	err := decodeObject(decoder, func(key string) error {
+		switch key {
+		case "play":
+			return decoder.Decode(&play.Play)
+		case "tasks":
+			return decodeArray(decoder, func() error {
+				var task Task
+				if err := decoder.Decode(&task); err != nil {
+					return err
+				}
+				play.Tasks = append(play.Tasks, task)
+				return nil
+			})
+		default:
+			return skipValue(decoder)
+		}
+	})
+	return play, err
+}
+
+// Decode a JSON object, calling decodeValue with the decoder at the value of
+// every key
+func decodeObject(decoder *json.Decoder, decodeValue func(key string) error) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("expected an object key, got %v", token)
+		}
+		if err := decodeValue(key); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, '}')
+}
+
+// Decode a JSON array, calling decodeElement with the decoder at every
+// element
+func decodeArray(decoder *json.Decoder, decodeElement func() error) error {
+	if err := expectDelim(decoder, '['); err != nil {
+		return err
+	}
+	for decoder.More() {
+		if err := decodeElement(); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// The offset of the JSON document in output that may have other text before
+// it, like warnings of plugins printed to stdout: the first line starting
+// with {. 0 if there's none, to report the decoding error of the whole output.
+func jsonDocumentOffset(r io.Reader) int64 {
+	reader := bufio.NewReader(r)
+	var offset int64
+	for {
+		first, err := reader.Peek(1)
+		if err != nil {
+			return 0
+		}
+		if first[0] == '{' {
+			return offset
+		}
+
+		for {
+			line, err := reader.ReadSlice('\n')
+			offset += int64(len(line))
+			if err == nil {
+				break
+			}
+			if err != bufio.ErrBufferFull {
+				return 0
+			}
+		}
+	}
+}
+
+func skipValue(decoder *json.Decoder) error {
+	var value json.RawMessage
+	return decoder.Decode(&value)
+}
+
 func printFailedInfo(result Result, indent string) string {
 	output := ""
 
@@ -81,15 +243,36 @@ func printFailedInfo(result Result, indent string) string {
 	return output
 }
 
-func AnalyzeJSON(buffer bytes.Buffer) (string, bool, error) {
-	var root Root
-	if err := json.Unmarshal(buffer.Bytes(), &root); err != nil {
-		return "", false, err
+// A failed task on a single host
+type TaskFailure struct {
+	Play        string
+	Task        string
+	Host        string
+	Unreachable bool
+	Details     string
+}
+
+func (f TaskFailure) Summary() string {
+	if f.Unreachable {
+		return fmt.Sprintf("Host %s unreachable in task %s", f.Host, f.Task)
 	}
+	return fmt.Sprintf("Task %s failed on %s", f.Task, f.Host)
+}
+
+func (f TaskFailure) Detail() string {
+	output := fmt.Sprintf("PLAY <%s>\nTASK <%s>\nHOST <%s>\n", f.Play, f.Task, f.Host)
+	if len(f.Details) > 0 {
+		output += "\n" + f.Details
+	}
+	return output
+}
 
+// Every failed task per host of the decoded artifact, in the order the tasks
+// ran. The bool reports whether the stats recorded any failed or unreachable
+// host. Without stats, e.g. if the output was cut off, every task is checked.
+func (root Root) Failures() ([]TaskFailure, bool) {
 	// Check for failures or unreachable hosts
 	failureDetected := false
-	output := ""
 	for _, stat := range root.Stats {
 		if stat.Failures > 0 || stat.Unreachable > 0 {
 			failureDetected = true
@@ -97,42 +280,85 @@ func AnalyzeJSON(buffer bytes.Buffer) (string, bool, error) {
 		}
 	}
 
-	if failureDetected {
+	var failures []TaskFailure
+	if failureDetected || root.Stats == nil {
 		for _, play := range root.Plays {
-			playHeaderPrinted := false
 			for _, task := range play.Tasks {
-				taskHeaderPrinted := false
-				for hostName, host := range task.Hosts {
-					if host.Failed || host.Unreachable {
-						if !playHeaderPrinted {
-							output += fmt.Sprintf("PLAY <%s>\n", play.Play.Name)
-							playHeaderPrinted = true
-						}
-						if !taskHeaderPrinted {
-							output += fmt.Sprintf("  TASK <%s>\n", task.Task.Name)
-							taskHeaderPrinted = true
-						}
+				hostNames := make([]string, 0, len(task.Hosts))
+				for hostName := range task.Hosts {
+					hostNames = append(hostNames, hostName)
+				}
+				sort.Strings(hostNames)
 
-						output += fmt.Sprintf("    HOST <%s>\n", hostName)
-
-						output += printFailedInfo(host.Result, "      ")
-						if host.Results != nil && len(host.Results) > 0 {
-							resultsOutput := ""
-							for _, result := range host.Results {
-								if result.Failed {
-									resultsOutput += printFailedInfo(result, "        ")
-								}
-							}
-
-							if len(resultsOutput) > 0 {
-								output += "      RESULTS\n"
-								output += resultsOutput
-							}
+				for _, hostName := range hostNames {
+					host := task.Hosts[hostName]
+					if !host.Failed && !host.Unreachable {
+						continue
+					}
+
+					details := printFailedInfo(host.Result, "")
+					resultsOutput := ""
+					for _, result := range host.Results {
+						if result.Failed {
+							resultsOutput += printFailedInfo(result, "  ")
 						}
 					}
+					if len(resultsOutput) > 0 {
+						details += "RESULTS\n" + resultsOutput
+					}
+
+					failures = append(failures, TaskFailure{
+						Play:        play.Play.Name,
+						Task:        task.Task.Name,
+						Host:        hostName,
+						Unreachable: host.Unreachable,
+						Details:     details,
+					})
 				}
 			}
 		}
 	}
-	return output, failureDetected, nil
+	if root.Stats == nil {
+		failureDetected = len(failures) > 0
+	}
+	return failures, failureDetected
+}
+
+// The outcome of a run per host, according to the stats
+type HostSummary struct {
+	Failed      []string
+	Unreachable []string
+	Total       int
+}
+
+// Summarize the stats of the decoded artifact. Host names are sorted.
+func (root Root) HostSummary() HostSummary {
+	summary := HostSummary{Total: len(root.Stats)}
+	for hostName, stat := range root.Stats {
+		if stat.Failures > 0 {
+			summary.Failed = append(summary.Failed, hostName)
+		}
+		if stat.Unreachable > 0 {
+			summary.Unreachable = append(summary.Unreachable, hostName)
+		}
+	}
+	sort.Strings(summary.Failed)
+	sort.Strings(summary.Unreachable)
+
+	return summary
+}
+
+// The hosts that count as failed, sorted. Unreachable hosts only count if
+// they aren't ignored.
+func (s HostSummary) FailedHosts(ignoreUnreachable bool) []string {
+	failed := append([]string{}, s.Failed...)
+	if !ignoreUnreachable {
+		for _, host := range s.Unreachable {
+			if !slices.Contains(failed, host) {
+				failed = append(failed, host)
+			}
+		}
+		sort.Strings(failed)
+	}
+	return failed
 }
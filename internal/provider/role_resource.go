@@ -0,0 +1,557 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"gopkg.in/yaml.v2"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &RoleResource{}
+var _ resource.ResourceWithImportState = &RoleResource{}
+var _ resource.ResourceWithValidateConfig = &RoleResource{}
+var _ resource.ResourceWithConfigure = &RoleResource{}
+var _ resource.ResourceWithModifyPlan = &RoleResource{}
+
+// The attributes of ansible_role that ansible_playbook doesn't have. They are
+// turned into the playbook that runs the role.
+var roleAttributes = []string{"role", "hosts", "gather_facts", "become", "project_dir"}
+
+// The attributes of ansible_playbook that ansible_role doesn't have, as they
+// select the playbook to run
+var playbookAttributes = []string{"playbook", "plays", "destroy_playbook", "ignore_destroy_failure", "destroy_mode"}
+
+func NewRoleResource() resource.Resource {
+	return &RoleResource{}
+}
+
+// RoleResource runs a single role with a playbook generated for each
+// operation. Everything else is the playbook resource: the values are
+// converted to its schema, with the generated playbook as playbook, and the
+// operations delegated to it.
+type RoleResource struct {
+	playbook PlaybookResource
+}
+
+// The attributes the playbook is generated from
+type RolePlayModel struct {
+	Role        types.String `tfsdk:"role"`
+	Hosts       types.String `tfsdk:"hosts"`
+	GatherFacts types.Bool   `tfsdk:"gather_facts"`
+	Become      types.Bool   `tfsdk:"become"`
+	ProjectDir  types.String `tfsdk:"project_dir"`
+}
+
+type rolePlay struct {
+	Name        string   `yaml:"name"`
+	Hosts       string   `yaml:"hosts"`
+	GatherFacts bool     `yaml:"gather_facts"`
+	Become      bool     `yaml:"become"`
+	Roles       []string `yaml:"roles"`
+}
+
+// The playbook that runs the role
+func (m RolePlayModel) Playbook() ([]byte, error) {
+	return m.playbook(m.roleReference())
+}
+
+func (m RolePlayModel) playbook(role string) ([]byte, error) {
+	return yaml.Marshal([]rolePlay{{
+		Name:        m.Role.ValueString(),
+		Hosts:       m.Hosts.ValueString(),
+		GatherFacts: m.GatherFacts.ValueBool(),
+		Become:      m.Become.ValueBool(),
+		Roles:       []string{role},
+	}})
+}
+
+// The playbook hash of the role: its files, the play and the options, with
+// the hash globs relative to the project directory. Unlike the hash of the
+// generated playbook, it doesn't depend on where that was written.
+func (m RolePlayModel) Hash(options PlaybookHashOptions) (string, error) {
+	roleHash, err := hashRole(m, options, DefaultFileDigestCache().Digest)
+
+	// The cache only speeds up the next plan, failing to persist it is harmless
+	_ = DefaultFileDigestCache().Save()
+
+	return roleHash, err
+}
+
+func hashRole(play RolePlayModel, options PlaybookHashOptions, digest FileDigest) (string, error) {
+	// The play as configured, the role by its name rather than the path it
+	// was resolved to
+	content, err := play.playbook(play.Role.ValueString())
+	if err != nil {
+		return "", fmt.Errorf("ERROR: couldn't generate the playbook of the role! %s", err)
+	}
+
+	projectDir := play.ProjectDir.ValueString()
+	filter, err := NewHashFilter(projectDir, options.Include, options.Exclude)
+	if err != nil {
+		return "", fmt.Errorf("ERROR: couldn't parse hash globs! %s", err)
+	}
+
+	hash := sha256.New()
+	if path, found := ResolveRole(projectDir, play.Role.ValueString()); found {
+		err := HashDirectory(hash, path, filter, digest)
+		if err != nil {
+			return "", fmt.Errorf("ERROR: couldn't hash the role! %s", err)
+		}
+	}
+
+	hash.Write(content)
+
+	for _, varFile := range options.VarFiles {
+		err = HashFile(hash, varFile, digest)
+		if err != nil {
+			return "", fmt.Errorf("ERROR: couldn't hash var file! %s", err)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// The role as the generated playbook refers to it. The playbook isn't in the
+// project directory, so a role of the project is referred to by its path,
+// anything else by its name for ansible to look up.
+func (m RolePlayModel) roleReference() string {
+	role := m.Role.ValueString()
+	if parts := strings.Split(role, "."); filepath.IsAbs(role) || (len(parts) == 3 && !strings.ContainsAny(role, `/\`)) {
+		return role
+	}
+
+	projectDir, err := filepath.Abs(m.ProjectDir.ValueString())
+	if err != nil {
+		return role
+	}
+
+	// The order ansible looks up roles in, relative to the playbook
+	if candidate := filepath.Join(projectDir, "roles", role); directoryExists(candidate) {
+		return candidate
+	}
+	for _, rolesPath := range RolesPaths() {
+		if directoryExists(filepath.Join(rolesPath, role)) {
+			return role
+		}
+	}
+	if candidate := filepath.Join(projectDir, role); directoryExists(candidate) {
+		return candidate
+	}
+	return role
+}
+
+func (r *RoleResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_role"
+}
+
+func (r *RoleResource) playbookSchema(ctx context.Context) schema.Schema {
+	var resp resource.SchemaResponse
+	r.playbook.Schema(ctx, resource.SchemaRequest{}, &resp)
+	return resp.Schema
+}
+
+func (r *RoleResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	playbookSchema := r.playbookSchema(ctx)
+
+	attributes := make(map[string]schema.Attribute, len(playbookSchema.Attributes))
+	for name, attribute := range playbookSchema.Attributes {
+		if !slices.Contains(playbookAttributes, name) {
+			attributes[name] = attribute
+		}
+	}
+
+	attributes["role"] = schema.StringAttribute{
+		MarkdownDescription: "The role to run, a name like in the `roles` of a play, e.g. `webserver` or the FQCN `namespace.collection.webserver`. Pass its variables with `extra_vars`, `sensitive_extra_vars` and `var_files`. Must be known during plan.",
+		Required:            true,
+	}
+	attributes["hosts"] = schema.StringAttribute{
+		MarkdownDescription: "The host pattern to run the role on. Defaults to `all`.",
+		Optional:            true,
+		Computed:            true,
+		Default:             stringdefault.StaticString("all"),
+	}
+	attributes["gather_facts"] = schema.BoolAttribute{
+		MarkdownDescription: "Whether to gather facts before running the role. Defaults to true.",
+		Optional:            true,
+		Computed:            true,
+		Default:             booldefault.StaticBool(true),
+	}
+	attributes["become"] = schema.BoolAttribute{
+		MarkdownDescription: "Whether to run the role with privilege escalation. Defaults to false.",
+		Optional:            true,
+		Computed:            true,
+		Default:             booldefault.StaticBool(false),
+	}
+	attributes["project_dir"] = schema.StringAttribute{
+		MarkdownDescription: "The directory of the project the role is in: the role is looked up in its `roles` directory and then in the roles path of ansible, as for a playbook in it, and `hash_include` and `hash_exclude` are relative to it. The generated playbook isn't written to it but to a private temporary directory, so `group_vars` and `host_vars` of the project aren't loaded, only those of the inventory. Defaults to the working directory.",
+		Optional:            true,
+		Computed:            true,
+		Default:             stringdefault.StaticString("."),
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Runs a single role against the inventory, without a playbook for it. A playbook with one play of the role is generated for every run, otherwise it works like `ansible_playbook`: the role runs again when it, its files or the variables change.",
+		Attributes:          attributes,
+	}
+}
+
+func (r *RoleResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.playbook.Configure(ctx, req, resp)
+}
+
+// Anything the attributes of the role can be read from: a config, plan or state
+type attributeGetter interface {
+	GetAttribute(ctx context.Context, path path.Path, target interface{}) diag.Diagnostics
+}
+
+// Read the attributes the playbook of the role is generated from. They must
+// be known by then.
+func (r *RoleResource) readPlay(ctx context.Context, values attributeGetter, diags *diag.Diagnostics) RolePlayModel {
+	var play RolePlayModel
+	diags.Append(values.GetAttribute(ctx, path.Root("role"), &play.Role)...)
+	diags.Append(values.GetAttribute(ctx, path.Root("hosts"), &play.Hosts)...)
+	diags.Append(values.GetAttribute(ctx, path.Root("gather_facts"), &play.GatherFacts)...)
+	diags.Append(values.GetAttribute(ctx, path.Root("become"), &play.Become)...)
+	diags.Append(values.GetAttribute(ctx, path.Root("project_dir"), &play.ProjectDir)...)
+	if diags.HasError() {
+		return play
+	}
+
+	unknown := map[string]bool{
+		"role":         play.Role.IsUnknown(),
+		"hosts":        play.Hosts.IsUnknown(),
+		"gather_facts": play.GatherFacts.IsUnknown(),
+		"become":       play.Become.IsUnknown(),
+		"project_dir":  play.ProjectDir.IsUnknown(),
+	}
+	for _, name := range roleAttributes {
+		if unknown[name] {
+			diags.AddAttributeError(path.Root(name), "Unknown role attribute",
+				fmt.Sprintf("%s must be known during plan, as the playbook of the role is generated from it.", name))
+		}
+	}
+	return play
+}
+
+// Write the playbook of the role of values for the playbook resource, and
+// hash the role instead of it. The playbook is written to a private
+// temporary directory, so ansible doesn't load anything else next to it.
+// Returns the path and a function to remove it.
+func (r *RoleResource) writePlaybook(ctx context.Context, values attributeGetter, diags *diag.Diagnostics) (string, func()) {
+	play := r.readPlay(ctx, values, diags)
+	if diags.HasError() {
+		return "", func() {}
+	}
+	r.playbook.playbookHash = play.Hash
+
+	content, err := play.Playbook()
+	if err != nil {
+		diags.AddError("Failed to generate the playbook of the role", err.Error())
+		return "", func() {}
+	}
+
+	dir, err := os.MkdirTemp("", TempFilePattern("role-*"))
+	if err != nil {
+		diags.AddError("Failed to write the playbook of the role", err.Error())
+		return "", func() {}
+	}
+	remove := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			diags.AddWarning(fmt.Sprintf("Failed to remove directory %s", dir), err.Error())
+		}
+	}
+
+	playbook := filepath.Join(dir, "playbook.yml")
+	if err := os.WriteFile(playbook, content, 0o600); err != nil {
+		diags.AddError("Failed to write the playbook of the role", err.Error())
+	}
+
+	return playbook, remove
+}
+
+// Convert a value of the role schema to the playbook schema
+func (r *RoleResource) toPlaybook(ctx context.Context, value tftypes.Value, playbook string, diags *diag.Diagnostics) tftypes.Value {
+	playbookType := r.playbookSchema(ctx).Type().TerraformType(ctx)
+	if value.IsNull() {
+		return tftypes.NewValue(playbookType, nil)
+	}
+
+	var attributes map[string]tftypes.Value
+	if err := value.As(&attributes); err != nil {
+		diags.AddError("Failed to convert the role to a playbook", err.Error())
+		return tftypes.NewValue(playbookType, nil)
+	}
+
+	// The map of As is the one of value, so it must not be modified
+	playbookValues := make(map[string]tftypes.Value, len(attributes))
+	for name, attribute := range attributes {
+		if !slices.Contains(roleAttributes, name) {
+			playbookValues[name] = attribute
+		}
+	}
+	playbookValues["playbook"] = tftypes.NewValue(tftypes.String, playbook)
+	playbookValues["plays"] = tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, nil)
+	playbookValues["destroy_playbook"] = tftypes.NewValue(tftypes.String, nil)
+	playbookValues["ignore_destroy_failure"] = tftypes.NewValue(tftypes.Bool, false)
+	playbookValues["destroy_mode"] = tftypes.NewValue(tftypes.String, nil)
+
+	return tftypes.NewValue(playbookType, playbookValues)
+}
+
+// Convert a value of the playbook schema back to the role schema, with the
+// role attributes of role
+func (r *RoleResource) toRole(ctx context.Context, value tftypes.Value, role tftypes.Value, diags *diag.Diagnostics) tftypes.Value {
+	var resp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &resp)
+	roleType := resp.Schema.Type().TerraformType(ctx)
+	if value.IsNull() || role.IsNull() {
+		return tftypes.NewValue(roleType, nil)
+	}
+
+	var attributes, roleValues map[string]tftypes.Value
+	if err := value.As(&attributes); err != nil {
+		diags.AddError("Failed to convert the playbook to a role", err.Error())
+		return tftypes.NewValue(roleType, nil)
+	}
+	if err := role.As(&roleValues); err != nil {
+		diags.AddError("Failed to convert the playbook to a role", err.Error())
+		return tftypes.NewValue(roleType, nil)
+	}
+
+	roleAttributeValues := make(map[string]tftypes.Value, len(attributes))
+	for name, attribute := range attributes {
+		if !slices.Contains(playbookAttributes, name) {
+			roleAttributeValues[name] = attribute
+		}
+	}
+	for _, name := range roleAttributes {
+		roleAttributeValues[name] = roleValues[name]
+	}
+
+	return tftypes.NewValue(roleType, roleAttributeValues)
+}
+
+func (r *RoleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	playbook, remove := r.writePlaybook(ctx, req.Plan, &resp.Diagnostics)
+	defer remove()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	playbookSchema := r.playbookSchema(ctx)
+	playbookReq := resource.CreateRequest{
+		Config:       tfsdk.Config{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.Config.Raw, playbook, &resp.Diagnostics)},
+		Plan:         tfsdk.Plan{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.Plan.Raw, playbook, &resp.Diagnostics)},
+		ProviderMeta: req.ProviderMeta,
+	}
+	playbookResp := resource.CreateResponse{
+		State:   tfsdk.State{Schema: playbookSchema, Raw: r.toPlaybook(ctx, resp.State.Raw, playbook, &resp.Diagnostics)},
+		Private: resp.Private,
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.playbook.Create(ctx, playbookReq, &playbookResp)
+
+	resp.Diagnostics.Append(playbookResp.Diagnostics...)
+	resp.State.Raw = r.toRole(ctx, playbookResp.State.Raw, req.Plan.Raw, &resp.Diagnostics)
+}
+
+func (r *RoleResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Nothing is read from the playbook, so it isn't written
+	playbookSchema := r.playbookSchema(ctx)
+	playbookReq := resource.ReadRequest{
+		State:        tfsdk.State{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.State.Raw, "", &resp.Diagnostics)},
+		Private:      req.Private,
+		ProviderMeta: req.ProviderMeta,
+	}
+	playbookResp := resource.ReadResponse{
+		State:   playbookReq.State,
+		Private: resp.Private,
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.playbook.Read(ctx, playbookReq, &playbookResp)
+
+	resp.Diagnostics.Append(playbookResp.Diagnostics...)
+	resp.State.Raw = r.toRole(ctx, playbookResp.State.Raw, req.State.Raw, &resp.Diagnostics)
+}
+
+func (r *RoleResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	playbook, remove := r.writePlaybook(ctx, req.Plan, &resp.Diagnostics)
+	defer remove()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	playbookSchema := r.playbookSchema(ctx)
+	playbookReq := resource.UpdateRequest{
+		Config:       tfsdk.Config{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.Config.Raw, playbook, &resp.Diagnostics)},
+		Plan:         tfsdk.Plan{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.Plan.Raw, playbook, &resp.Diagnostics)},
+		State:        tfsdk.State{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.State.Raw, playbook, &resp.Diagnostics)},
+		ProviderMeta: req.ProviderMeta,
+		Private:      req.Private,
+	}
+	playbookResp := resource.UpdateResponse{
+		State:   tfsdk.State{Schema: playbookSchema, Raw: r.toPlaybook(ctx, resp.State.Raw, playbook, &resp.Diagnostics)},
+		Private: resp.Private,
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.playbook.Update(ctx, playbookReq, &playbookResp)
+
+	resp.Diagnostics.Append(playbookResp.Diagnostics...)
+	resp.State.Raw = r.toRole(ctx, playbookResp.State.Raw, req.Plan.Raw, &resp.Diagnostics)
+}
+
+func (r *RoleResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	playbook, remove := r.writePlaybook(ctx, req.State, &resp.Diagnostics)
+	defer remove()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	playbookSchema := r.playbookSchema(ctx)
+	playbookReq := resource.DeleteRequest{
+		State:        tfsdk.State{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.State.Raw, playbook, &resp.Diagnostics)},
+		ProviderMeta: req.ProviderMeta,
+		Private:      req.Private,
+	}
+	playbookResp := resource.DeleteResponse{
+		State:   tfsdk.State{Schema: playbookSchema, Raw: r.toPlaybook(ctx, resp.State.Raw, playbook, &resp.Diagnostics)},
+		Private: resp.Private,
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.playbook.Delete(ctx, playbookReq, &playbookResp)
+
+	resp.Diagnostics.Append(playbookResp.Diagnostics...)
+}
+
+func (r *RoleResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	// The playbook isn't needed to validate the other attributes
+	playbookReq := resource.ValidateConfigRequest{
+		Config: tfsdk.Config{Schema: r.playbookSchema(ctx), Raw: r.toPlaybook(ctx, req.Config.Raw, "", &resp.Diagnostics)},
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.playbook.ValidateConfig(ctx, playbookReq, resp)
+}
+
+func (r *RoleResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	playbook, remove := r.writePlaybook(ctx, req.Plan, &resp.Diagnostics)
+	defer remove()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	playbookSchema := r.playbookSchema(ctx)
+	playbookReq := resource.ModifyPlanRequest{
+		Config:       tfsdk.Config{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.Config.Raw, playbook, &resp.Diagnostics)},
+		Plan:         tfsdk.Plan{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.Plan.Raw, playbook, &resp.Diagnostics)},
+		State:        tfsdk.State{Schema: playbookSchema, Raw: r.toPlaybook(ctx, req.State.Raw, playbook, &resp.Diagnostics)},
+		ProviderMeta: req.ProviderMeta,
+		Private:      req.Private,
+	}
+	playbookResp := resource.ModifyPlanResponse{
+		Plan:            tfsdk.Plan{Schema: playbookSchema, Raw: playbookReq.Plan.Raw},
+		RequiresReplace: resp.RequiresReplace,
+		Private:         resp.Private,
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.playbook.ModifyPlan(ctx, playbookReq, &playbookResp)
+
+	resp.Diagnostics.Append(playbookResp.Diagnostics...)
+	resp.RequiresReplace = playbookResp.RequiresReplace
+	resp.Plan.Raw = r.toRole(ctx, playbookResp.Plan.Raw, req.Plan.Raw, &resp.Diagnostics)
+}
+
+// Import an ID, or `<role>:<inventory file>`, which sets the role, the
+// inventory from the file and the hash of the role with the default play and
+// project directory, so the imported resource only runs again if the
+// configuration differs, like for ansible_playbook.
+func (r *RoleResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	role, inventoryFile, found := splitRoleImportID(req.ID)
+	if !found {
+		resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+		return
+	}
+
+	inventory, err := os.ReadFile(inventoryFile)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read the inventory", err.Error())
+		return
+	}
+	play := RolePlayModel{
+		Role:        types.StringValue(role),
+		Hosts:       types.StringValue("all"),
+		GatherFacts: types.BoolValue(true),
+		Become:      types.BoolValue(false),
+		ProjectDir:  types.StringValue("."),
+	}
+	hash, err := play.Hash(PlaybookHashOptions{})
+	if err != nil {
+		resp.Diagnostics.AddError("Error Calculating Playbook Hash", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), uuid.New().String())...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("role"), play.Role)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("hosts"), play.Hosts)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("gather_facts"), play.GatherFacts)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("become"), play.Become)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_dir"), play.ProjectDir)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("inventory"), string(inventory))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("playbook_hash"), hash)...)
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, importedPrivateKey, []byte("true"))...)
+}
+
+// Split an import ID of the form `<role>:<inventory file>` at the colon
+// followed by an existing file, as a role given by its path may contain
+// colons, like Windows drive letters
+func splitRoleImportID(id string) (string, string, bool) {
+	for i, c := range id {
+		if c != ':' {
+			continue
+		}
+		role, inventory := id[:i], id[i+1:]
+		if len(role) > 0 && fileExists(inventory) {
+			return role, inventory, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// A plan of ansible_role with the role attributes set, everything else null
+func rolePlan(t *testing.T, r *RoleResource, role string, projectDir string) tfsdk.Plan {
+	ctx := context.Background()
+	var resp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &resp)
+
+	objectType := resp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attributeType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attributeType, nil)
+	}
+	values["role"] = tftypes.NewValue(tftypes.String, role)
+	values["hosts"] = tftypes.NewValue(tftypes.String, "all")
+	values["gather_facts"] = tftypes.NewValue(tftypes.Bool, true)
+	values["become"] = tftypes.NewValue(tftypes.Bool, false)
+	values["project_dir"] = tftypes.NewValue(tftypes.String, projectDir)
+
+	return tfsdk.Plan{Schema: resp.Schema, Raw: tftypes.NewValue(objectType, values)}
+}
+
+func writeRoleFixture(t *testing.T, projectDir string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(projectDir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRoleWritePlaybook(t *testing.T) {
+	projectDir := t.TempDir()
+	writeRoleFixture(t, projectDir, map[string]string{"roles/web/tasks/main.yml": "- debug: msg=hi\n"})
+
+	r := &RoleResource{}
+	var diags diag.Diagnostics
+	playbook, remove := r.writePlaybook(context.Background(), rolePlan(t, r, "web", projectDir), &diags)
+	if diags.HasError() {
+		t.Fatalf("writePlaybook() diagnostics: %v", diags)
+	}
+
+	dir := filepath.Dir(playbook)
+	if !matchesTempFilePattern(filepath.Base(dir)) {
+		t.Errorf("writePlaybook() wrote %s, want it in a temporary directory of the provider", playbook)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("the directory of the playbook has %d entries, want only the playbook", len(entries))
+	}
+
+	// Hashed from the project, not from the generated playbook
+	if r.playbook.playbookHash == nil {
+		t.Fatal("writePlaybook() didn't set the hash of the role")
+	}
+	got, err := r.playbook.hash(playbook, PlaybookHashOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := hashRole(RolePlayModel{
+		Role:        types.StringValue("web"),
+		Hosts:       types.StringValue("all"),
+		GatherFacts: types.BoolValue(true),
+		Become:      types.BoolValue(false),
+		ProjectDir:  types.StringValue(projectDir),
+	}, PlaybookHashOptions{}, DefaultFileDigestCache().Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("hash() = %s, want the hash of the role %s", got, want)
+	}
+
+	remove()
+	if diags.HasError() || directoryExists(dir) {
+		t.Errorf("remove() left %s behind: %v", dir, diags)
+	}
+}
+
+func TestHashRole(t *testing.T) {
+	projectDir := t.TempDir()
+	writeRoleFixture(t, projectDir, map[string]string{
+		"roles/web/tasks/main.yml":     "- debug: msg=hi\n",
+		"roles/web/files/index.html":   "<h1>hi</h1>\n",
+		"roles/web/files/generated.js": "1\n",
+		"group_vars/all.yml":           "port: 80\n",
+	})
+	play := RolePlayModel{
+		Role:        types.StringValue("web"),
+		Hosts:       types.StringValue("all"),
+		GatherFacts: types.BoolValue(true),
+		Become:      types.BoolValue(false),
+		ProjectDir:  types.StringValue(projectDir),
+	}
+	exclude := PlaybookHashOptions{Exclude: []string{"roles/web/files/*.js"}}
+
+	hash := func(play RolePlayModel, options PlaybookHashOptions) string {
+		t.Helper()
+		h, err := hashRole(play, options, os.ReadFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	base, excluded := hash(play, PlaybookHashOptions{}), hash(play, exclude)
+
+	becomePlay := play
+	becomePlay.Become = types.BoolValue(true)
+	if hash(becomePlay, PlaybookHashOptions{}) == base {
+		t.Error("the hash didn't change with the play")
+	}
+
+	// Not loaded, as the playbook isn't in the project
+	writeRoleFixture(t, projectDir, map[string]string{"group_vars/all.yml": "port: 8080\n"})
+	if hash(play, PlaybookHashOptions{}) != base {
+		t.Error("the hash changed with group_vars of the project")
+	}
+
+	// The hash globs are relative to the project directory
+	writeRoleFixture(t, projectDir, map[string]string{"roles/web/files/generated.js": "2\n"})
+	if hash(play, exclude) != excluded {
+		t.Error("the hash changed with an excluded file")
+	}
+	if hash(play, PlaybookHashOptions{}) == base {
+		t.Error("the hash didn't change with a file of the role")
+	}
+}
+
+func TestSplitRoleImportID(t *testing.T) {
+	dir := t.TempDir()
+	inventory := filepath.Join(dir, "hosts.ini")
+	if err := os.WriteFile(inventory, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		id            string
+		wantRole      string
+		wantInventory string
+		wantFound     bool
+	}{
+		{name: "plain ID", id: "5b3e1c6a-6ab0-4c8d-9a43-b9f8a4f3e3f1"},
+		{name: "role name", id: "web:" + inventory, wantRole: "web", wantInventory: inventory, wantFound: true},
+		{name: "FQCN", id: "acme.web.nginx:" + inventory, wantRole: "acme.web.nginx", wantInventory: inventory, wantFound: true},
+		{name: "colon in role path", id: `C:\roles\web:` + inventory, wantRole: `C:\roles\web`, wantInventory: inventory, wantFound: true},
+		{name: "missing inventory", id: "web:" + filepath.Join(dir, "missing.ini")},
+		{name: "no role", id: ":" + inventory},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotRole, gotInventory, gotFound := splitRoleImportID(test.id)
+			if gotRole != test.wantRole || gotInventory != test.wantInventory || gotFound != test.wantFound {
+				t.Errorf("splitRoleImportID(%q) = %q, %q, %t, want %q, %q, %t", test.id,
+					gotRole, gotInventory, gotFound, test.wantRole, test.wantInventory, test.wantFound)
+			}
+		})
+	}
+}
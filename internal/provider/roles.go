@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	defaultRolesPath       = []string{"~/.ansible/roles", "/usr/share/ansible/roles", "/etc/ansible/roles"}
+	defaultCollectionsPath = []string{"~/.ansible/collections", "/usr/share/ansible/collections"}
+)
+
+// The ansible.cfg that ansible would use: ANSIBLE_CONFIG, ./ansible.cfg,
+// ~/.ansible.cfg or /etc/ansible/ansible.cfg, whichever exists first.
+func ansibleConfigFile() string {
+	candidates := []string{os.Getenv("ANSIBLE_CONFIG"), "ansible.cfg", "~/.ansible.cfg", "/etc/ansible/ansible.cfg"}
+	for _, candidate := range candidates {
+		if len(candidate) == 0 {
+			continue
+		}
+		candidate = expandHome(candidate)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// Read a key of the [defaults] section of an ansible.cfg
+func readAnsibleConfig(configFile string, key string) string {
+	return readAnsibleConfigSection(configFile, "defaults", key)
+}
+
+// Read a key of a section of an ansible.cfg
+func readAnsibleConfigSection(configFile string, wantedSection string, key string) string {
+	file, err := os.Open(configFile)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	section := ""
+	// Not with bufio.Scanner, which stops at lines longer than 64KB
+	lines := bufio.NewReader(file)
+	for {
+		line, err := lines.ReadString('\n')
+		if err != nil && len(line) == 0 {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != wantedSection {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if found && strings.TrimSpace(name) == key {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// Resolve a path list setting the way ansible does: environment variables
+// first, then ansible.cfg, then the built-in default.
+func ansiblePathSetting(envVars []string, configKeys []string, defaults []string) []string {
+	value := ""
+	for _, envVar := range envVars {
+		if value = os.Getenv(envVar); len(value) > 0 {
+			break
+		}
+	}
+
+	configDir := ""
+	if len(value) == 0 {
+		if configFile := ansibleConfigFile(); len(configFile) > 0 {
+			configDir = filepath.Dir(configFile)
+			for _, key := range configKeys {
+				if value = readAnsibleConfig(configFile, key); len(value) > 0 {
+					break
+				}
+			}
+		}
+	}
+
+	paths := defaults
+	if len(value) > 0 {
+		paths = filepath.SplitList(value)
+	}
+
+	var resolved []string
+	for _, p := range paths {
+		p = expandHome(strings.TrimSpace(p))
+		if len(configDir) > 0 && !filepath.IsAbs(p) {
+			// Relative paths in ansible.cfg are relative to its directory
+			p = filepath.Join(configDir, p)
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[1:])
+		}
+	}
+	return p
+}
+
+func RolesPaths() []string {
+	return ansiblePathSetting([]string{"ANSIBLE_ROLES_PATH"}, []string{"roles_path"}, defaultRolesPath)
+}
+
+func CollectionsPaths() []string {
+	return ansiblePathSetting([]string{"ANSIBLE_COLLECTIONS_PATH", "ANSIBLE_COLLECTIONS_PATHS"}, []string{"collections_path", "collections_paths"}, defaultCollectionsPath)
+}
+
+// Find the directory of a role in the same places ansible looks for it.
+// Roles given by FQCN are looked up in the installed collections.
+func ResolveRole(playbookDir string, role string) (string, bool) {
+	var candidates []string
+
+	if parts := strings.Split(role, "."); len(parts) == 3 && !strings.ContainsAny(role, `/\`) {
+		// namespace.collection.role
+		collectionsPaths := append([]string{filepath.Join(playbookDir, "collections")}, CollectionsPaths()...)
+		for _, collectionsPath := range collectionsPaths {
+			candidates = append(candidates, filepath.Join(collectionsPath, "ansible_collections", parts[0], parts[1], "roles", parts[2]))
+		}
+	}
+
+	if filepath.IsAbs(role) {
+		candidates = append(candidates, role)
+	} else {
+		candidates = append(candidates, filepath.Join(playbookDir, "roles", role))
+		for _, rolesPath := range RolesPaths() {
+			candidates = append(candidates, filepath.Join(rolesPath, role))
+		}
+		candidates = append(candidates, filepath.Join(playbookDir, role))
+	}
+
+	for _, candidate := range candidates {
+		if directoryExists(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
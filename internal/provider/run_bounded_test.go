@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBounded(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		n             int
+		parallelism   int64
+		stopOnFailure bool
+		failing       int
+		wantStarted   int
+		wantRuns      []int
+	}{
+		{name: "all in order", ctx: context.Background(), n: 3, parallelism: 1, failing: -1, wantStarted: 3, wantRuns: []int{0, 1, 2}},
+		{name: "no runs", ctx: context.Background(), n: 0, parallelism: 2, failing: -1, wantStarted: 0},
+		{name: "zero parallelism", ctx: context.Background(), n: 2, parallelism: 0, failing: -1, wantStarted: 2, wantRuns: []int{0, 1}},
+		{name: "stop on failure", ctx: context.Background(), n: 4, parallelism: 1, stopOnFailure: true, failing: 1, wantStarted: 2, wantRuns: []int{0, 1}},
+		{name: "continue on failure", ctx: context.Background(), n: 4, parallelism: 1, failing: 1, wantStarted: 4, wantRuns: []int{0, 1, 2, 3}},
+		{name: "canceled", ctx: canceled, n: 3, parallelism: 2, failing: -1, wantStarted: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var runs []int
+			started := runBounded(test.ctx, test.n, test.parallelism, test.stopOnFailure, func(i int) bool {
+				mutex.Lock()
+				runs = append(runs, i)
+				mutex.Unlock()
+				return i != test.failing
+			})
+			if started != test.wantStarted || !slices.Equal(runs, test.wantRuns) {
+				t.Errorf("runBounded() started %d and ran %v, want %d and %v", started, runs, test.wantStarted, test.wantRuns)
+			}
+		})
+	}
+}
+
+func TestRunBoundedParallelism(t *testing.T) {
+	var running, maxRunning atomic.Int64
+	started := runBounded(context.Background(), 10, 3, false, func(i int) bool {
+		current := running.Add(1)
+		for {
+			previous := maxRunning.Load()
+			if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return true
+	})
+	if started != 10 {
+		t.Errorf("runBounded() started %d, want 10", started)
+	}
+	if got := maxRunning.Load(); got != 3 {
+		t.Errorf("runBounded() ran %d at a time, want 3", got)
+	}
+}
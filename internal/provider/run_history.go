@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// A line of the run history log
+type RunHistoryEntry struct {
+	Timestamp string `json:"timestamp"`
+	// The provider doesn't know the Terraform address of a resource, so runs
+	// are identified by the resource ID and the playbook.
+	Id       string   `json:"id"`
+	Playbook string   `json:"playbook"`
+	Args     []string `json:"args"`
+	Duration float64  `json:"duration_seconds"`
+	ExitCode int      `json:"rc"`
+	Stats    Stats    `json:"stats"`
+}
+
+// Appends a JSON line per playbook run to a file, for auditing. A nil
+// *RunHistory does nothing.
+type RunHistory struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewRunHistory(path string) *RunHistory {
+	return &RunHistory{path: path}
+}
+
+// Build the entry of a run. The args are redacted, the stats are taken from
+// the artifact and left empty if it can't be parsed.
+func NewRunHistoryEntry(id string, playbook string, args []string, start time.Time, exitCode int, root Root, redactor *Redactor) RunHistoryEntry {
+	redactedArgs := make([]string, len(args))
+	for i, arg := range args {
+		redactedArgs[i] = redactor.Redact(arg)
+	}
+
+	stats := root.Stats
+	if stats == nil {
+		stats = Stats{}
+	}
+
+	return RunHistoryEntry{
+		Timestamp: start.UTC().Format(time.RFC3339),
+		Id:        id,
+		Playbook:  playbook,
+		Args:      redactedArgs,
+		Duration:  time.Since(start).Seconds(),
+		ExitCode:  exitCode,
+		Stats:     stats,
+	}
+}
+
+func (h *RunHistory) Append(entry RunHistoryEntry) error {
+	if h == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Runs of several resources may finish at the same time
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
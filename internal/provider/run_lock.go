@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	runLocksMu sync.Mutex
+	runLocks   = map[string]chan struct{}{}
+)
+
+// The lock key of a run: lock_key if set, otherwise a hash of the inventory,
+// so runs against the same hosts don't overlap by default.
+func RunLockKey(data *PlaybookResourceModel) string {
+	if !data.LockKey.IsNull() && !data.LockKey.IsUnknown() {
+		return data.LockKey.ValueString()
+	}
+	sum := sha256.Sum256([]byte(data.Inventory.ValueString()))
+	return "inventory:" + hex.EncodeToString(sum[:])
+}
+
+// Wait until no other run holds the lock of key, then take it. The returned
+// function releases the lock. An empty key doesn't lock at all.
+func AcquireRunLock(ctx context.Context, key string) (func(), error) {
+	if len(key) == 0 {
+		return func() {}, nil
+	}
+
+	runLocksMu.Lock()
+	lock, found := runLocks[key]
+	if !found {
+		lock = make(chan struct{}, 1)
+		runLocks[key] = lock
+	}
+	runLocksMu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+	default:
+		tflog.Info(ctx, fmt.Sprintf("Waiting for another playbook run with the lock key %q to finish", key))
+		select {
+		case lock <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() { <-lock }, nil
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+type SemaphoreModel struct {
+	URL      types.String `tfsdk:"url"`
+	Token    types.String `tfsdk:"token"`
+	Insecure types.Bool   `tfsdk:"insecure"`
+}
+
+// A client of the API of Ansible Semaphore
+type SemaphoreClient struct {
+	APIClient
+}
+
+func NewSemaphoreClient(model *SemaphoreModel) *SemaphoreClient {
+	token := model.Token.ValueString()
+	return &SemaphoreClient{NewAPIClient(model.URL.ValueString(), model.Insecure.ValueBool(), func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})}
+}
+
+// A task of Semaphore, a run of a task template
+type SemaphoreTask struct {
+	Id         int64  `json:"id"`
+	TemplateId int64  `json:"template_id"`
+	Status     string `json:"status"`
+}
+
+// Whether the task won't change anymore
+func (t SemaphoreTask) Finished() bool {
+	switch t.Status {
+	case "success", "error", "stopped", "rejected":
+		return true
+	}
+	return false
+}
+
+// Poll the task every interval until it's finished
+func (c *SemaphoreClient) WaitForTask(ctx context.Context, projectId int64, taskId int64, interval time.Duration) (SemaphoreTask, error) {
+	var task SemaphoreTask
+	status := ""
+	err := c.Poll(ctx, fmt.Sprintf("/api/project/%d/tasks/%d", projectId, taskId), interval, &task, func() bool {
+		if task.Status != status {
+			tflog.Info(ctx, fmt.Sprintf("Semaphore task %d is %s", task.Id, task.Status))
+			status = task.Status
+		}
+		return task.Finished()
+	})
+	return task, err
+}
+
+// The output of the task, its lines joined
+func (c *SemaphoreClient) TaskOutput(ctx context.Context, projectId int64, taskId int64) (string, error) {
+	var lines []struct {
+		Output string `json:"output"`
+	}
+	if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api/project/%d/tasks/%d/output", projectId, taskId), nil, &lines); err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	for _, line := range lines {
+		output.WriteString(line.Output + "\n")
+	}
+	return output.String(), nil
+}
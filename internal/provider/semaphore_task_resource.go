@@ -0,0 +1,274 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &SemaphoreTaskResource{}
+var _ resource.ResourceWithValidateConfig = &SemaphoreTaskResource{}
+var _ resource.ResourceWithConfigure = &SemaphoreTaskResource{}
+
+func NewSemaphoreTaskResource() resource.Resource {
+	return &SemaphoreTaskResource{}
+}
+
+// SemaphoreTaskResource runs a task template of the Semaphore of the provider
+// and waits for the task to finish.
+type SemaphoreTaskResource struct {
+	providerData *ProviderData
+}
+
+// SemaphoreTaskResourceModel describes the resource data model.
+type SemaphoreTaskResourceModel struct {
+	ProjectId    types.Int64    `tfsdk:"project_id"`
+	TemplateId   types.Int64    `tfsdk:"template_id"`
+	Environment  types.Map      `tfsdk:"environment"`
+	Arguments    types.List     `tfsdk:"arguments"`
+	Wait         types.Bool     `tfsdk:"wait"`
+	PollInterval types.String   `tfsdk:"poll_interval"`
+	Status       types.String   `tfsdk:"status"`
+	Timeouts     timeouts.Value `tfsdk:"timeouts"`
+	Id           types.String   `tfsdk:"id"`
+}
+
+// The fields of a new task. Semaphore expects the environment and the
+// arguments as JSON encoded strings.
+type semaphoreNewTask struct {
+	TemplateId  int64  `json:"template_id"`
+	Environment string `json:"environment,omitempty"`
+	Arguments   string `json:"arguments,omitempty"`
+}
+
+func (r *SemaphoreTaskResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_semaphore_task"
+}
+
+func (r *SemaphoreTaskResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Runs a task template on the Ansible Semaphore configured in the `semaphore` block of the provider and waits for the task to finish. The task runs again when any argument changes. Destroying the resource doesn't touch Semaphore.",
+
+		Attributes: map[string]schema.Attribute{
+			"project_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the project of the task template.",
+				Required:            true,
+			},
+			"template_id": schema.Int64Attribute{
+				MarkdownDescription: "ID of the task template to run.",
+				Required:            true,
+			},
+			"environment": schema.MapAttribute{
+				MarkdownDescription: "Extra variables of the task, overriding the ones of the environment of the template.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"arguments": schema.ListAttribute{
+				MarkdownDescription: "Additional command line arguments of `ansible-playbook`, e.g. `[\"--tags\", \"deploy\"]`. The template must allow overriding the CLI args in tasks.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"wait": schema.BoolAttribute{
+				MarkdownDescription: "Wait for the task to finish and fail if it didn't succeed. Defaults to true.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"poll_interval": schema.StringAttribute{
+				MarkdownDescription: "How often to check the status of the task while waiting, e.g. `30s`. Defaults to `5s`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("5s"),
+			},
+			"status": schema.StringAttribute{
+				MarkdownDescription: "The status of the task, `success` when waiting for it succeeded, `waiting` usually if not waiting.",
+				Computed:            true,
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+			}),
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "ID of the last task.",
+			},
+		},
+	}
+}
+
+func (r *SemaphoreTaskResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.providerData = providerData
+}
+
+func (r *SemaphoreTaskResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config SemaphoreTaskResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validatePollInterval(path.Root("poll_interval"), config.PollInterval, &resp.Diagnostics)
+}
+
+// Run the task template and wait for the task
+func (r *SemaphoreTaskResource) run(ctx context.Context, diags *diag.Diagnostics, data *SemaphoreTaskResourceModel) {
+	semaphore := r.providerData.GetSemaphore()
+	if semaphore == nil {
+		diags.AddError("Semaphore isn't configured", "Configure the semaphore block of the provider to run task templates.")
+		return
+	}
+
+	newTask := semaphoreNewTask{TemplateId: data.TemplateId.ValueInt64()}
+	if !data.Environment.IsNull() {
+		var environment map[string]string
+		diags.Append(data.Environment.ElementsAs(ctx, &environment, false)...)
+		encoded, err := json.Marshal(environment)
+		if err != nil {
+			diags.AddError("Failed to encode the environment", err.Error())
+		}
+		newTask.Environment = string(encoded)
+	}
+	if !data.Arguments.IsNull() {
+		var arguments []string
+		diags.Append(data.Arguments.ElementsAs(ctx, &arguments, false)...)
+		encoded, err := json.Marshal(arguments)
+		if err != nil {
+			diags.AddError("Failed to encode the arguments", err.Error())
+		}
+		newTask.Arguments = string(encoded)
+	}
+	if diags.HasError() {
+		return
+	}
+
+	projectId := data.ProjectId.ValueInt64()
+	var task SemaphoreTask
+	if err := semaphore.Do(ctx, http.MethodPost, fmt.Sprintf("/api/project/%d/tasks", projectId), newTask, &task); err != nil {
+		diags.AddError("Failed to run the task template", err.Error())
+		return
+	}
+	tflog.Info(ctx, fmt.Sprintf("Started Semaphore task %d", task.Id))
+
+	taskId := task.Id
+	data.Id = types.StringValue(strconv.FormatInt(taskId, 10))
+	data.Status = types.StringValue(task.Status)
+	if !data.Wait.ValueBool() {
+		return
+	}
+
+	// Validated by ValidateConfig
+	interval, _ := time.ParseDuration(data.PollInterval.ValueString())
+	task, err := semaphore.WaitForTask(ctx, projectId, taskId, interval)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Don't leave the task running after a timeout
+			stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), apiRequestTimeout)
+			defer cancel()
+			if stopErr := semaphore.Do(stopCtx, http.MethodPost, fmt.Sprintf("/api/project/%d/tasks/%d/stop", projectId, taskId), nil, nil); stopErr != nil {
+				diags.AddWarning("Failed to stop the task", stopErr.Error())
+			}
+		}
+		diags.AddError(fmt.Sprintf("Failed to wait for the task %d", taskId), err.Error())
+		return
+	}
+
+	data.Status = types.StringValue(task.Status)
+	if task.Status != "success" {
+		output, err := semaphore.TaskOutput(ctx, projectId, task.Id)
+		if err != nil {
+			output = fmt.Sprintf("Failed to get the output of the task: %s", err)
+		} else {
+			output = "End of the output:\n" + lastLines(output, rawOutputTailLines)
+		}
+		diags.AddError(fmt.Sprintf("The task %d is %s", task.Id, task.Status), output)
+	}
+}
+
+func (r *SemaphoreTaskResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data SemaphoreTaskResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := data.Timeouts.Create(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *SemaphoreTaskResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+func (r *SemaphoreTaskResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data SemaphoreTaskResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := data.Timeouts.Update(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	r.run(ctx, &resp.Diagnostics, &data)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *SemaphoreTaskResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
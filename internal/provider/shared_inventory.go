@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	sharedInventoriesMu sync.Mutex
+	// The number of users of every shared inventory file
+	sharedInventories = map[string]int{}
+)
+
+// Write the inventory content to a temporary file shared by all resources
+// with the same content within this provider process, named by the hash of
+// the content like pattern does with os.CreateTemp. The file is written by
+// the first user and removed by ReleaseInventory of the last one.
+func SharedInventory(ctx context.Context, pattern string, content string, diags *diag.Diagnostics) string {
+	sum := sha256.Sum256([]byte(content))
+	// The process ID keeps parallel applies from removing each other's files
+	name := strconv.Itoa(os.Getpid()) + "-" + hex.EncodeToString(sum[:16])
+	filename := filepath.Join(os.TempDir(), strings.Replace(pattern, "*", name, 1))
+
+	sharedInventoriesMu.Lock()
+	defer sharedInventoriesMu.Unlock()
+
+	if sharedInventories[filename] > 0 {
+		sharedInventories[filename]++
+		tflog.Debug(ctx, fmt.Sprintf("Inventory %s is reused", filename))
+		return filename
+	}
+
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		diags.AddError("Failed to create inventory", err.Error())
+		return ""
+	}
+	sharedInventories[filename] = 1
+	tflog.Debug(ctx, fmt.Sprintf("Inventory %s was created", filename))
+
+	return filename
+}
+
+// Release a file of SharedInventory, removing it if it isn't used anymore
+func ReleaseInventory(filename string, diags *diag.Diagnostics) {
+	sharedInventoriesMu.Lock()
+	defer sharedInventoriesMu.Unlock()
+
+	sharedInventories[filename]--
+	if sharedInventories[filename] > 0 {
+		return
+	}
+	delete(sharedInventories, filename)
+	RemoveFile(filename, diags)
+}
@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// Collects output in memory up to a threshold and spills it to a temporary
+// file beyond, so very verbose runs don't exhaust the memory of the provider.
+// A threshold of 0 or less keeps all output in memory.
+type SpillBuffer struct {
+	threshold int64
+	pattern   string
+	buffer    bytes.Buffer
+	file      *os.File
+	size      int64
+	// Whether the file was created by the buffer and is removed by Close
+	temporary bool
+}
+
+// A buffer spilling beyond threshold bytes to a temporary file named like
+// os.CreateTemp does with pattern
+func NewSpillBuffer(threshold int64, pattern string) *SpillBuffer {
+	return &SpillBuffer{threshold: threshold, pattern: pattern}
+}
+
+// A buffer with the content of an existing file, e.g. the artifact the json
+// callback wrote. The file is left in place by Close.
+func OpenSpillFile(name string) (*SpillBuffer, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &SpillBuffer{file: file, size: info.Size()}, nil
+}
+
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.threshold > 0 && int64(b.buffer.Len()+len(p)) > b.threshold {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.buffer.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *SpillBuffer) spill() error {
+	file, err := os.CreateTemp("", b.pattern)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(b.buffer.Bytes()); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	b.file = file
+	b.temporary = true
+	b.buffer = bytes.Buffer{}
+	return nil
+}
+
+// Whether the output is in a file instead of memory
+func (b *SpillBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+func (b *SpillBuffer) Len() int64 {
+	return b.size
+}
+
+// A reader of the whole output. Every reader has its own offset, so several
+// readers can be used one after the other.
+func (b *SpillBuffer) Reader() *io.SectionReader {
+	if b.file != nil {
+		return io.NewSectionReader(b.file, 0, b.size)
+	}
+	return io.NewSectionReader(bytes.NewReader(b.buffer.Bytes()), 0, b.size)
+}
+
+// The redacted output, truncated to maxSize bytes like TruncateOutput does.
+// Spilled output is redacted line by line to another temporary file first,
+// so secrets across the cuts are replaced as well, and only the kept head
+// and tail of it are read.
+func (b *SpillBuffer) Text(maxSize int64, redactor *Redactor) (string, bool, error) {
+	if b.file == nil {
+		text, truncated := TruncateOutput(redactor.Redact(b.buffer.String()), maxSize)
+		return text, truncated, nil
+	}
+
+	if maxSize <= 0 || b.size <= maxSize {
+		content, err := io.ReadAll(b.Reader())
+		text, truncated := TruncateOutput(redactor.Redact(string(content)), maxSize)
+		return text, truncated, err
+	}
+
+	redacted, err := os.CreateTemp("", TempFilePattern("redacted-*"))
+	if err != nil {
+		return "", true, err
+	}
+	defer os.Remove(redacted.Name())
+	defer redacted.Close()
+
+	if err := redactor.RedactLines(redacted, b.Reader()); err != nil {
+		return "", true, err
+	}
+	info, err := redacted.Stat()
+	if err != nil {
+		return "", true, err
+	}
+	size := info.Size()
+	if size <= maxSize {
+		content, err := io.ReadAll(io.NewSectionReader(redacted, 0, size))
+		return string(content), false, err
+	}
+
+	head, tail, marked := truncation(size, maxSize)
+	headContent := make([]byte, head)
+	if _, err := redacted.ReadAt(headContent, 0); err != nil {
+		return "", true, err
+	}
+	tailContent := make([]byte, tail)
+	if _, err := redacted.ReadAt(tailContent, size-tail); err != nil {
+		return "", true, err
+	}
+	headText, tailText := trimPartialRunes(string(headContent), string(tailContent))
+	return joinTruncated(size, headText, tailText, marked), true, nil
+}
+
+// Close the file of spilled output and remove it if it's temporary
+func (b *SpillBuffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	if b.temporary {
+		if removeErr := os.Remove(b.file.Name()); err == nil {
+			err = removeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var sshControlMasters = []string{"auto", "autoask", "yes", "ask", "no"}
+
+// yes, no or an ssh time like 60, 10m or 1h30m
+var sshControlPersistPattern = regexp.MustCompile(`^(yes|no|([0-9]+[sSmMhHdDwW]?)+)$`)
+
+// How long stopping a control master may take
+const controlMasterStopTimeout = 10 * time.Second
+
+// The defaults of ansible's ssh_args, which ansible_ssh_args replaces
+const (
+	defaultSSHControlMaster  = "auto"
+	defaultSSHControlPersist = "60s"
+)
+
+type SSHModel struct {
+	ControlMaster  types.String `tfsdk:"control_master"`
+	ControlPersist types.String `tfsdk:"control_persist"`
+	ControlPathDir types.String `tfsdk:"control_path_dir"`
+	Timeout        types.Int64  `tfsdk:"timeout"`
+}
+
+func (SSHModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"control_master":   types.StringType,
+		"control_persist":  types.StringType,
+		"control_path_dir": types.StringType,
+		"timeout":          types.Int64Type,
+	}
+}
+
+// The connection variables of the SSH settings. ansible_ssh_args replaces
+// ansible's default ssh_args, so an unset control_master or control_persist
+// keeps ansible's default.
+func (m SSHModel) Vars() map[string]interface{} {
+	vars := map[string]interface{}{}
+
+	if !m.ControlMaster.IsNull() || !m.ControlPersist.IsNull() {
+		controlMaster, controlPersist := defaultSSHControlMaster, defaultSSHControlPersist
+		if !m.ControlMaster.IsNull() {
+			controlMaster = m.ControlMaster.ValueString()
+		}
+		if !m.ControlPersist.IsNull() {
+			controlPersist = m.ControlPersist.ValueString()
+		}
+		vars["ansible_ssh_args"] = fmt.Sprintf("-C -o ControlMaster=%s -o ControlPersist=%s", controlMaster, controlPersist)
+	}
+	if !m.ControlPathDir.IsNull() {
+		vars["ansible_control_path_dir"] = m.ControlPathDir.ValueString()
+	}
+	if !m.Timeout.IsNull() {
+		vars["ansible_ssh_timeout"] = m.Timeout.ValueInt64()
+	}
+
+	return vars
+}
+
+func validateSSH(ctx context.Context, value types.Object, diags *diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return
+	}
+
+	var ssh SSHModel
+	diags.Append(value.As(ctx, &ssh, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return
+	}
+
+	validateOneOf(path.Root("ssh").AtName("control_master"), ssh.ControlMaster, sshControlMasters, diags)
+
+	if !ssh.ControlPersist.IsNull() && !ssh.ControlPersist.IsUnknown() && !sshControlPersistPattern.MatchString(ssh.ControlPersist.ValueString()) {
+		diags.AddAttributeError(path.Root("ssh").AtName("control_persist"), "Invalid value",
+			"Must be yes, no or a time like 60s, 10m or 1h30m.")
+	}
+	if !ssh.Timeout.IsNull() && !ssh.Timeout.IsUnknown() && ssh.Timeout.ValueInt64() < 1 {
+		diags.AddAttributeError(path.Root("ssh").AtName("timeout"), "Invalid timeout", "timeout must be at least 1 second.")
+	}
+}
+
+// A control path directory of the run alone, so the ssh control masters it
+// started can be stopped when it ends. With ControlPersist they detach from
+// the process group of ansible and would outlive a canceled run. Empty if
+// the directory is configured by control_path_dir, the environment or
+// ansible.cfg, or the run is in a container.
+func RunControlPathDir(ctx context.Context, data *PlaybookResourceModel, env map[string]string, diags *diag.Diagnostics) string {
+	if !controlMastersStoppable || runsInContainer(data) || len(env["ANSIBLE_SSH_CONTROL_PATH_DIR"]) > 0 || len(env["ANSIBLE_SSH_CONTROL_PATH"]) > 0 {
+		return ""
+	}
+	if !data.SSH.IsNull() && !data.SSH.IsUnknown() {
+		var ssh SSHModel
+		diags.Append(data.SSH.As(ctx, &ssh, basetypes.ObjectAsOptions{})...)
+		if diags.HasError() || !ssh.ControlPathDir.IsNull() {
+			return ""
+		}
+	}
+	if configFile := ansibleConfigFile(); len(configFile) > 0 {
+		for _, key := range []string{"control_path_dir", "control_path"} {
+			if len(readAnsibleConfigSection(configFile, "ssh_connection", key)) > 0 {
+				return ""
+			}
+		}
+	}
+
+	// Below ansible's default directory, as paths of sockets must be short
+	parent := expandHome("~/.ansible/cp")
+	if err := os.MkdirAll(parent, 0o700); err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("Failed to create %s, ssh control masters can outlive the run: %s", parent, err))
+		return ""
+	}
+	dir, err := os.MkdirTemp(parent, "terraform-*")
+	if err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("Failed to create a control path directory, ssh control masters can outlive the run: %s", err))
+		return ""
+	}
+	return dir
+}
+
+// Stop the ssh control masters with sockets in the directory of
+// RunControlPathDir with `ssh -O exit` and remove it
+func StopControlMasters(ctx context.Context, dir string) {
+	if len(dir) == 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("Failed to list the ssh control sockets in %s: %s", dir, err))
+	}
+	for _, entry := range entries {
+		socket := filepath.Join(dir, entry.Name())
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), controlMasterStopTimeout)
+		// The destination is required, but the control path is all that counts
+		output, err := exec.CommandContext(stopCtx, "ssh", "-O", "exit", "-o", "ControlPath="+socket, "localhost").CombinedOutput()
+		cancel()
+		if err != nil {
+			tflog.Warn(ctx, fmt.Sprintf("Failed to stop the ssh control master of %s: %s %s", socket, err, output))
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("Failed to remove %s: %s", dir, err))
+	}
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const telemetryScope = "terraform-provider-ansible"
+
+type TelemetryConfig struct {
+	Endpoint    string
+	Insecure    bool
+	Headers     map[string]string
+	ServiceName string
+}
+
+// Exports a trace and metrics of every playbook run via OTLP/HTTP.
+// A nil *Telemetry does nothing.
+type Telemetry struct {
+	tracerProvider *sdktrace.TracerProvider
+	meterProvider  *sdkmetric.MeterProvider
+	tracer         trace.Tracer
+
+	runs        metric.Int64Counter
+	duration    metric.Float64Histogram
+	failedHosts metric.Int64Counter
+}
+
+func NewTelemetry(ctx context.Context, config TelemetryConfig) (*Telemetry, error) {
+	traceOptions := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(config.Endpoint)}
+	metricOptions := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpointURL(config.Endpoint)}
+	if config.Insecure {
+		traceOptions = append(traceOptions, otlptracehttp.WithInsecure())
+		metricOptions = append(metricOptions, otlpmetrichttp.WithInsecure())
+	}
+	if len(config.Headers) > 0 {
+		traceOptions = append(traceOptions, otlptracehttp.WithHeaders(config.Headers))
+		metricOptions = append(metricOptions, otlpmetrichttp.WithHeaders(config.Headers))
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx, traceOptions...)
+	if err != nil {
+		return nil, err
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := sdkresource.NewSchemaless(semconv.ServiceName(config.ServiceName))
+
+	t := &Telemetry{
+		tracerProvider: sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter), sdktrace.WithResource(res)),
+		meterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)), sdkmetric.WithResource(res)),
+	}
+	t.tracer = t.tracerProvider.Tracer(telemetryScope)
+
+	meter := t.meterProvider.Meter(telemetryScope)
+	if t.runs, err = meter.Int64Counter("ansible.playbook.runs", metric.WithDescription("Number of playbook runs")); err != nil {
+		return nil, err
+	}
+	if t.duration, err = meter.Float64Histogram("ansible.playbook.duration", metric.WithDescription("Duration of playbook runs"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if t.failedHosts, err = meter.Int64Counter("ansible.playbook.failed_hosts", metric.WithDescription("Number of failed or unreachable hosts of playbook runs")); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Start the span of a playbook run
+func (t *Telemetry) StartRun(ctx context.Context, playbook string) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return t.tracer.Start(ctx, "ansible-playbook "+playbook, trace.WithAttributes(attribute.String("ansible.playbook", playbook)))
+}
+
+// End the span of a playbook run, adding spans for its plays and tasks from
+// the artifact, record the metrics and export everything.
+func (t *Telemetry) EndRun(ctx context.Context, span trace.Span, playbook string, start time.Time, root Root, exitCode int) {
+	if t == nil {
+		return
+	}
+
+	status := "ok"
+	if exitCode != ExitCodeOK {
+		status = "failed"
+	}
+
+	failed := 0
+	unreachable := 0
+	for _, stat := range root.Stats {
+		if stat.Failures > 0 {
+			failed++
+		}
+		if stat.Unreachable > 0 {
+			unreachable++
+		}
+	}
+
+	t.addPlaySpans(ctx, root.Plays)
+
+	span.SetAttributes(
+		attribute.Int("ansible.exit_code", exitCode),
+		attribute.Int("ansible.hosts", len(root.Stats)),
+		attribute.Int("ansible.hosts.failed", failed),
+		attribute.Int("ansible.hosts.unreachable", unreachable),
+	)
+	if exitCode != ExitCodeOK {
+		span.SetStatus(codes.Error, DescribeExitCode(exitCode, unreachable > 0))
+	}
+	span.End()
+
+	attributes := metric.WithAttributes(attribute.String("ansible.playbook", playbook), attribute.String("status", status))
+	t.runs.Add(ctx, 1, attributes)
+	t.duration.Record(ctx, time.Since(start).Seconds(), attributes)
+	t.failedHosts.Add(ctx, int64(failed+unreachable), metric.WithAttributes(attribute.String("ansible.playbook", playbook)))
+
+	if err := t.Flush(ctx); err != nil {
+		tflog.Warn(ctx, "Failed to export telemetry: "+err.Error())
+	}
+}
+
+func (t *Telemetry) addPlaySpans(ctx context.Context, plays []Play) {
+	for _, play := range plays {
+		playStart, playEnd, ok := play.Play.Duration.Times()
+		if !ok {
+			continue
+		}
+
+		playCtx, playSpan := t.tracer.Start(ctx, "play "+play.Play.Name, trace.WithTimestamp(playStart))
+		for _, task := range play.Tasks {
+			taskStart, taskEnd, ok := task.Task.Duration.Times()
+			if !ok {
+				continue
+			}
+
+			failed, changed := 0, 0
+			for _, host := range task.Hosts {
+				if host.Failed || host.Unreachable {
+					failed++
+				}
+				if host.Changed {
+					changed++
+				}
+			}
+
+			_, taskSpan := t.tracer.Start(playCtx, "task "+task.Task.Name, trace.WithTimestamp(taskStart), trace.WithAttributes(
+				attribute.Int("ansible.hosts", len(task.Hosts)),
+				attribute.Int("ansible.hosts.failed", failed),
+				attribute.Int("ansible.hosts.changed", changed),
+			))
+			if failed > 0 {
+				taskSpan.SetStatus(codes.Error, "task failed")
+			}
+			taskSpan.End(trace.WithTimestamp(taskEnd))
+		}
+		playSpan.End(trace.WithTimestamp(playEnd))
+	}
+}
+
+// Export pending spans and metrics. The provider process may exit any time
+// after a run, so nothing is left to the periodic export.
+func (t *Telemetry) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	return errors.Join(t.tracerProvider.ForceFlush(ctx), t.meterProvider.ForceFlush(ctx))
+}
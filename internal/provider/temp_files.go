@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// The prefix of the temporary files and directories the provider creates in
+// os.TempDir()
+const tempFilePrefix = ".terraform-ansible-"
+
+// Temporary files and directories of the provider, including the names of
+// earlier versions
+var tempFilePatterns = []string{tempFilePrefix + "*", ".inventory-*", "terraform-ansible-callbacks-*"}
+
+// The pattern for os.CreateTemp of a temporary file of the provider, named by
+// what it holds, e.g. `artifact-*.json`
+func TempFilePattern(pattern string) string {
+	return tempFilePrefix + pattern
+}
+
+// Write content to a new temporary file of the provider, only readable by the
+// user, named like pattern does for TempFilePattern. Returns its path, which
+// the caller has to remove, or "" if it couldn't be created.
+func WriteTempFile(ctx context.Context, pattern string, content string, diags *diag.Diagnostics) string {
+	file, err := os.CreateTemp("", TempFilePattern(pattern))
+	if err != nil {
+		diags.AddError("Failed to create a temporary file", err.Error())
+		return ""
+	}
+	defer file.Close()
+
+	tflog.Debug(ctx, fmt.Sprintf("Temporary file %s was created", file.Name()))
+
+	if _, err := file.WriteString(content); err != nil {
+		diags.AddError("Failed to write a temporary file", err.Error())
+	}
+	return file.Name()
+}
+
+const defaultTempFileMaxAge = 24 * time.Hour
+
+// Remove temporary files of the provider older than maxAge, left behind by
+// runs that crashed or were killed before they could clean up.
+func RemoveStaleTempFiles(ctx context.Context, maxAge time.Duration) {
+	tempDir := os.TempDir()
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("Failed to list %s: %s", tempDir, err))
+		return
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		if !matchesTempFilePattern(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		stale := filepath.Join(tempDir, entry.Name())
+		if err := os.RemoveAll(stale); err != nil {
+			tflog.Warn(ctx, fmt.Sprintf("Failed to remove stale temporary file %s: %s", stale, err))
+			continue
+		}
+		tflog.Debug(ctx, fmt.Sprintf("Removed stale temporary file %s", stale))
+	}
+}
+
+func matchesTempFilePattern(name string) bool {
+	for _, pattern := range tempFilePatterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
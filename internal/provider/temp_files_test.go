@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func TestWriteTempFile(t *testing.T) {
+	tests := []struct {
+		pattern string
+		content string
+		suffix  string
+	}{
+		{pattern: "connection-vars-*.json", content: `{"ansible_user": "root"}`, suffix: ".json"},
+		{pattern: "vault-password-*", content: "secret"},
+		{pattern: "artifact-*.json", content: "", suffix: ".json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			var diags diag.Diagnostics
+			file := WriteTempFile(context.Background(), test.pattern, test.content, &diags)
+			if diags.HasError() {
+				t.Fatalf("WriteTempFile() diagnostics: %v", diags)
+			}
+			defer os.Remove(file)
+
+			name := filepath.Base(file)
+			if !matchesTempFilePattern(name) || !strings.HasSuffix(name, test.suffix) {
+				t.Errorf("WriteTempFile() created %s, want a temporary file of the provider ending in %q", name, test.suffix)
+			}
+
+			content, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.content {
+				t.Errorf("WriteTempFile() wrote %q, want %q", content, test.content)
+			}
+
+			info, err := os.Stat(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+				t.Errorf("WriteTempFile() created %s with mode %v, want 0600", name, info.Mode().Perm())
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestWithinFailureThreshold(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxHosts      types.Int64
+		maxPercentage types.Float64
+		failedHosts   int
+		totalHosts    int
+		want          bool
+	}{
+		{name: "no thresholds", maxHosts: types.Int64Null(), maxPercentage: types.Float64Null(), failedHosts: 3, totalHosts: 4, want: true},
+		{name: "hosts below", maxHosts: types.Int64Value(2), maxPercentage: types.Float64Null(), failedHosts: 1, totalHosts: 4, want: true},
+		{name: "hosts equal", maxHosts: types.Int64Value(2), maxPercentage: types.Float64Null(), failedHosts: 2, totalHosts: 4, want: true},
+		{name: "hosts above", maxHosts: types.Int64Value(2), maxPercentage: types.Float64Null(), failedHosts: 3, totalHosts: 4, want: false},
+		{name: "percentage equal", maxHosts: types.Int64Null(), maxPercentage: types.Float64Value(25), failedHosts: 1, totalHosts: 4, want: true},
+		{name: "percentage above", maxHosts: types.Int64Null(), maxPercentage: types.Float64Value(25), failedHosts: 2, totalHosts: 4, want: false},
+		{name: "no hosts", maxHosts: types.Int64Null(), maxPercentage: types.Float64Value(0), failedHosts: 0, totalHosts: 0, want: true},
+		{name: "both within", maxHosts: types.Int64Value(2), maxPercentage: types.Float64Value(50), failedHosts: 2, totalHosts: 4, want: true},
+		{name: "percentage exceeded first", maxHosts: types.Int64Value(5), maxPercentage: types.Float64Value(10), failedHosts: 1, totalHosts: 4, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := &PlaybookResourceModel{MaxFailedHosts: test.maxHosts, MaxFailedPercentage: test.maxPercentage}
+			if got := withinFailureThreshold(data, test.failedHosts, test.totalHosts); got != test.want {
+				t.Errorf("withinFailureThreshold(%d, %d) = %t, want %t", test.failedHosts, test.totalHosts, got, test.want)
+			}
+		})
+	}
+}
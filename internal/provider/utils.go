@@ -2,14 +2,25 @@ package provider
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"hash"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
+	"github.com/itchyny/gojq"
 	"gopkg.in/yaml.v2"
 	"k8s.io/client-go/util/jsonpath"
 
@@ -38,7 +49,9 @@ func BuildInventory(ctx context.Context, inventoryDest string, inventoryContent
 	fileInfo, err := os.CreateTemp("", inventoryDest)
 	if err != nil {
 		diags.AddError("Failed to create inventory file", err.Error())
+		return ""
 	}
+	fileInfo.Close()
 
 	tempFileName := fileInfo.Name()
 	tflog.Debug(ctx, fmt.Sprintf("Inventory %s was created", fileInfo.Name()))
@@ -51,6 +64,99 @@ func BuildInventory(ctx context.Context, inventoryDest string, inventoryContent
 	return tempFileName
 }
 
+// Shorten output to at most maxSize bytes, keeping its head and tail
+// around a marker that states how much was dropped.
+// A maxSize of 0 or less disables truncation.
+func TruncateOutput(output string, maxSize int64) (string, bool) {
+	size := int64(len(output))
+	if maxSize <= 0 || size <= maxSize {
+		return output, false
+	}
+
+	head, tail, marked := truncation(size, maxSize)
+	headText, tailText := trimPartialRunes(output[:head], output[size-tail:])
+	return joinTruncated(size, headText, tailText, marked), true
+}
+
+// Drop the parts of multi-byte characters cut off at the end of head and the
+// start of tail, so truncated output stays valid UTF-8
+func trimPartialRunes(head string, tail string) (string, string) {
+	for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(head[i]) {
+			if !utf8.FullRuneInString(head[i:]) {
+				head = head[:i]
+			}
+			break
+		}
+	}
+	for i := 0; i < len(tail) && i < utf8.UTFMax; i++ {
+		if utf8.RuneStart(tail[i]) {
+			tail = tail[i:]
+			break
+		}
+	}
+	return head, tail
+}
+
+func truncationMarker(dropped int64) string {
+	return fmt.Sprintf("\n\n[... %d bytes truncated ...]\n\n", dropped)
+}
+
+// The sizes of the head and tail TruncateOutput keeps of output of size
+// bytes, and whether there is room for the marker between them. The room is
+// that of the marker for size bytes, which is at least as long as the one
+// for the bytes actually dropped.
+func truncation(size int64, maxSize int64) (int64, int64, bool) {
+	keep := maxSize - int64(len(truncationMarker(size)))
+	if keep <= 0 {
+		return maxSize, 0, false
+	}
+
+	head := keep / 2
+	return head, keep - head, true
+}
+
+// Join the head and tail kept of output of size bytes around the marker of
+// the bytes dropped between them, including the ones of partial characters
+func joinTruncated(size int64, head string, tail string, marked bool) string {
+	if !marked {
+		return head
+	}
+	return head + truncationMarker(size-int64(len(head))-int64(len(tail))) + tail
+}
+
+// Gzip output and encode it as base64, to store it in the state
+func CompressOutput(output string) (string, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(output)); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Decode output stored by CompressOutput
+func DecompressOutput(compressed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(compressed)
+	if err != nil {
+		return "", err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
 func RemoveFile(filename string, diags *diag.Diagnostics) {
 
 	err := os.Remove(filename)
@@ -59,12 +165,37 @@ func RemoveFile(filename string, diags *diag.Diagnostics) {
 	}
 }
 
+func SortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 type Role struct {
 	Name string
 }
 
+type AnsibleTask struct {
+	IncludeRole        *Role         `yaml:"include_role"`
+	ImportRole         *Role         `yaml:"import_role"`
+	IncludeRoleBuiltin *Role         `yaml:"ansible.builtin.include_role"`
+	ImportRoleBuiltin  *Role         `yaml:"ansible.builtin.import_role"`
+	Block              []AnsibleTask `yaml:"block"`
+	Rescue             []AnsibleTask `yaml:"rescue"`
+	Always             []AnsibleTask `yaml:"always"`
+}
+
 type AnsiblePlay struct {
-	Roles []Role `yaml:"roles"`
+	ImportPlaybook        string        `yaml:"import_playbook"`
+	ImportPlaybookBuiltin string        `yaml:"ansible.builtin.import_playbook"`
+	Roles                 []Role        `yaml:"roles"`
+	PreTasks              []AnsibleTask `yaml:"pre_tasks"`
+	Tasks                 []AnsibleTask `yaml:"tasks"`
+	PostTasks             []AnsibleTask `yaml:"post_tasks"`
+	Handlers              []AnsibleTask `yaml:"handlers"`
 }
 
 type AnsiblePlaybook []AnsiblePlay
@@ -74,6 +205,12 @@ func (r *Role) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var roleStr string
 	if err := unmarshal(&roleStr); err == nil {
 		r.Name = roleStr
+		// include_role: name=foo
+		for _, field := range strings.Fields(roleStr) {
+			if name, found := strings.CutPrefix(field, "name="); found {
+				r.Name = name
+			}
+		}
 		return nil
 	}
 
@@ -82,6 +219,8 @@ func (r *Role) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal(&roleMap); err == nil {
 		if roleName, ok := roleMap["role"].(string); ok {
 			r.Name = roleName
+		} else if roleName, ok := roleMap["name"].(string); ok {
+			r.Name = roleName
 		}
 		return nil
 	}
@@ -89,9 +228,31 @@ func (r *Role) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return fmt.Errorf("failed to unmarshal role")
 }
 
-func uniqueRoles(roles []string) []string {
-	roleMap := make(map[string]bool)
-	var unique []string
+func (t AnsibleTask) roles() []string {
+	var roles []string
+	for _, role := range []*Role{t.IncludeRole, t.ImportRole, t.IncludeRoleBuiltin, t.ImportRoleBuiltin} {
+		if role != nil && len(role.Name) > 0 {
+			roles = append(roles, role.Name)
+		}
+	}
+	for _, tasks := range [][]AnsibleTask{t.Block, t.Rescue, t.Always} {
+		for _, task := range tasks {
+			roles = append(roles, task.roles()...)
+		}
+	}
+	return roles
+}
+
+// A role used by a playbook, with the directory of the playbook that uses it,
+// which is where ansible looks for the role first
+type PlaybookRole struct {
+	Name        string
+	PlaybookDir string
+}
+
+func uniqueRoles(roles []PlaybookRole) []PlaybookRole {
+	roleMap := make(map[PlaybookRole]bool)
+	var unique []PlaybookRole
 	for _, role := range roles {
 		if _, exists := roleMap[role]; !exists {
 			unique = append(unique, role)
@@ -101,9 +262,105 @@ func uniqueRoles(roles []string) []string {
 	return unique
 }
 
-func ParsePlaybookRoles(playbookPath string) ([]string, error) {
+// Parse the roles used by a playbook, and the playbooks it imports. Roles
+// are taken from the roles of every play and from include_role/import_role
+// tasks. Templated names can't be resolved and are skipped.
+func ParsePlaybookDependencies(playbookPath string) ([]PlaybookRole, []string, error) {
+	var allRoles []PlaybookRole
+	var importedPlaybooks []string
+	err := parsePlaybookDependencies(playbookPath, map[string]bool{}, &allRoles, &importedPlaybooks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var roles []PlaybookRole
+	for _, role := range uniqueRoles(allRoles) {
+		if !strings.Contains(role.Name, "{{") {
+			roles = append(roles, role)
+		}
+	}
+	return roles, importedPlaybooks, nil
+}
+
+func parsePlaybookDependencies(playbookPath string, visited map[string]bool, roles *[]PlaybookRole, importedPlaybooks *[]string) error {
+	visited[filepath.Clean(playbookPath)] = true
+
 	var playbook AnsiblePlaybook
 	content, err := os.ReadFile(playbookPath)
+	if err != nil {
+		return err
+	}
+	err = yaml.Unmarshal(content, &playbook)
+	if err != nil {
+		return err
+	}
+
+	// Extract roles from all plays
+	playbookDir := filepath.Dir(playbookPath)
+	for _, play := range playbook {
+		for _, role := range play.Roles {
+			*roles = append(*roles, PlaybookRole{Name: role.Name, PlaybookDir: playbookDir})
+		}
+		for _, tasks := range [][]AnsibleTask{play.PreTasks, play.Tasks, play.PostTasks, play.Handlers} {
+			for _, task := range tasks {
+				for _, role := range task.roles() {
+					*roles = append(*roles, PlaybookRole{Name: role, PlaybookDir: playbookDir})
+				}
+			}
+		}
+
+		for _, imported := range []string{play.ImportPlaybook, play.ImportPlaybookBuiltin} {
+			if len(imported) == 0 || strings.Contains(imported, "{{") {
+				continue
+			}
+			if !filepath.IsAbs(imported) {
+				imported = filepath.Join(filepath.Dir(playbookPath), imported)
+			}
+			if visited[filepath.Clean(imported)] {
+				continue
+			}
+
+			*importedPlaybooks = append(*importedPlaybooks, imported)
+			err := parsePlaybookDependencies(imported, visited, roles, importedPlaybooks)
+			if err != nil {
+				return fmt.Errorf("%s: %w", imported, err)
+			}
+		}
+	}
+	return nil
+}
+
+// A role in the roles of a play as state version 0 parsed it: a name, or the
+// role key of a map
+type legacyRole struct {
+	Name string
+}
+
+func (r *legacyRole) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var roleStr string
+	if err := unmarshal(&roleStr); err == nil {
+		r.Name = roleStr
+		return nil
+	}
+
+	var roleMap map[string]interface{}
+	if err := unmarshal(&roleMap); err == nil {
+		if roleName, ok := roleMap["role"].(string); ok {
+			r.Name = roleName
+		}
+		return nil
+	}
+
+	return fmt.Errorf("failed to unmarshal role")
+}
+
+// Parse the roles of the plays of a playbook, without duplicates, the way
+// the playbook hash of state version 0 did
+func parseLegacyPlaybookRoles(playbookPath string) ([]string, error) {
+	var playbook []struct {
+		Roles []legacyRole `yaml:"roles"`
+	}
+	content, err := os.ReadFile(playbookPath)
 	if err != nil {
 		return nil, err
 	}
@@ -112,86 +369,404 @@ func ParsePlaybookRoles(playbookPath string) ([]string, error) {
 		return nil, err
 	}
 
-	// Extract roles from all plays
-	var allRoles []string
+	var roles []string
+	seen := map[string]bool{}
 	for _, play := range playbook {
 		for _, role := range play.Roles {
-			allRoles = append(allRoles, role.Name)
+			if !seen[role.Name] {
+				seen[role.Name] = true
+				roles = append(roles, role.Name)
+			}
 		}
 	}
-	allRoles = uniqueRoles(allRoles)
-	return allRoles, nil
+	return roles, nil
 }
 
-func HashDirectory(hash hash.Hash, dirPath string) error {
+// Convert a glob into a regular expression. "*" and "?" don't match "/",
+// "**" matches any number of directories.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				expr.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+type hashPattern struct {
+	expr *regexp.Regexp
+	// Patterns with a "/" match the relative path, all others the name
+	matchPath bool
+}
+
+func newHashPatterns(globs []string) ([]hashPattern, error) {
+	var patterns []hashPattern
+	for _, glob := range globs {
+		glob = strings.TrimSuffix(glob, "/")
+		expr, err := globToRegexp(glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+		patterns = append(patterns, hashPattern{expr: expr, matchPath: strings.Contains(glob, "/")})
+	}
+	return patterns, nil
+}
+
+func matchesAny(patterns []hashPattern, relPath string) bool {
+	for _, pattern := range patterns {
+		subject := relPath
+		if !pattern.matchPath {
+			subject = path.Base(relPath)
+		}
+		if pattern.expr.MatchString(subject) {
+			return true
+		}
+	}
+	return false
+}
+
+// Selects the files inside of directories that feed into the playbook hash.
+// Patterns without a "/" match the name of a file or directory at any depth,
+// all others match the path relative to BaseDir.
+type HashFilter struct {
+	BaseDir string
+	include []hashPattern
+	exclude []hashPattern
+}
+
+func NewHashFilter(baseDir string, include []string, exclude []string) (*HashFilter, error) {
+	includePatterns, err := newHashPatterns(include)
+	if err != nil {
+		return nil, err
+	}
+	excludePatterns, err := newHashPatterns(exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &HashFilter{BaseDir: baseDir, include: includePatterns, exclude: excludePatterns}, nil
+}
+
+// Whether to skip a file, or for directories, everything inside of it
+func (f *HashFilter) Skip(filePath string, isDir bool) bool {
+	if f == nil {
+		return false
+	}
+
+	relPath, err := filepath.Rel(f.BaseDir, filePath)
+	if err != nil {
+		relPath = filePath
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	if matchesAny(f.exclude, relPath) {
+		return true
+	}
+
+	// Directories are always entered, the include patterns apply to files
+	return !isDir && len(f.include) > 0 && !matchesAny(f.include, relPath)
+}
+
+// What a file adds to the playbook hash, its digest, or its content for the
+// playbook hashes of state version 0
+type FileDigest func(filePath string) ([]byte, error)
+
+// Add the digests of all files inside of dirPath to hash. The files are
+// read in parallel, but their digests are combined in lexical order, so the
+// result is deterministic.
+func HashDirectory(hash hash.Hash, dirPath string, filter *HashFilter, digest FileDigest) error {
+	var files []string
 	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() {
-			err := HashFile(hash, path)
-			if err != nil {
-				return err
+		if filter.Skip(path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
+		}
+		if !info.IsDir() {
+			files = append(files, path)
 		}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
+
+	digests := make([][]byte, len(files))
+	errs := make([]error, len(files))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	workers := min(runtime.NumCPU(), len(files))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				digests[i], errs[i] = digest(files[i])
+			}
+		}()
+	}
+
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := range files {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		hash.Write(digests[i])
+	}
 	return nil
 }
 
-func HashFile(hash hash.Hash, filePath string) error {
-	fileContent, err := os.ReadFile(filePath)
+// Add the digest of a file to hash
+func HashFile(hash hash.Hash, filePath string, digest FileDigest) error {
+	fileDigest, err := digest(filePath)
 	if err != nil {
 		return err
 	}
-	hash.Write(fileContent)
+	hash.Write(fileDigest)
 	return nil
 }
 
-// Adapted from https://github.com/marshallford/terraform-provider-ansible/blob/main/pkg/ansible/utils.go#L25
-func jsonPath(data []byte, query ArtifactQuery) (string, error) {
-	var blob interface{}
-	if err := json.Unmarshal(data, &blob); err != nil {
-		return "", err
+func regexQuery(data []byte, query *ArtifactQuery) error {
+	re, err := regexp.Compile(query.Regex)
+	if err != nil {
+		return err
 	}
 
+	var results []string
+	var matches []interface{}
+	for _, match := range re.FindAllSubmatch(data, -1) {
+		value := string(match[0])
+		if len(match) > 1 {
+			value = string(match[1])
+		}
+		matches = append(matches, value)
+
+		if !query.JsonOutput {
+			results = append(results, value)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		results = append(results, string(encoded))
+	}
+
+	if len(results) == 0 && query.FailOnMissingKey {
+		return fmt.Errorf("%s didn't match", query.Regex)
+	}
+
+	query.Result = strings.Join(results, "\n")
+	query.Results = results
+	query.Values = matches
+	return nil
+}
+
+// Adapted from https://github.com/marshallford/terraform-provider-ansible/blob/main/pkg/ansible/utils.go#L25
+func jsonPath(blob interface{}, query *ArtifactQuery) error {
 	jsonPath := jsonpath.New(query.JSONPath)
 	jsonPath.AllowMissingKeys(!query.FailOnMissingKey)
 	jsonPath.EnableJSONOutput(query.JsonOutput)
 
 	err := jsonPath.Parse(fmt.Sprintf("{%s}", query.JSONPath))
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	fullResults, err := jsonPath.FindResults(blob)
+	if err != nil {
+		return err
 	}
 
 	output := new(bytes.Buffer)
-	if err := jsonPath.Execute(output, blob); err != nil {
-		return "", err
+	var results []string
+	var matches []interface{}
+	for _, values := range fullResults {
+		if err := jsonPath.PrintResults(output, values); err != nil {
+			return err
+		}
+
+		for _, value := range values {
+			matches = append(matches, value.Interface())
+
+			if query.JsonOutput {
+				encoded, err := json.Marshal(value.Interface())
+				if err != nil {
+					return err
+				}
+				results = append(results, string(encoded))
+				continue
+			}
+
+			// Format each value the same way as the joined result does
+			element := new(bytes.Buffer)
+			if err := jsonpath.New(query.JSONPath).PrintResults(element, []reflect.Value{value}); err != nil {
+				return err
+			}
+			results = append(results, element.String())
+		}
+	}
+
+	query.Result = output.String()
+	query.Results = results
+	query.Values = matches
+	return nil
+}
+
+func jqQuery(blob interface{}, query *ArtifactQuery) error {
+	parsed, err := gojq.Parse(query.JQ)
+	if err != nil {
+		return err
+	}
+
+	var results []string
+	var matches []interface{}
+	iter := parsed.Run(blob)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := value.(error); ok {
+			return err
+		}
+
+		if value == nil && query.FailOnMissingKey {
+			return fmt.Errorf("%s yielded null", query.JQ)
+		}
+		matches = append(matches, value)
+
+		if str, ok := value.(string); ok && !query.JsonOutput {
+			results = append(results, str)
+			continue
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		results = append(results, string(encoded))
+	}
+
+	if len(results) == 0 && query.FailOnMissingKey {
+		return fmt.Errorf("%s yielded no results", query.JQ)
 	}
 
-	return output.String(), nil
+	query.Result = strings.Join(results, "\n")
+	query.Results = results
+	query.Values = matches
+	return nil
 }
 
 // Adapted from https://github.com/marshallford/terraform-provider-ansible/blob/main/pkg/ansible/navigator_query.go#L9
 type ArtifactQuery struct {
 	JSONPath         string
+	JQ               string
+	Regex            string
+	Source           string
 	FailOnMissingKey bool
 	JsonOutput       bool
+	Type             string
 	Result           string
+	Results          []string
+	Values           []interface{}
+}
+
+// Decode a JSON document from r into generic values
+func decodeJSON(r io.Reader) (interface{}, error) {
+	var blob interface{}
+	err := json.NewDecoder(r).Decode(&blob)
+	return blob, err
+}
+
+// The largest artifact a regex query reads into memory. Bigger artifacts are
+// only queried through their decoded document, with JSONPath or jq
+const maxRegexArtifactSize = 64 << 20
+
+// Read the artifact for a regex query, up to maxRegexArtifactSize
+func readArtifactForRegex(artifact *Artifact) ([]byte, error) {
+	if size := artifact.source.Size(); size > maxRegexArtifactSize {
+		return nil, fmt.Errorf("the artifact is %d bytes, more than the %d bytes a regex query reads, use a JSONPath or jq query instead", size, maxRegexArtifactSize)
+	}
+	return io.ReadAll(artifact.Reader())
 }
 
-func QueryPlaybookArtifact(stdout bytes.Buffer, queries map[string]ArtifactQuery) error {
+// Run the queries against the artifact, or stderr for their source. Each
+// source is decoded at most once, however many queries read it
+func QueryPlaybookArtifact(artifact *Artifact, stderr []byte, queries map[string]ArtifactQuery) error {
+	var stderrDocument interface{}
+	var stderrErr error
+	stderrDecoded := false
+	document := func(query ArtifactQuery) (interface{}, error) {
+		if query.Source != "stderr" {
+			return artifact.Document()
+		}
+		if !stderrDecoded {
+			stderrDecoded = true
+			stderrDocument, stderrErr = decodeJSON(bytes.NewReader(stderr))
+		}
+		return stderrDocument, stderrErr
+	}
 
+	var artifactData []byte
 	for name, query := range queries {
-		result, err := jsonPath(stdout.Bytes(), query)
-		if err != nil {
-			return fmt.Errorf("failed to query playbook artifact with JSONPath, %w", err)
+		if len(query.Regex) > 0 {
+			data, err := stderr, error(nil)
+			if query.Source != "stderr" {
+				if artifactData == nil {
+					artifactData, err = readArtifactForRegex(artifact)
+				}
+				data = artifactData
+			}
+			if err == nil {
+				err = regexQuery(data, &query)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to query playbook %s with regex, %w", query.Source, err)
+			}
+		} else if len(query.JQ) > 0 {
+			blob, err := document(query)
+			if err == nil {
+				err = jqQuery(blob, &query)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to query playbook artifact with jq, %w", err)
+			}
+		} else {
+			blob, err := document(query)
+			if err == nil {
+				err = jsonPath(blob, &query)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to query playbook artifact with JSONPath, %w", err)
+			}
 		}
 
-		query.Result = result
 		queries[name] = query
 	}
 
@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var truncationMarkerRegexp = regexp.MustCompile(`\n\n\[\.\.\. (\d+) bytes truncated \.\.\.\]\n\n`)
+
+func TestTruncateOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		maxSize   int64
+		truncated bool
+	}{
+		{name: "unlimited", output: strings.Repeat("a", 1000), maxSize: 0, truncated: false},
+		{name: "fits", output: strings.Repeat("a", 100), maxSize: 100, truncated: false},
+		{name: "ASCII", output: strings.Repeat("a", 1000), maxSize: 100, truncated: true},
+		{name: "large", output: strings.Repeat("a", 100000), maxSize: 1000, truncated: true},
+		{name: "multi-byte characters", output: strings.Repeat("äö€😀", 100), maxSize: 101, truncated: true},
+		{name: "no room for the marker", output: strings.Repeat("€", 100), maxSize: 10, truncated: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, truncated := TruncateOutput(test.output, test.maxSize)
+			if truncated != test.truncated {
+				t.Fatalf("truncated = %t, want %t", truncated, test.truncated)
+			}
+			if !truncated {
+				if got != test.output {
+					t.Errorf("changed output that fits")
+				}
+				return
+			}
+
+			if int64(len(got)) > test.maxSize {
+				t.Errorf("%d bytes, more than %d", len(got), test.maxSize)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("invalid UTF-8: %q", got)
+			}
+
+			marker := truncationMarkerRegexp.FindStringSubmatchIndex(got)
+			if marker == nil {
+				if !strings.HasPrefix(test.output, got) {
+					t.Errorf("%q isn't the start of the output", got)
+				}
+				return
+			}
+
+			head, tail := got[:marker[0]], got[marker[1]:]
+			if !strings.HasPrefix(test.output, head) || !strings.HasSuffix(test.output, tail) {
+				t.Errorf("%q isn't the start and end of the output", got)
+			}
+			dropped, _ := strconv.Atoi(got[marker[2]:marker[3]])
+			if want := len(test.output) - len(head) - len(tail); dropped != want {
+				t.Errorf("marker of %d bytes, %d were dropped", dropped, want)
+			}
+		})
+	}
+}
+
+func TestQueryPlaybookArtifact(t *testing.T) {
+	output := `{"plays": [{"play": {"name": "web"}, "tasks": []}], "stats": {"web1": {"ok": 2}}}`
+	artifact := ParseArtifact(io.NewSectionReader(strings.NewReader(output), 0, int64(len(output))))
+	stderr := []byte(`{"warnings": ["first", "second"]}`)
+
+	tests := []struct {
+		name    string
+		query   ArtifactQuery
+		results []string
+		err     bool
+	}{
+		{name: "jsonpath", query: ArtifactQuery{JSONPath: "$.plays[0].play.name", Source: "artifact"}, results: []string{"web"}},
+		{name: "jq", query: ArtifactQuery{JQ: ".stats.web1.ok", Source: "artifact"}, results: []string{"2"}},
+		{name: "regex", query: ArtifactQuery{Regex: `"name": "(\w+)"`, Source: "artifact"}, results: []string{"web"}},
+		{name: "stderr jq", query: ArtifactQuery{JQ: ".warnings[]", Source: "stderr"}, results: []string{"first", "second"}},
+		{name: "stderr regex", query: ArtifactQuery{Regex: `"(s\w+)"`, Source: "stderr"}, results: []string{"second"}},
+		{name: "invalid regex", query: ArtifactQuery{Regex: `(`, Source: "artifact"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queries := map[string]ArtifactQuery{"q": test.query}
+			err := QueryPlaybookArtifact(artifact, stderr, queries)
+			if (err != nil) != test.err {
+				t.Fatalf("QueryPlaybookArtifact() error = %v, want error %v", err, test.err)
+			}
+			if err == nil && !slices.Equal(queries["q"].Results, test.results) {
+				t.Errorf("Results = %q, want %q", queries["q"].Results, test.results)
+			}
+		})
+	}
+}
+
+func TestQueryPlaybookArtifactRegexSizeLimit(t *testing.T) {
+	// A sparse section reader stands in for an artifact over the limit
+	size := int64(maxRegexArtifactSize + 1)
+	artifact := &Artifact{source: io.NewSectionReader(strings.NewReader(""), 0, size)}
+	queries := map[string]ArtifactQuery{"q": {Regex: "x", Source: "artifact"}}
+	if err := QueryPlaybookArtifact(artifact, nil, queries); err == nil {
+		t.Fatal("QueryPlaybookArtifact() succeeded on an artifact over the regex size limit")
+	}
+}
@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	vaultHeader     = "$ANSIBLE_VAULT"
+	vaultIterations = 10000
+	vaultSaltSize   = 32
+	vaultLineLength = 80
+)
+
+// The AES key, HMAC key and counter IV of the vault format 1.1/1.2
+func vaultKeys(password []byte, salt []byte) ([]byte, []byte, []byte) {
+	derived := pbkdf2.Key(password, salt, vaultIterations, 2*32+aes.BlockSize, sha256.New)
+	return derived[:32], derived[32:64], derived[64:]
+}
+
+// Encrypt plaintext the way ansible-vault does, with AES256 in CTR mode and
+// a HMAC-SHA256. A non-empty vaultId results in the 1.2 format.
+//
+// The salt is derived from the password, vault ID and plaintext instead of
+// being random, so the same input always gives the same result. That keeps
+// the output stable between plan and apply, at the cost of revealing when
+// two vaulted values are equal.
+func VaultEncrypt(plaintext []byte, password []byte, vaultId string) (string, error) {
+	if len(password) == 0 {
+		return "", fmt.Errorf("password must not be empty")
+	}
+	if strings.ContainsAny(vaultId, ";\n") {
+		return "", fmt.Errorf("vault ID must not contain ';' or line breaks")
+	}
+
+	saltMac := hmac.New(sha256.New, password)
+	saltMac.Write([]byte(vaultId))
+	saltMac.Write([]byte{0})
+	saltMac.Write(plaintext)
+	salt := saltMac.Sum(nil)[:vaultSaltSize]
+
+	cipherKey, hmacKey, iv := vaultKeys(password, salt)
+
+	// PKCS#7 padding, as ansible pads even though CTR wouldn't need it
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append([]byte{}, plaintext...)
+	for i := 0; i < padding; i++ {
+		padded = append(padded, byte(padding))
+	}
+
+	block, err := aes.NewCipher(cipherKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, padded)
+
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(ciphertext)
+
+	body := hex.EncodeToString([]byte(hex.EncodeToString(salt) + "\n" + hex.EncodeToString(mac.Sum(nil)) + "\n" + hex.EncodeToString(ciphertext)))
+
+	header := vaultHeader + ";1.1;AES256"
+	if len(vaultId) > 0 {
+		header = vaultHeader + ";1.2;AES256;" + vaultId
+	}
+
+	lines := []string{header}
+	for len(body) > vaultLineLength {
+		lines = append(lines, body[:vaultLineLength])
+		body = body[vaultLineLength:]
+	}
+	lines = append(lines, body)
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// Format an encrypted vault as a YAML `!vault` value, like
+// `ansible-vault encrypt_string` does.
+func VaultYAMLBlock(vault string) string {
+	lines := strings.Split(strings.TrimRight(vault, "\n"), "\n")
+	return "!vault |\n          " + strings.Join(lines, "\n          ")
+}
+
+// Decrypt a vault in the 1.1 or 1.2 format, either as produced by
+// ansible-vault or as a YAML `!vault` value.
+func VaultDecrypt(vault string, password []byte) ([]byte, error) {
+	var lines []string
+	for _, line := range strings.Split(vault, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || (len(lines) == 0 && strings.HasPrefix(line, "!vault")) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("not an ansible vault")
+	}
+
+	header := strings.Split(lines[0], ";")
+	if header[0] != vaultHeader || len(header) < 3 {
+		return nil, fmt.Errorf("not an ansible vault, expected a %s header", vaultHeader)
+	}
+	if header[1] != "1.1" && header[1] != "1.2" {
+		return nil, fmt.Errorf("unsupported vault format version %s", header[1])
+	}
+	if strings.TrimSpace(header[2]) != "AES256" {
+		return nil, fmt.Errorf("unsupported vault cipher %s", header[2])
+	}
+
+	body, err := hex.DecodeString(strings.Join(lines[1:], ""))
+	if err != nil {
+		return nil, fmt.Errorf("malformed vault: %s", err)
+	}
+	parts := strings.Split(string(body), "\n")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed vault")
+	}
+
+	var decoded [3][]byte
+	for i, part := range parts {
+		if decoded[i], err = hex.DecodeString(part); err != nil {
+			return nil, fmt.Errorf("malformed vault: %s", err)
+		}
+	}
+	salt, expectedMac, ciphertext := decoded[0], decoded[1], decoded[2]
+
+	cipherKey, hmacKey, iv := vaultKeys(password, salt)
+
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(ciphertext)
+	if !hmac.Equal(mac.Sum(nil), expectedMac) {
+		return nil, fmt.Errorf("decryption failed, the password is wrong or the vault was modified")
+	}
+
+	block, err := aes.NewCipher(cipherKey)
+	if err != nil {
+		return nil, err
+	}
+	padded := make([]byte, len(ciphertext))
+	cipher.NewCTR(block, iv).XORKeyStream(padded, ciphertext)
+
+	if len(padded) == 0 {
+		return nil, fmt.Errorf("malformed vault: invalid padding")
+	}
+	padding := int(padded[len(padded)-1])
+	if padding == 0 || padding > aes.BlockSize || padding > len(padded) {
+		return nil, fmt.Errorf("malformed vault: invalid padding")
+	}
+	return padded[:len(padded)-padding], nil
+}
+
+// The vault ID label of a vault in the 1.2 format, or "" if it has none
+func VaultLabel(vault string) string {
+	for _, line := range strings.Split(vault, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, vaultHeader) {
+			continue
+		}
+		header := strings.Split(line, ";")
+		if len(header) > 3 {
+			return strings.TrimSpace(header[3])
+		}
+		return ""
+	}
+	return ""
+}
+
+// Re-encrypt a vault with a new password, like `ansible-vault rekey`. The
+// vault ID label is kept.
+func VaultRekey(vault string, oldPassword []byte, newPassword []byte) (string, error) {
+	plaintext, err := VaultDecrypt(vault, oldPassword)
+	if err != nil {
+		return "", err
+	}
+	return VaultEncrypt(plaintext, newPassword, VaultLabel(vault))
+}
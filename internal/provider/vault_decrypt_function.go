@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &VaultDecryptFunction{}
+
+func NewVaultDecryptFunction() function.Function {
+	return &VaultDecryptFunction{}
+}
+
+type VaultDecryptFunction struct {
+}
+
+func (f *VaultDecryptFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "vault_decrypt"
+}
+
+func (f *VaultDecryptFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Decrypt a value encrypted with Ansible Vault",
+		MarkdownDescription: "Decrypts a value encrypted with Ansible Vault. Accepts both the output of `ansible-vault` and `!vault` YAML blocks.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "ciphertext",
+				MarkdownDescription: "The encrypted value, starting with `$ANSIBLE_VAULT` or `!vault`.",
+			},
+			function.StringParameter{
+				Name:                "password",
+				MarkdownDescription: "The vault password.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *VaultDecryptFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var ciphertext, password string
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &ciphertext, &password))
+	if resp.Error != nil {
+		return
+	}
+
+	plaintext, err := VaultDecrypt(ciphertext, []byte(password))
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, string(plaintext)))
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &VaultEncryptFunction{}
+
+func NewVaultEncryptFunction() function.Function {
+	return &VaultEncryptFunction{}
+}
+
+type VaultEncryptFunction struct {
+}
+
+func (f *VaultEncryptFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "vault_encrypt"
+}
+
+func (f *VaultEncryptFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Encrypt a value with Ansible Vault",
+		MarkdownDescription: "Encrypts a value like `ansible-vault encrypt_string` and returns it as a `!vault` YAML block, e.g. for generated group_vars. The result only depends on the arguments, so encrypting the same value with the same password always returns the same block.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "plaintext",
+				MarkdownDescription: "The value to encrypt.",
+			},
+			function.StringParameter{
+				Name:                "password",
+				MarkdownDescription: "The vault password.",
+			},
+			function.StringParameter{
+				Name:                "vault_id",
+				MarkdownDescription: "The vault ID label, e.g. `prod`. `null` or `\"\"` for none.",
+				AllowNullValue:      true,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *VaultEncryptFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var plaintext, password string
+	var vaultId types.String
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &plaintext, &password, &vaultId))
+	if resp.Error != nil {
+		return
+	}
+
+	vault, err := VaultEncrypt([]byte(plaintext), []byte(password), vaultId.ValueString())
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, VaultYAMLBlock(vault)))
+}
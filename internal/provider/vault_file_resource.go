@@ -0,0 +1,258 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &VaultFileResource{}
+var _ resource.ResourceWithValidateConfig = &VaultFileResource{}
+
+func NewVaultFileResource() resource.Resource {
+	return &VaultFileResource{}
+}
+
+type VaultFileResource struct {
+}
+
+// VaultFileResourceModel describes the resource data model.
+type VaultFileResourceModel struct {
+	Path           types.String `tfsdk:"path"`
+	Content        types.String `tfsdk:"content"`
+	Password       types.String `tfsdk:"password"`
+	VaultId        types.String `tfsdk:"vault_id"`
+	FilePermission types.String `tfsdk:"file_permission"`
+	Id             types.String `tfsdk:"id"`
+}
+
+func (r *VaultFileResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_vault_file"
+}
+
+func (r *VaultFileResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages a file encrypted with Ansible Vault, e.g. generated group_vars with secrets. The file is compared with `content` on every refresh, so it's rewritten if it was modified, and removed on destroy. When only the password changes, the file is rekeyed.",
+
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				MarkdownDescription: "Path of the encrypted file. Missing parent directories are created.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"content": schema.StringAttribute{
+				MarkdownDescription: "The content to encrypt.",
+				Required:            true,
+				Sensitive:           true,
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "The vault password.",
+				Required:            true,
+				Sensitive:           true,
+			},
+			"vault_id": schema.StringAttribute{
+				MarkdownDescription: "The vault ID label, e.g. `prod`, to encrypt the file in the 1.2 format with.",
+				Optional:            true,
+			},
+			"file_permission": schema.StringAttribute{
+				MarkdownDescription: "Permissions of the file, in octal. Defaults to `0600`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("0600"),
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The path of the file.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *VaultFileResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config VaultFileResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.FilePermission.IsNull() && !config.FilePermission.IsUnknown() {
+		if _, err := parseFilePermission(config.FilePermission.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("file_permission"), "Invalid file permission", err.Error())
+		}
+	}
+}
+
+func parseFilePermission(permission string) (fs.FileMode, error) {
+	mode, err := strconv.ParseUint(permission, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("%q isn't an octal permission like 0600", permission)
+	}
+	return fs.FileMode(mode), nil
+}
+
+// Write the vault to the file of the resource. It's written to a temporary
+// file next to it first, so it's never left half written.
+func writeVaultFile(data *VaultFileResourceModel, vault string) error {
+	mode, err := parseFilePermission(data.FilePermission.ValueString())
+	if err != nil {
+		return err
+	}
+
+	filePath := data.Path.ValueString()
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(vault); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filePath)
+}
+
+func (r *VaultFileResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data VaultFileResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vault, err := VaultEncrypt([]byte(data.Content.ValueString()), []byte(data.Password.ValueString()), data.VaultId.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to encrypt the content", err.Error())
+		return
+	}
+	if err := writeVaultFile(&data, vault); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("path"), "Failed to write the vault file", err.Error())
+		return
+	}
+
+	data.Id = data.Path
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *VaultFileResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data VaultFileResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vault, err := os.ReadFile(data.Path.ValueString())
+	if errors.Is(err, fs.ErrNotExist) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("path"), "Failed to read the vault file", err.Error())
+		return
+	}
+
+	// A file that can't be decrypted anymore was modified, it's rewritten
+	// by the next apply like one with a different content
+	plaintext, err := VaultDecrypt(string(vault), []byte(data.Password.ValueString()))
+	if err != nil {
+		data.Content = types.StringValue("")
+	} else {
+		data.Content = types.StringValue(string(plaintext))
+	}
+
+	if label := VaultLabel(string(vault)); len(label) > 0 || !data.VaultId.IsNull() {
+		data.VaultId = types.StringValue(label)
+	}
+
+	if info, err := os.Stat(data.Path.ValueString()); err == nil {
+		data.FilePermission = types.StringValue(fmt.Sprintf("%04o", info.Mode().Perm()))
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *VaultFileResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, state VaultFileResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var vault string
+	var err error
+	if data.Content.Equal(state.Content) && data.VaultId.Equal(state.VaultId) && !data.Password.Equal(state.Password) {
+		// Only the password changed
+		var current []byte
+		current, err = os.ReadFile(data.Path.ValueString())
+		if err == nil {
+			vault, err = VaultRekey(string(current), []byte(state.Password.ValueString()), []byte(data.Password.ValueString()))
+		}
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("path"), "Failed to rekey the vault file", err.Error())
+			return
+		}
+	} else {
+		vault, err = VaultEncrypt([]byte(data.Content.ValueString()), []byte(data.Password.ValueString()), data.VaultId.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to encrypt the content", err.Error())
+			return
+		}
+	}
+
+	if err := writeVaultFile(&data, vault); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("path"), "Failed to write the vault file", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *VaultFileResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data VaultFileResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := os.Remove(data.Path.ValueString()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		resp.Diagnostics.AddAttributeError(path.Root("path"), "Failed to remove the vault file", err.Error())
+	}
+}
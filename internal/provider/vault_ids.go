@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type VaultIdModel struct {
+	PasswordFile types.String `tfsdk:"password_file"`
+	PasswordEnv  types.String `tfsdk:"password_env"`
+	Password     types.String `tfsdk:"password"`
+}
+
+func (VaultIdModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"password_file": types.StringType,
+		"password_env":  types.StringType,
+		"password":      types.StringType,
+	}
+}
+
+// The `--vault-id label@source` arguments of the vault IDs, sorted by label.
+// Passwords from the environment or inline are written to temporary files,
+// which are returned to be removed, along with the passwords to redact.
+func VaultIdArgs(ctx context.Context, vaultIds types.Map, diags *diag.Diagnostics) ([]string, []string, []string) {
+	var models map[string]VaultIdModel
+	diags.Append(vaultIds.ElementsAs(ctx, &models, false)...)
+	if diags.HasError() || len(models) == 0 {
+		return nil, nil, nil
+	}
+
+	labels := make([]string, 0, len(models))
+	for label := range models {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var args, tempFiles, secrets []string
+	for _, label := range labels {
+		model := models[label]
+
+		source := model.PasswordFile.ValueString()
+		if model.PasswordFile.IsNull() {
+			password := model.Password.ValueString()
+			if !model.PasswordEnv.IsNull() {
+				var found bool
+				password, found = os.LookupEnv(model.PasswordEnv.ValueString())
+				if !found {
+					diags.AddAttributeError(path.Root("vault_ids").AtMapKey(label).AtName("password_env"), "Vault password not set",
+						fmt.Sprintf("The environment variable %s isn't set.", model.PasswordEnv.ValueString()))
+					continue
+				}
+			}
+
+			source = WriteTempFile(ctx, "vault-password-*", password, diags)
+			if len(source) > 0 {
+				tempFiles = append(tempFiles, source)
+			}
+			secrets = append(secrets, password)
+		}
+
+		args = append(args, "--vault-id", label+"@"+source)
+	}
+
+	return args, tempFiles, secrets
+}
+
+func validateVaultIds(ctx context.Context, vaultIds types.Map, diags *diag.Diagnostics) {
+	var models map[string]VaultIdModel
+	diags.Append(vaultIds.ElementsAs(ctx, &models, false)...)
+
+	for label, model := range models {
+		if strings.Contains(label, "@") {
+			diags.AddAttributeError(path.Root("vault_ids").AtMapKey(label), "Invalid vault ID", "The label must not contain '@'.")
+		}
+
+		if model.PasswordFile.IsUnknown() || model.PasswordEnv.IsUnknown() || model.Password.IsUnknown() {
+			continue
+		}
+		sources := 0
+		for _, source := range []types.String{model.PasswordFile, model.PasswordEnv, model.Password} {
+			if !source.IsNull() {
+				sources++
+			}
+		}
+		if sources != 1 {
+			diags.AddAttributeError(path.Root("vault_ids").AtMapKey(label), "Invalid vault ID",
+				"Exactly one of password_file, password_env and password must be set.")
+		}
+	}
+}
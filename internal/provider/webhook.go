@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const webhookTimeout = 30 * time.Second
+
+type NotifyWebhookModel struct {
+	URL             types.String `tfsdk:"url"`
+	Headers         types.Map    `tfsdk:"headers"`
+	PayloadTemplate types.String `tfsdk:"payload_template"`
+}
+
+func (NotifyWebhookModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"url":              types.StringType,
+		"headers":          types.MapType{ElemType: types.StringType},
+		"payload_template": types.StringType,
+	}
+}
+
+type NotifyWebhook struct {
+	URL             string
+	Headers         map[string]string
+	PayloadTemplate string
+}
+
+func (m NotifyWebhookModel) Value(ctx context.Context, webhook *NotifyWebhook) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	webhook.URL = m.URL.ValueString()
+	webhook.PayloadTemplate = m.PayloadTemplate.ValueString()
+	diags.Append(m.Headers.ElementsAs(ctx, &webhook.Headers, false)...)
+
+	return diags
+}
+
+// The summary of a run sent to the webhook, and the data of the payload
+// template
+type RunNotification struct {
+	Id          string             `json:"id"`
+	Playbook    string             `json:"playbook"`
+	Status      string             `json:"status"`
+	ExitCode    int                `json:"exit_code"`
+	Stats       Stats              `json:"stats"`
+	FailedTasks []NotificationTask `json:"failed_tasks"`
+}
+
+type NotificationTask struct {
+	Play        string `json:"play"`
+	Task        string `json:"task"`
+	Host        string `json:"host"`
+	Unreachable bool   `json:"unreachable"`
+	Details     string `json:"details"`
+}
+
+// Build the notification of a run from its decoded artifact. Failed task
+// details are redacted.
+func NewRunNotification(id string, playbook string, failed bool, exitCode int, root Root, redactor *Redactor) RunNotification {
+	notification := RunNotification{
+		Id:          id,
+		Playbook:    playbook,
+		Status:      "ok",
+		ExitCode:    exitCode,
+		Stats:       Stats{},
+		FailedTasks: []NotificationTask{},
+	}
+	if failed {
+		notification.Status = "failed"
+	}
+
+	if root.Stats != nil {
+		notification.Stats = root.Stats
+	}
+
+	failures, _ := root.Failures()
+	for _, failure := range failures {
+		notification.FailedTasks = append(notification.FailedTasks, NotificationTask{
+			Play:        failure.Play,
+			Task:        failure.Task,
+			Host:        failure.Host,
+			Unreachable: failure.Unreachable,
+			Details:     redactor.Redact(failure.Details),
+		})
+	}
+
+	return notification
+}
+
+// Render the payload, either as JSON or with the Go template of the webhook.
+// Templates can use the `json` function to encode values.
+func (w NotifyWebhook) Payload(notification RunNotification) ([]byte, error) {
+	if len(w.PayloadTemplate) == 0 {
+		return json.Marshal(notification)
+	}
+
+	tmpl, err := template.New("payload").Funcs(template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			encoded, err := json.Marshal(value)
+			return string(encoded), err
+		},
+	}).Parse(w.PayloadTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload bytes.Buffer
+	if err := tmpl.Execute(&payload, notification); err != nil {
+		return nil, err
+	}
+	return payload.Bytes(), nil
+}
+
+// POST the notification to the webhook
+func (w NotifyWebhook) Send(ctx context.Context, notification RunNotification) error {
+	payload, err := w.Payload(notification)
+	if err != nil {
+		return fmt.Errorf("failed to render the payload: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("the webhook responded with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
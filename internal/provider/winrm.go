@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+var winrmTransports = []string{"basic", "certificate", "ntlm", "kerberos", "credssp"}
+var winrmCertValidations = []string{"validate", "ignore"}
+var winrmSchemes = []string{"http", "https"}
+var winrmMessageEncryptions = []string{"auto", "always", "never"}
+
+type WinRMModel struct {
+	Port                 types.Int64  `tfsdk:"port"`
+	Scheme               types.String `tfsdk:"scheme"`
+	Transport            types.String `tfsdk:"transport"`
+	ServerCertValidation types.String `tfsdk:"server_cert_validation"`
+	CACertPath           types.String `tfsdk:"ca_cert_path"`
+	User                 types.String `tfsdk:"user"`
+	Password             types.String `tfsdk:"password"`
+	KerberosDelegation   types.Bool   `tfsdk:"kerberos_delegation"`
+	MessageEncryption    types.String `tfsdk:"message_encryption"`
+	ReadTimeout          types.Int64  `tfsdk:"read_timeout"`
+	OperationTimeout     types.Int64  `tfsdk:"operation_timeout"`
+}
+
+func (WinRMModel) AttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"port":                   types.Int64Type,
+		"scheme":                 types.StringType,
+		"transport":              types.StringType,
+		"server_cert_validation": types.StringType,
+		"ca_cert_path":           types.StringType,
+		"user":                   types.StringType,
+		"password":               types.StringType,
+		"kerberos_delegation":    types.BoolType,
+		"message_encryption":     types.StringType,
+		"read_timeout":           types.Int64Type,
+		"operation_timeout":      types.Int64Type,
+	}
+}
+
+// The connection variables of the WinRM settings. Unset attributes are left
+// out, so ansible's defaults or the inventory apply.
+func (m WinRMModel) Vars() map[string]interface{} {
+	vars := map[string]interface{}{"ansible_connection": "winrm"}
+
+	setString := func(name string, value types.String) {
+		if !value.IsNull() && !value.IsUnknown() {
+			vars[name] = value.ValueString()
+		}
+	}
+	setInt64 := func(name string, value types.Int64) {
+		if !value.IsNull() && !value.IsUnknown() {
+			vars[name] = value.ValueInt64()
+		}
+	}
+
+	setInt64("ansible_port", m.Port)
+	setString("ansible_winrm_scheme", m.Scheme)
+	setString("ansible_winrm_transport", m.Transport)
+	setString("ansible_winrm_server_cert_validation", m.ServerCertValidation)
+	setString("ansible_winrm_ca_trust_path", m.CACertPath)
+	setString("ansible_user", m.User)
+	setString("ansible_password", m.Password)
+	if !m.KerberosDelegation.IsNull() && !m.KerberosDelegation.IsUnknown() {
+		vars["ansible_winrm_kerberos_delegation"] = m.KerberosDelegation.ValueBool()
+	}
+	setString("ansible_winrm_message_encryption", m.MessageEncryption)
+	setInt64("ansible_winrm_read_timeout_sec", m.ReadTimeout)
+	setInt64("ansible_winrm_operation_timeout_sec", m.OperationTimeout)
+
+	return vars
+}
+
+func validateWinRM(ctx context.Context, value types.Object, diags *diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return
+	}
+
+	var winrm WinRMModel
+	diags.Append(value.As(ctx, &winrm, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return
+	}
+
+	validateOneOf(path.Root("winrm").AtName("transport"), winrm.Transport, winrmTransports, diags)
+	validateOneOf(path.Root("winrm").AtName("server_cert_validation"), winrm.ServerCertValidation, winrmCertValidations, diags)
+	validateOneOf(path.Root("winrm").AtName("scheme"), winrm.Scheme, winrmSchemes, diags)
+	validateOneOf(path.Root("winrm").AtName("message_encryption"), winrm.MessageEncryption, winrmMessageEncryptions, diags)
+
+	if !winrm.Port.IsNull() && !winrm.Port.IsUnknown() && (winrm.Port.ValueInt64() < 1 || winrm.Port.ValueInt64() > 65535) {
+		diags.AddAttributeError(path.Root("winrm").AtName("port"), "Invalid port", "port must be between 1 and 65535.")
+	}
+}
+
+// Report an error on attribute, if value isn't one of values
+func validateOneOf(attribute path.Path, value types.String, values []string, diags *diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() || slices.Contains(values, value.ValueString()) {
+		return
+	}
+	diags.AddAttributeError(attribute, "Invalid value", fmt.Sprintf("Must be one of %s.", strings.Join(values, ", ")))
+}